@@ -0,0 +1,48 @@
+//+build !nofuse
+
+package cgofuse
+
+import (
+	"sync/atomic"
+
+	"github.com/ipfs/go-ipfs/filesystem/manager"
+	logging "github.com/ipfs/go-log"
+)
+
+// activity counts the operations of a host binding
+// (fields are accessed atomically)
+type activity struct {
+	bytesRead, bytesWritten uint64
+	errors                  uint64
+}
+
+func (ac *activity) countRead(count int)    { atomic.AddUint64(&ac.bytesRead, uint64(count)) }
+func (ac *activity) countWritten(count int) { atomic.AddUint64(&ac.bytesWritten, uint64(count)) }
+
+// countingLogger counts the errors logged by the host
+// (operations log one error when they fail)
+type countingLogger struct {
+	logging.EventLogger
+	errors *uint64
+}
+
+func (cl countingLogger) Error(args ...interface{}) {
+	atomic.AddUint64(cl.errors, 1)
+	cl.EventLogger.Error(args...)
+}
+
+func (cl countingLogger) Errorf(format string, args ...interface{}) {
+	atomic.AddUint64(cl.errors, 1)
+	cl.EventLogger.Errorf(format, args...)
+}
+
+// Activity reports the open references and operation counts of the binding
+func (fs *hostBinding) Activity() manager.Activity {
+	return manager.Activity{
+		OpenFiles:       fs.files.Length(),
+		OpenDirectories: fs.directories.Length(),
+		BytesRead:       atomic.LoadUint64(&fs.bytesRead),
+		BytesWritten:    atomic.LoadUint64(&fs.bytesWritten),
+		Errors:          atomic.LoadUint64(&fs.errors),
+	}
+}
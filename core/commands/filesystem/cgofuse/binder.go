@@ -19,21 +19,16 @@ import (
 // TODO: migrate the rest of this file
 
 // cgofuseBinder mounts requests in the host FS via the Fuse API
+// each request is bound to its own host interface, so that its activity may be reported separately
 type cgofuseBinder struct {
-	ctx    context.Context
-	goFs   filesystem.Interface
-	fuseFs fuselib.FileSystemInterface
+	ctx  context.Context
+	goFs filesystem.Interface
 }
 
 func NewBinder(ctx context.Context, fs filesystem.Interface) (manager.Binder, error) {
-	fsi, err := NewFuseInterface(fs)
-	if err != nil {
-		return nil, err
-	}
 	return &cgofuseBinder{
-		ctx:    ctx,
-		goFs:   fs,
-		fuseFs: fsi,
+		ctx:  ctx,
+		goFs: fs,
 	}, nil
 }
 
@@ -54,7 +49,7 @@ func (ca *cgofuseBinder) Bind(ctx context.Context, requests manager.Requests) ma
 			if err != nil {
 				goto respond
 			}
-			response.Closer, response.Error = attachToHost(ca.fuseFs, oldRequest)
+			response.Closer, response.Error = attachToHost(newHostBinding(ca.goFs), oldRequest)
 
 		respond:
 			if err != nil {
@@ -104,7 +99,17 @@ func safeMount(hostInterface *fuselib.FileSystemHost, request Request, failFunc
 
 type closer func() error      // io.Closer closure wrapper
 func (f closer) Close() error { return f() }
-func attachToHost(fuseFS fuselib.FileSystemInterface, request Request) (instanceDetach io.Closer, err error) {
+
+// hostInstance detaches the binding from the host when closed,
+// and reports the binding's activity while attached
+type hostInstance struct {
+	closer
+	binding *hostBinding
+}
+
+func (hi hostInstance) Activity() manager.Activity { return hi.binding.Activity() }
+
+func attachToHost(fuseFS *hostBinding, request Request) (instanceDetach io.Closer, err error) {
 	hostInterface := fuselib.NewFileSystemHost(fuseFS)
 	hostInterface.SetCapReaddirPlus(canReaddirPlus)
 	hostInterface.SetCapCaseInsensitive(false)
@@ -189,7 +194,7 @@ func attachToHost(fuseFS fuselib.FileSystemInterface, request Request) (instance
 	// this means piping the index delete() all the way down to the FS.Destroy
 	// otherwise we double close on shutdown/unmount
 	// because FUSE closed the FS, but we were still tracking it in the FS manager
-	instanceDetach = closer(func() (err error) {
+	instanceDetach = hostInstance{binding: fuseFS, closer: func() (err error) {
 		// TODO: feed close errors back to constructor caller
 		// ^ old branch has (bad) code for this
 
@@ -199,7 +204,7 @@ func attachToHost(fuseFS fuselib.FileSystemInterface, request Request) (instance
 		}
 
 		return
-	})
+	}}
 
 	return
 }
@@ -3,20 +3,16 @@
 package cgofuse
 
 import (
-	fuselib "github.com/billziss-gh/cgofuse/fuse"
+	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
 )
 
 func (fs *hostBinding) Create(path string, flags int, mode uint32) (int, uint64) {
-	fs.log.Warnf("Create - {%X|%X}%q", flags, mode, path)
+	fs.log.Debugf("Create - {%X|%X}%q", flags, mode, path)
 
-	// TODO: why is fuselib passing us flags and what are they?
-	// both FUSE and SUS predefine what they should be (to Open)
-
-	//return hostBinding.open(path, fuselib.O_WRONLY|fuselib.O_CREAT|fuselib.O_TRUNC)
-
-	// disabled until we parse relevant flags in open
-	// fuse will do shenanigans to make this work
-	return -fuselib.ENOSYS, errorHandle
+	// fuselib passes us the flags the caller provided to `open`
+	// which should contain `O_CREAT`, but we make sure of it regardless
+	return fs.open(path, ioFlagsFromFuse(flags)|filesystem.IOCreate)
 }
 
 func (fs *hostBinding) Mknod(path string, mode uint32, dev uint64) int {
@@ -39,10 +35,22 @@ func (fs *hostBinding) Mkdir(path string, mode uint32) int {
 	return operationSuccess
 }
 
+// hard links share the node between both names, until either is modified
+func (fs *hostBinding) Link(oldpath, newpath string) int {
+	fs.log.Debugf("Link - HostRequest %q<->%q", oldpath, newpath)
+
+	if err := interfaceutils.HardLink(fs.nodeInterface, oldpath, newpath); err != nil {
+		fs.log.Error(err)
+		return interpretError(err)
+	}
+
+	return operationSuccess
+}
+
 func (fs *hostBinding) Symlink(target, newpath string) int {
 	fs.log.Debugf("Symlink - HostRequest %q->%q", newpath, target)
 
-	if err := fs.nodeInterface.MakeLink(target, newpath); err != nil {
+	if err := fs.nodeInterface.MakeLink(newpath, target); err != nil {
 		fs.log.Error(err)
 		return interpretError(err)
 	}
@@ -19,4 +19,7 @@ const (
 
 	IRWXA = S_IRWXU | S_IRWXG | S_IRWXO                                    // 0777
 	IRXA  = IRWXA &^ (fuselib.S_IWUSR | fuselib.S_IWGRP | fuselib.S_IWOTH) // 0555}
+
+	statfsBlockSize = 4096 // nominal block size used to report storage statistics
+	statfsNameMax   = 255
 )
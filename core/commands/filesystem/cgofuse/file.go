@@ -6,11 +6,15 @@ import (
 	"io"
 
 	fuselib "github.com/billziss-gh/cgofuse/fuse"
+	"github.com/ipfs/go-ipfs/filesystem"
 )
 
 func (fs *hostBinding) Open(path string, flags int) (int, uint64) {
 	fs.log.Debugf("Open - {%X}%q", flags, path)
+	return fs.open(path, ioFlagsFromFuse(flags))
+}
 
+func (fs *hostBinding) open(path string, flags filesystem.IOFlags) (int, uint64) {
 	switch path {
 	case "":
 		fs.log.Error(fuselib.Error(-fuselib.ENOENT))
@@ -20,7 +24,7 @@ func (fs *hostBinding) Open(path string, flags int) (int, uint64) {
 		return -fuselib.EISDIR, errorHandle
 	}
 
-	file, err := fs.nodeInterface.Open(path, ioFlagsFromFuse(flags))
+	file, err := fs.nodeInterface.Open(path, flags)
 	if err != nil {
 		fs.log.Error(err)
 		return interpretError(err), errorHandle
@@ -64,6 +68,9 @@ func (fs *hostBinding) Read(path string, buff []byte, ofst int64, fh uint64) int
 	if err != nil && err != io.EOF {
 		fs.log.Error(err)
 	}
+	if retVal > 0 {
+		fs.countRead(retVal)
+	}
 	return retVal
 }
 
@@ -85,5 +92,8 @@ func (fs *hostBinding) Write(path string, buff []byte, ofst int64, fh uint64) in
 	if err != nil && err != io.EOF {
 		fs.log.Error(err)
 	}
+	if errNo > 0 {
+		fs.countWritten(errNo)
+	}
 	return errNo
 }
@@ -9,13 +9,13 @@ import (
 	"strings"
 
 	fuselib "github.com/billziss-gh/cgofuse/fuse"
-	config "github.com/ipfs/go-ipfs-config"
-	"github.com/ipfs/go-ipfs/core/commands/filesystem/cgofuse/sys"
 	"github.com/ipfs/go-ipfs/filesystem"
 	logging "github.com/ipfs/go-log"
 )
 
 type hostBinding struct {
+	activity // (first, so that its counters are aligned for atomic access)
+
 	nodeInterface filesystem.Interface
 	log           logging.EventLogger // general operations log
 
@@ -29,11 +29,20 @@ type hostBinding struct {
 }
 
 func NewFuseInterface(fs filesystem.Interface) (fuselib.FileSystemInterface, error) {
+	return newHostBinding(fs), nil
+}
+
+func newHostBinding(fs filesystem.Interface) *hostBinding {
 	// TODO: migrate options interface from old branch
 	logName := strings.ToLower(gopath.Join("fuse", fs.ID().String()))
 	fuseInterface := &hostBinding{
 		nodeInterface: fs,
-		log:           logging.Logger(logName),
+		files:         newFileTable(),
+		directories:   newDirectoryTable(),
+	}
+	fuseInterface.log = countingLogger{
+		EventLogger: logging.Logger(logName),
+		errors:      &fuseInterface.errors,
 	}
 
 	// TODO: we need to provide a function Option for swapping out methods
@@ -42,23 +51,20 @@ func NewFuseInterface(fs filesystem.Interface) (fuselib.FileSystemInterface, err
 	switch fs.ID() {
 	case filesystem.PinFS, filesystem.IPFS:
 		fuseInterface.readdirplusGen = staticStat
-	case filesystem.KeyFS, filesystem.Files:
+	case filesystem.KeyFS, filesystem.Files, filesystem.RootFS:
 		fuseInterface.filesWritable = true
 		fallthrough
 	default:
 		fuseInterface.readdirplusGen = dynamicStat
 	}
 
-	return fuseInterface, nil
+	return fuseInterface
 }
 
 func (fs *hostBinding) Init() {
 	fs.log.Debugf("Init")
 	defer fs.log.Debugf("Init finished")
 
-	fs.files = newFileTable()
-	fs.directories = newDirectoryTable()
-
 	timeOfMount := fuselib.Now()
 
 	fs.mountTimeGroup = statTimeGroup{
@@ -83,25 +89,36 @@ func (fs *hostBinding) Destroy() {
 	*/
 }
 
+// Statfs reports the statistics of the node's repo (not the client's)
+// for systems which implement the (optional) extension
 func (fs *hostBinding) Statfs(path string, stat *fuselib.Statfs_t) int {
 	fs.log.Debugf("Statfs - HostRequest %q", path)
-	return -fuselib.ENOSYS
 
-	// FIXME: this works but needs to be done host side and somehow relayed to the client
-	// otherwise the client would be getting statistics for their repo, even when remote mounting
-	// (or errors if they don't have one)
+	stater, ok := fs.nodeInterface.(filesystem.StorageStater)
+	if !ok {
+		return -fuselib.ENOSYS
+	}
 
-	target, err := config.DataStorePath("")
+	storage, err := stater.StorageStat()
 	if err != nil {
-		fs.log.Errorf("Statfs - Config err %q: %v", path, err)
-		return -fuselib.ENOENT
+		fs.log.Error(err)
+		return interpretError(err)
 	}
 
-	errNo, err := sys.Statfs(target, stat)
-	if err != nil {
-		fs.log.Errorf("Statfs - err %q: %v", target, err)
+	var free uint64
+	if storage.Capacity > storage.Used {
+		free = storage.Capacity - storage.Used
 	}
-	return errNo
+
+	stat.Bsize, stat.Frsize = statfsBlockSize, statfsBlockSize
+	stat.Blocks = storage.Capacity / statfsBlockSize
+	stat.Bfree = free / statfsBlockSize
+	stat.Bavail = stat.Bfree
+	// objects are at least 1 block in size
+	stat.Files = storage.Objects + stat.Bfree
+	stat.Ffree = stat.Bfree
+	stat.Namemax = statfsNameMax
+	return operationSuccess
 }
 
 func (fs *hostBinding) Readlink(path string) (int, string) {
@@ -15,7 +15,7 @@ func (fs *hostBinding) Getattr(path string, stat *fuselib.Stat_t, fh uint64) int
 		return -fuselib.ENOENT
 	}
 
-	iStat, _, err := fs.nodeInterface.Info(path, filesystem.StatRequestAll)
+	iStat, filled, err := fs.nodeInterface.Info(path, filesystem.StatRequestAll)
 	if err != nil {
 		errNo := interpretError(err)
 		if errNo != -fuselib.ENOENT { // don't flood the logs with "not found" errors
@@ -28,5 +28,6 @@ func (fs *hostBinding) Getattr(path string, stat *fuselib.Stat_t, fh uint64) int
 	ids.uid, ids.gid, _ = fuselib.Getcontext()
 	applyIntermediateStat(stat, iStat)
 	applyCommonsToStat(stat, fs.filesWritable, fs.mountTimeGroup, ids)
+	applyMetadataToStat(stat, iStat, filled)
 	return operationSuccess
 }
@@ -0,0 +1,64 @@
+//+build !nofuse
+
+package cgofuse
+
+import (
+	"time"
+
+	fuselib "github.com/billziss-gh/cgofuse/fuse"
+	"github.com/ipfs/go-ipfs/filesystem"
+)
+
+// metadata is stored within nodes, by systems which implement the (optional) extension
+
+func (fs *hostBinding) Chmod(path string, mode uint32) int {
+	fs.log.Debugf("Chmod - {%o}%q", mode, path)
+
+	modifier, ok := fs.nodeInterface.(filesystem.MetadataModifier)
+	if !ok {
+		fs.log.Warnf("Chmod - HostRequest {%X}%q", mode, path)
+		return -fuselib.ENOSYS
+	}
+
+	if err := modifier.Chmod(path, mode); err != nil {
+		fs.log.Error(err)
+		return interpretError(err)
+	}
+	return operationSuccess
+}
+
+// `utimensat` sentinel values, passed through by the host within `Timespec.Nsec`
+// (as `UTIME_NOW` and `UTIME_OMIT`)
+const (
+	utimeNow  = (1 << 30) - 1
+	utimeOmit = (1 << 30) - 2
+)
+
+func (fs *hostBinding) Utimens(path string, tmsp []fuselib.Timespec) int {
+	fs.log.Debugf("Utimens - {%v}%q", tmsp, path)
+
+	modifier, ok := fs.nodeInterface.(filesystem.MetadataModifier)
+	if !ok {
+		fs.log.Warnf("Utimens - HostRequest {%v}%q", tmsp, path)
+		return -fuselib.ENOSYS
+	}
+
+	// [0] is access time, which we don't store
+	// if no times are provided, the current time is to be used
+	mtime := time.Now()
+	if len(tmsp) == 2 {
+		switch tmsp[1].Nsec {
+		case utimeOmit: // the modification time is to be left as is
+			return operationSuccess
+		case utimeNow: // (already set above)
+		default:
+			mtime = tmsp[1].Time()
+		}
+	}
+
+	if err := modifier.Chtimes(path, mtime); err != nil {
+		fs.log.Error(err)
+		return interpretError(err)
+	}
+	return operationSuccess
+}
@@ -0,0 +1,65 @@
+//+build !nofuse
+
+package cgofuse
+
+import (
+	fuselib "github.com/billziss-gh/cgofuse/fuse"
+	"github.com/ipfs/go-ipfs/filesystem"
+)
+
+// syncing is provided by systems which implement the (optional) extension
+// failures are reported to the caller as I/O errors, regardless of their kind
+
+func (fs *hostBinding) Flush(path string, fh uint64) int {
+	fs.log.Debugf("Flush - HostRequest {%X}%q", fh, path)
+
+	file, err := fs.files.Get(fh)
+	if err != nil {
+		fs.log.Error(err)
+		return -fuselib.EBADF
+	}
+
+	// flush is called on every close, so files without anything to commit succeed
+	syncer, ok := file.(filesystem.Syncer)
+	if !ok {
+		return operationSuccess
+	}
+	return fs.sync(syncer)
+}
+
+func (fs *hostBinding) Fsync(path string, datasync bool, fh uint64) int {
+	fs.log.Debugf("Fsync - HostRequest {%X|%t}%q", fh, datasync, path)
+
+	// use the handle if it's valid, otherwise sync the whole system
+	var syncer filesystem.Syncer
+	file, err := fs.files.Get(fh)
+	if err == nil {
+		syncer, _ = file.(filesystem.Syncer)
+	} else {
+		syncer, _ = fs.nodeInterface.(filesystem.Syncer)
+	}
+
+	if syncer == nil {
+		return -fuselib.ENOSYS
+	}
+	return fs.sync(syncer)
+}
+
+// directory modifications are committed by the system, not the directory itself
+func (fs *hostBinding) Fsyncdir(path string, datasync bool, fh uint64) int {
+	fs.log.Debugf("Fsyncdir - HostRequest {%X|%t}%q", fh, datasync, path)
+
+	syncer, ok := fs.nodeInterface.(filesystem.Syncer)
+	if !ok {
+		return -fuselib.ENOSYS
+	}
+	return fs.sync(syncer)
+}
+
+func (fs *hostBinding) sync(syncer filesystem.Syncer) errNo {
+	if err := syncer.Sync(); err != nil {
+		fs.log.Error(err)
+		return -fuselib.EIO
+	}
+	return operationSuccess
+}
@@ -93,6 +93,7 @@ type statIDGroup struct {
 func applyCommonsToStat(stat *fuselib.Stat_t, writable bool, tg statTimeGroup, ids statIDGroup) {
 	stat.Atim, stat.Mtim, stat.Ctim, stat.Birthtim = tg.atim, tg.mtim, tg.ctim, tg.birthtim
 	stat.Uid, stat.Gid = ids.uid, ids.gid
+	stat.Nlink = 1 // unless the system says otherwise
 
 	if writable {
 		stat.Mode |= IRWXA &^ (fuselib.S_IWOTH | fuselib.S_IXOTH) // |0774
@@ -230,6 +231,22 @@ func applyIntermediateStat(fStat *fuselib.Stat_t, iStat *filesystem.Stat) {
 	fStat.Blocks = int64(iStat.Blocks)
 }
 
+// applyMetadataToStat overrides the common permission and time values
+// with the node's own metadata (if it has any)
+func applyMetadataToStat(fStat *fuselib.Stat_t, iStat *filesystem.Stat, filled filesystem.StatRequest) {
+	if filled.Mode {
+		fStat.Mode = (fStat.Mode & fuselib.S_IFMT) | (iStat.Mode & 07777)
+	}
+	if filled.MTime {
+		// UFS doesn't store change times, so we treat modifications as the last change
+		fStat.Mtim = fuselib.NewTimespec(iStat.MTime)
+		fStat.Ctim = fStat.Mtim
+	}
+	if filled.Links {
+		fStat.Nlink = uint32(iStat.Links)
+	}
+}
+
 type fuseFileType = uint32
 
 func coreTypeToFuseType(ct coreiface.FileType) fuseFileType {
@@ -246,20 +263,35 @@ func coreTypeToFuseType(ct coreiface.FileType) fuseFileType {
 }
 
 func ioFlagsFromFuse(fuseFlags int) filesystem.IOFlags {
+	var ioFlags filesystem.IOFlags
 	switch fuseFlags & fuselib.O_ACCMODE {
 	case fuselib.O_RDONLY:
-		return filesystem.IOReadOnly
+		ioFlags = filesystem.IOReadOnly
 	case fuselib.O_WRONLY:
-		return filesystem.IOWriteOnly
+		ioFlags = filesystem.IOWriteOnly
 	case fuselib.O_RDWR:
-		return filesystem.IOReadWrite
+		ioFlags = filesystem.IOReadWrite
 	default:
 		return filesystem.IOFlags(0)
 	}
+
+	if fuseFlags&fuselib.O_APPEND != 0 {
+		ioFlags |= filesystem.IOAppend
+	}
+	if fuseFlags&fuselib.O_CREAT != 0 {
+		ioFlags |= filesystem.IOCreate
+	}
+	if fuseFlags&fuselib.O_EXCL != 0 {
+		ioFlags |= filesystem.IOExclusive
+	}
+	if fuseFlags&fuselib.O_TRUNC != 0 {
+		ioFlags |= filesystem.IOTruncate
+	}
+	return ioFlags
 }
 
 func getStat(r filesystem.Interface, path string, template *fuselib.Stat_t) *fuselib.Stat_t {
-	iStat, _, err := r.Info(path, filesystem.StatRequestAll)
+	iStat, filled, err := r.Info(path, filesystem.StatRequestAll)
 	if err != nil {
 		return nil
 	}
@@ -267,6 +299,7 @@ func getStat(r filesystem.Interface, path string, template *fuselib.Stat_t) *fus
 	subStat := new(fuselib.Stat_t)
 	*subStat = *template
 	applyIntermediateStat(subStat, iStat)
+	applyMetadataToStat(subStat, iStat, filled)
 	return subStat
 }
 
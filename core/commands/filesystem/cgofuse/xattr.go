@@ -0,0 +1,81 @@
+//+build !nofuse
+
+package cgofuse
+
+import (
+	fuselib "github.com/billziss-gh/cgofuse/fuse"
+	"github.com/ipfs/go-ipfs/filesystem"
+)
+
+// extended attributes are provided by systems which implement the (optional) extension
+// they are read-only
+
+func (fs *hostBinding) attributes(path string) (map[string]string, errNo) {
+	attributer, ok := fs.nodeInterface.(filesystem.ExtendedAttributer)
+	if !ok {
+		return nil, -fuselib.ENOSYS
+	}
+
+	attrs, err := attributer.ExtendedAttributes(path)
+	if err != nil {
+		errNo := interpretError(err)
+		if errNo != -fuselib.ENOENT { // don't flood the logs with "not found" errors
+			fs.log.Error(err)
+		}
+		return nil, errNo
+	}
+	return attrs, operationSuccess
+}
+
+func (fs *hostBinding) Getxattr(path, name string) (int, []byte) {
+	fs.log.Debugf("Getxattr - {%s}%q", name, path)
+
+	attrs, errNo := fs.attributes(path)
+	if errNo != operationSuccess {
+		return errNo, nil
+	}
+
+	value, ok := attrs[name]
+	if !ok {
+		return -fuselib.ENOATTR, nil
+	}
+	return operationSuccess, []byte(value)
+}
+
+func (fs *hostBinding) Listxattr(path string, fill func(name string) bool) int {
+	fs.log.Debugf("Listxattr - %q", path)
+
+	attrs, errNo := fs.attributes(path)
+	if errNo != operationSuccess {
+		return errNo
+	}
+
+	for name := range attrs {
+		if !fill(name) {
+			return -fuselib.ERANGE
+		}
+	}
+	return operationSuccess
+}
+
+func (fs *hostBinding) Setxattr(path, name string, value []byte, flags int) int {
+	fs.log.Debugf("Setxattr - {%X|%s|%d}%q", flags, name, len(value), path)
+	return fs.denyXattrModification(path, name)
+}
+
+func (fs *hostBinding) Removexattr(path, name string) int {
+	fs.log.Debugf("Removexattr - {%s}%q", name, path)
+	return fs.denyXattrModification(path, name)
+}
+
+// our attributes may not be modified, and we don't store any others
+func (fs *hostBinding) denyXattrModification(path, name string) errNo {
+	attrs, errNo := fs.attributes(path)
+	if errNo != operationSuccess {
+		return errNo
+	}
+	if _, ok := attrs[name]; ok {
+		return -fuselib.EPERM
+	}
+	return -fuselib.ENOTSUP
+}
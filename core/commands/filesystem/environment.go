@@ -156,7 +156,11 @@ func parseClientOptions(maddr multiaddr.Multiaddr) (network, dialHost, clientHos
 }
 
 func (fe *filesystemEnvironment) Manager(request *cmds.Request) (manager.Interface, error) {
-	ipfs, err := fe.IPFS(request)
+	apiAddr, err := ipfsAPIAddr(request)
+	if err != nil {
+		return nil, err
+	}
+	ipfs, err := httpapi.NewApi(apiAddr)
 	if err != nil {
 		return nil, err
 	}
@@ -168,19 +172,29 @@ func (fe *filesystemEnvironment) Manager(request *cmds.Request) (manager.Interfa
 
 	return &commandDispatcher{
 		instanceIndex: fe.instanceIndex,
+		ipfsAPI:       apiAddr.String(),
 		dispatchers:   ipfsDispatch,
+		makeBinder: func(header requestHeader) (manager.Binder, error) {
+			return newCoreBinder(fe.Context, ipfs, header)
+		},
 	}, nil
 }
 
 func (fe *filesystemEnvironment) IPFS(request *cmds.Request) (coreiface.CoreAPI, error) {
-	apiAddr, err := getIPFSAPIAddr(request)
+	apiAddr, err := ipfsAPIAddr(request)
 	if err != nil {
 		return nil, err
 	}
-	if apiAddr, err = resolveAddr(request.Context, apiAddr); err != nil {
+	return httpapi.NewApi(apiAddr)
+}
+
+// ipfsAPIAddr returns the resolved address of the IPFS API to use for the request
+func ipfsAPIAddr(request *cmds.Request) (multiaddr.Multiaddr, error) {
+	apiAddr, err := getIPFSAPIAddr(request)
+	if err != nil {
 		return nil, err
 	}
-	return httpapi.NewApi(apiAddr)
+	return resolveAddr(request.Context, apiAddr)
 }
 
 func (fe *filesystemEnvironment) Index(request *cmds.Request) (manager.Index, error) {
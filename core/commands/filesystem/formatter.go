@@ -7,9 +7,12 @@ import (
 	"io"
 	"runtime"
 	"strings"
+	"time"
 
+	humanize "github.com/dustin/go-humanize"
 	cmds "github.com/ipfs/go-ipfs-cmds"
 	"github.com/ipfs/go-ipfs/filesystem"
+	"github.com/ipfs/go-ipfs/filesystem/interface/keyfs"
 	"github.com/ipfs/go-ipfs/filesystem/manager"
 	"github.com/ipfs/go-ipfs/filesystem/manager/errors"
 	"github.com/multiformats/go-multiaddr"
@@ -26,23 +29,31 @@ const (
 	thBinding                    // Binding
 	thExtra                      // Options
 
-	// NOTE: rows must align to this width
-	tableWidth
+	// long listings extend rows with instance statistics
+	thMounted    // Mounted
+	thIPFSAPI    // IPFS API
+	thFiles      // Open files
+	thDirs       // Open directories
+	thRead       // Read
+	thWritten    // Written
+	thErrorCount // Errors
+
+	// NOTE: rows must align to one of these widths
+	tableWidth     = thMounted
+	longTableWidth = thErrorCount + 1
 )
 
 // constructs the interface used to draw graphical tables to a writer
-func newTableFormatter(writer io.Writer) *tablewriter.Table {
-	tableHeader := []string{ // construct the header cells
-		thHAPI.String(),
-		thNAPI.String(),
-		thBinding.String(),
-		thExtra.String(),
+func newTableFormatter(writer io.Writer, width tableColumn) *tablewriter.Table {
+	tableHeader := make([]string, width) // construct the header cells
+	for column := range tableHeader {
+		tableHeader[column] = tableColumn(column).String()
 	}
 
 	table := tablewriter.NewWriter(writer) // construct the table renderer
 	table.SetHeader(tableHeader)           // insert header cells
 
-	hColors := make([]tablewriter.Colors, tableWidth) // apply styles to them
+	hColors := make([]tablewriter.Colors, width) // apply styles to them
 	for i := range hColors {
 		hColors[i] = tablewriter.Colors{tablewriter.Bold}
 	}
@@ -68,8 +79,8 @@ func newTableFormatter(writer io.Writer) *tablewriter.Table {
 }
 
 // XXX: sloppy
-func responseAsTableRow(resp manager.Response) ([]string, []tablewriter.Colors) {
-	row := make([]string, tableWidth)
+func responseAsTableRow(resp manager.Response, width tableColumn) ([]string, []tablewriter.Colors) {
+	row := make([]string, width)
 	maddr := resp.Request
 
 	if maddr != nil { // retrieve row data from the multiaddr (if any)
@@ -85,8 +96,15 @@ func responseAsTableRow(resp manager.Response) ([]string, []tablewriter.Colors)
 				row[thNAPI] = comp.Value()
 
 				// XXX: quick 9P formatting hacks; make formal and break out of here
-				_, tail := multiaddr.SplitFirst(maddr)       // strip fs header
-				hopefullyNet, _ := multiaddr.SplitLast(tail) // strip path tail
+				_, hopefullyNet := multiaddr.SplitFirst(maddr) // strip fs header
+				_, hopefullyNet = splitOptions(hopefullyNet)   // and options (if any)
+				if hopefullyNet == nil {
+					break
+				}
+				if head, last := multiaddr.SplitLast(hopefullyNet); last != nil &&
+					last.Protocol().Code == int(filesystem.PathProtocol) {
+					hopefullyNet = head // strip path tail (if any)
+				}
 				if hopefullyNet == nil {
 					break
 				}
@@ -94,6 +112,34 @@ func responseAsTableRow(resp manager.Response) ([]string, []tablewriter.Colors)
 					row[thExtra] = fmt.Sprintf("Listening on: %s://%s", addr.Network(), addr.String())
 				}
 
+			case filesystem.PublishOption:
+				option := fmt.Sprintf("Publish: %s", comp.Value())
+				if row[thExtra] != "" {
+					option = row[thExtra] + ", " + option
+				}
+				row[thExtra] = option
+
+			case filesystem.CacheOption:
+				option := fmt.Sprintf("Cache: %s", comp.Value())
+				if row[thExtra] != "" {
+					option = row[thExtra] + ", " + option
+				}
+				row[thExtra] = option
+
+			case filesystem.ReadAheadOption:
+				option := fmt.Sprintf("Read-ahead: %s", comp.Value())
+				if row[thExtra] != "" {
+					option = row[thExtra] + ", " + option
+				}
+				row[thExtra] = option
+
+			case filesystem.ReferencesOption:
+				option := fmt.Sprintf("References: %s", comp.Value())
+				if row[thExtra] != "" {
+					option = row[thExtra] + ", " + option
+				}
+				row[thExtra] = option
+
 			case int(filesystem.PathProtocol):
 				localPath := comp.Value()
 				if runtime.GOOS == "windows" { // `/C:\path` -> `C:\path`
@@ -103,11 +149,23 @@ func responseAsTableRow(resp manager.Response) ([]string, []tablewriter.Colors)
 			}
 			return true
 		})
+		if (row[thNAPI] == filesystem.KeyFS.String() || row[thNAPI] == filesystem.RootFS.String()) &&
+			!strings.Contains(row[thExtra], "Publish: ") {
+			option := fmt.Sprintf("Publish: %s", keyfs.DefaultPublishPolicy)
+			if row[thExtra] != "" {
+				option = row[thExtra] + ", " + option
+			}
+			row[thExtra] = option
+		}
+	}
+
+	if width == longTableWidth {
+		statisticsAsTableRow(resp.Statistics, row)
 	}
 
 	// create the corresponding color values for the table's row
 	// XXX: non-deuteranopia friendly colours
-	rowColors := make([]tablewriter.Colors, tableWidth)
+	rowColors := make([]tablewriter.Colors, width)
 	for i := range rowColors {
 		switch {
 		case resp.Error == nil:
@@ -129,15 +187,37 @@ func responseAsTableRow(resp manager.Response) ([]string, []tablewriter.Colors)
 	return row, rowColors
 }
 
+// statisticsAsTableRow fills in the statistics columns of a long row
+// (values that were not reported are shown as "-")
+func statisticsAsTableRow(stats *manager.Statistics, row []string) {
+	for column := thMounted; column != longTableWidth; column++ {
+		row[column] = "-"
+	}
+	if stats == nil {
+		return
+	}
+	row[thMounted] = stats.Mounted.Local().Format(time.RFC3339)
+	if stats.IPFSAPI != "" {
+		row[thIPFSAPI] = stats.IPFSAPI
+	}
+	if activity := stats.Activity; activity != nil {
+		row[thFiles] = fmt.Sprint(activity.OpenFiles)
+		row[thDirs] = fmt.Sprint(activity.OpenDirectories)
+		row[thRead] = humanize.Bytes(activity.BytesRead)
+		row[thWritten] = humanize.Bytes(activity.BytesWritten)
+		row[thErrorCount] = fmt.Sprint(activity.Errors)
+	}
+}
+
 // TODO: English
 // responsesToConsole renders responses sent on the returned channel,
 // to the supplied buffer (as terminal text data).
 // Caller should cancel the context when done drawing.
-func responsesToConsole(ctx context.Context, renderBuffer io.Writer) (chan<- manager.Response, errors.Stream) {
+func responsesToConsole(ctx context.Context, renderBuffer io.Writer, width tableColumn) (chan<- manager.Response, errors.Stream) {
 	var (
 		responses  = make(chan manager.Response)
 		renderErrs = make(chan error)
-		graphics   = newTableFormatter(renderBuffer)
+		graphics   = newTableFormatter(renderBuffer, width)
 		scrollBack int
 	)
 	go func() {
@@ -150,7 +230,7 @@ func responsesToConsole(ctx context.Context, renderBuffer io.Writer) (chan<- man
 					panic("caller closed input channel")
 				}
 				scrollBack = graphics.NumLines() // start drawing this many lines above the current line
-				if err := overdrawResponse(renderBuffer, scrollBack, graphics, response); err != nil {
+				if err := overdrawResponse(renderBuffer, scrollBack, graphics, width, response); err != nil {
 					select {
 					case renderErrs <- err:
 					case <-ctx.Done():
@@ -165,12 +245,12 @@ func responsesToConsole(ctx context.Context, renderBuffer io.Writer) (chan<- man
 	return responses, renderErrs
 }
 
-func drawResponse(graphics *tablewriter.Table, response manager.Response) {
-	graphics.Rich(responseAsTableRow(response)) // adds the row to the table
-	graphics.Render()                           // draws the entire table
+func drawResponse(graphics *tablewriter.Table, width tableColumn, response manager.Response) {
+	graphics.Rich(responseAsTableRow(response, width)) // adds the row to the table
+	graphics.Render()                                  // draws the entire table
 }
 
-func overdrawResponse(console io.Writer, scrollBack int, graphics *tablewriter.Table, response manager.Response) (err error) {
+func overdrawResponse(console io.Writer, scrollBack int, graphics *tablewriter.Table, width tableColumn, response manager.Response) (err error) {
 	const headerHeight = 2
 	if scrollBack != 0 {
 		// TODO: this needs to be abstracted; byte sequence is going to depend on the terminal
@@ -184,14 +264,18 @@ func overdrawResponse(console io.Writer, scrollBack int, graphics *tablewriter.T
 	}
 	// draw the table, at cursors current position
 	// (this should draw over any characters from a previous call, if any)
-	drawResponse(graphics, response)
+	drawResponse(graphics, width, response)
 	return
 }
 
 func renderToConsole(request *cmds.Request, output optionalOutputs, inputErrors errors.Stream, responses manager.Responses) []error {
+	width := tableWidth
+	if long, _ := request.Options[listLongOptionKwd].(bool); long {
+		width = longTableWidth
+	}
 	var (
 		renderCtx, renderCancel       = context.WithCancel(request.Context)
-		consoleRenderer, renderErrors = responsesToConsole(renderCtx, output.console)
+		consoleRenderer, renderErrors = responsesToConsole(renderCtx, output.console, width)
 		allErrs                       = errors.Merge(inputErrors, renderErrors)
 		encounteredErrs               []error
 	)
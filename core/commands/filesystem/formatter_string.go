@@ -12,12 +12,20 @@ func _() {
 	_ = x[thNAPI-1]
 	_ = x[thBinding-2]
 	_ = x[thExtra-3]
+	_ = x[thMounted-4]
+	_ = x[thIPFSAPI-5]
+	_ = x[thFiles-6]
+	_ = x[thDirs-7]
+	_ = x[thRead-8]
+	_ = x[thWritten-9]
+	_ = x[thErrorCount-10]
 	_ = x[tableWidth-4]
+	_ = x[longTableWidth-11]
 }
 
-const _tableColumn_name = "Host APINode APIBindingOptionstableWidth"
+const _tableColumn_name = "Host APINode APIBindingOptionsMountedIPFS APIOpen filesOpen directoriesReadWrittenErrorslongTableWidth"
 
-var _tableColumn_index = [...]uint8{0, 8, 16, 23, 30, 40}
+var _tableColumn_index = [...]uint8{0, 8, 16, 23, 30, 37, 45, 55, 71, 75, 82, 88, 102}
 
 func (i tableColumn) String() string {
 	if i < 0 || i >= tableColumn(len(_tableColumn_index)-1) {
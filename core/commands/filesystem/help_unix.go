@@ -15,6 +15,10 @@ You may have to create the directories before using 'ipfs mount':
 > sudo chown $(whoami) /ipfs /ipns
 > ipfs daemon &
 > ipfs mount
+
+Alternatively, the 'rootfs' system presents IPFS, IPNS, keys, pins, and MFS
+within a single directory ('/ipfs', '/ipns', '/keys', '/pins', and '/files'),
+so only one mountpoint is needed (e.g. '/fuse/rootfs/path/mnt/ipfs').
 `
 	mountDescExample = `
 # setup
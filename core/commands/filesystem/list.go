@@ -1,6 +1,11 @@
 package fscmds
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
 	cmds "github.com/ipfs/go-ipfs-cmds"
 	"github.com/ipfs/go-ipfs/filesystem/manager"
 	"github.com/ipfs/go-ipfs/filesystem/manager/errors"
@@ -9,10 +14,28 @@ import (
 const (
 	listParameter   = "list"
 	listDescription = "list active instances"
+
+	listPersistentOptionKwd         = "persistent"
+	listPersistentOptionDescription = "list the requests which are restored when the service starts (instead of active instances)"
+
+	listLongOptionKwd         = "long"
+	listLongOptionDescription = "also list the statistics of active instances (mount time, IPFS API, open handles, bytes read and written, and errors)"
+
+	listStringArgument      = "filters"
+	listArgumentDescription = "Only list requests which begin with these prefixes. " + listFilterExamples
+	listFilterExamples      = "(e.g. `/fuse /9p/ipfs ...`)"
 )
 
 var List = &cmds.Command{
-	Run: listRun,
+	Options: []cmds.Option{
+		cmds.BoolOption(listPersistentOptionKwd, listPersistentOptionDescription),
+		cmds.BoolOption(listLongOptionKwd, "l", listLongOptionDescription),
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg(listStringArgument, false, true, listArgumentDescription),
+	},
+	PreRun: listPreRun,
+	Run:    listRun,
 	PostRun: cmds.PostRunMap{
 		cmds.CLI: formatList,
 	},
@@ -26,6 +49,82 @@ var List = &cmds.Command{
 	NoLocal: true, // always execute on fs service instance
 }
 
+// construct subcommand groups from supported API/ID pairs, which filter the list
+// e.g. make these invocations equal
+// 1) `ipfs fs list /fuse/ipns`
+// 2) `ipfs fs list fuse /ipns`
+// 3) `ipfs fs list fuse ipns`
+func init() { registerListSubcommands(List) }
+
+func registerListSubcommands(parent *cmds.Command) {
+	template := &cmds.Command{
+		Options:  parent.Options,
+		Run:      parent.Run,
+		PostRun:  parent.PostRun,
+		Encoders: parent.Encoders,
+		Helptext: parent.Helptext,
+		Type:     parent.Type,
+		NoLocal:  parent.NoLocal,
+		NoRemote: parent.NoRemote,
+	}
+
+	// arguments are relative to the subcommand's prefix;
+	// without any, the prefix itself is the filter
+	genPrerun := func(prefix string) func(request *cmds.Request, env cmds.Environment) error {
+		return func(request *cmds.Request, env cmds.Environment) error {
+			if len(request.Arguments) == 0 {
+				request.Arguments = []string{prefix}
+			} else {
+				for i, arg := range request.Arguments {
+					request.Arguments[i] = prefix + arg
+				}
+			}
+			return parent.PreRun(request, env)
+		}
+	}
+	subArgs := func(subExamples string) []cmds.Argument {
+		return []cmds.Argument{
+			cmds.StringArg("sub"+listStringArgument, false, true,
+				strings.ReplaceAll(listArgumentDescription, listFilterExamples, subExamples)),
+		}
+	}
+
+	subcommands := make(map[string]*cmds.Command)
+	for _, api := range supportedHostAPIs {
+		hostName := api.String()
+		subsystems := make(map[string]*cmds.Command)
+
+		com := new(cmds.Command)
+		*com = *template
+		prefix := fmt.Sprintf("/%s", hostName)
+		com.Arguments = subArgs("(e.g. `/ipfs /ipns/path/mnt ...`)")
+		com.PreRun = genPrerun(prefix)
+		com.Subcommands = subsystems
+		subcommands[hostName] = com
+
+		for _, id := range supportedNodeAPIs {
+			nodeName := id.String()
+			com := new(cmds.Command)
+			*com = *template
+			prefix := fmt.Sprintf("/%s/%s", hostName, nodeName)
+			com.Arguments = subArgs("(e.g. `/path/mnt ...`)")
+			com.PreRun = genPrerun(prefix)
+			subsystems[nodeName] = com
+		}
+	}
+	parent.Subcommands = subcommands
+}
+
+func listPreRun(request *cmds.Request, env cmds.Environment) error {
+	for _, filter := range request.Arguments {
+		if !strings.HasPrefix(filter, "/") {
+			return cmds.Errorf(cmds.ErrClient,
+				"filter %q is not a request prefix %s", filter, listFilterExamples)
+		}
+	}
+	return nil
+}
+
 func listRun(request *cmds.Request, emitter cmds.ResponseEmitter, env cmds.Environment) error {
 	fsEnv, envIsUsable := env.(FileSystemEnvironment)
 	if !envIsUsable {
@@ -38,16 +137,98 @@ func listRun(request *cmds.Request, emitter cmds.ResponseEmitter, env cmds.Envir
 	}
 
 	var (
+		listPersistent, _ = request.Options[listPersistentOptionKwd].(bool)
+		listLong, _       = request.Options[listLongOptionKwd].(bool)
+
 		ctx         = request.Context
 		inputErrors errors.Stream // intentionally nil, list has no possible input errors (yet)
-		responses   = fsi.List(ctx)
-		allErrs     = emitResponses(ctx, emitter.Emit,
-			inputErrors, responses)
+		responses   manager.Responses
 	)
+	if listPersistent {
+		if responses, err = listPersistentMounts(ctx); err != nil {
+			return err
+		}
+	} else {
+		responses = fsi.List(ctx)
+	}
+
+	responses = sortResponses(ctx, filterResponses(responses, request.Arguments))
+	if listLong && !listPersistent { // (persistent requests are not instances)
+		responses = withStatistics(ctx, responses)
+	}
+
+	allErrs := emitResponses(ctx, emitter.Emit,
+		inputErrors, responses)
 
 	return flattenErrors("listing", allErrs) // TODO: pull name prefix from request path
 }
 
+// filterResponses relays the responses whose requests begin with any of the prefixes
+// (or all responses, if none are provided)
+func filterResponses(responses manager.Responses, prefixes []string) manager.Responses {
+	if len(prefixes) == 0 {
+		return responses
+	}
+	filtered := make(chan manager.Response)
+	go func() {
+		defer close(filtered)
+		for response := range responses {
+			request := response.Request.String()
+			for _, prefix := range prefixes {
+				prefix = strings.TrimSuffix(prefix, "/")
+				if request == prefix || strings.HasPrefix(request, prefix+"/") {
+					filtered <- response
+					break
+				}
+			}
+		}
+	}()
+	return filtered
+}
+
+// sortResponses relays all responses, ordered by their requests
+// (the index has no order of its own)
+func sortResponses(ctx context.Context, responses manager.Responses) manager.Responses {
+	sorted := make(chan manager.Response)
+	go func() {
+		defer close(sorted)
+		var all []manager.Response
+		for response := range responses {
+			all = append(all, response)
+		}
+		sort.SliceStable(all, func(i, j int) bool {
+			return all[i].Request.String() < all[j].Request.String()
+		})
+		for _, response := range all {
+			select {
+			case sorted <- response:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return sorted
+}
+
+// withStatistics relays the responses with the statistics of their instance attached
+func withStatistics(ctx context.Context, responses manager.Responses) manager.Responses {
+	relay := make(chan manager.Response)
+	go func() {
+		defer close(relay)
+		for response := range responses {
+			if instance, ok := response.Closer.(indexedInstance); ok {
+				response.Statistics = instance.statistics()
+			}
+			select {
+			case relay <- response:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return relay
+}
+
 func formatList(response cmds.Response, emitter cmds.ResponseEmitter) (err error) {
 	var (
 		ctx                   = response.Request().Context
@@ -75,7 +256,11 @@ func formatList(response cmds.Response, emitter cmds.ResponseEmitter) (err error
 	err = flattenErrors("listing", renderToConsole(response.Request(), outputs,
 		cmdsErrors, relay))
 	if !gotResponse && err == nil {
-		if err = outputs.Print("No active instances\n"); err != nil {
+		msg := "No active instances\n"
+		if listPersistent, _ := response.Request().Options[listPersistentOptionKwd].(bool); listPersistent {
+			msg = "No persistent requests\n"
+		}
+		if err = outputs.Print(msg); err != nil {
 			return
 		}
 	}
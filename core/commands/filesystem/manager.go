@@ -50,10 +50,10 @@ func (ci *commandDispatcher) Bind(ctx context.Context, requests <-chan manager.R
 					continue
 				}
 				header := section.requestHeader
-				binder, ok := ci.dispatchers[header]
-				if !ok {
+				binder, err := ci.binder(header)
+				if err != nil {
 					wg.Add(1)
-					go respondWithError(fmt.Errorf("no binder found for: %v", header))
+					go respondWithError(err)
 					continue
 				}
 				wg.Add(1)
@@ -76,7 +76,26 @@ func (ci *commandDispatcher) Bind(ctx context.Context, requests <-chan manager.R
 		close(sectionResponses)
 	}()
 
-	return handleResponses(ctx, ci.instanceIndex, mergeResponseStreams(ctx, sectionResponses))
+	return handleResponses(ctx, ci.instanceIndex, ci.ipfsAPI, mergeResponseStreams(ctx, sectionResponses))
+}
+
+// binder returns the binder for the header,
+// constructing one if the header contains options.
+func (ci *commandDispatcher) binder(header requestHeader) (manager.Binder, error) {
+	ci.dispatchMu.Lock()
+	defer ci.dispatchMu.Unlock()
+	if binder, ok := ci.dispatchers[header]; ok {
+		return binder, nil
+	}
+	if header.requestOptions == (requestOptions{}) || ci.makeBinder == nil {
+		return nil, fmt.Errorf("no binder found for: %v", header)
+	}
+	binder, err := ci.makeBinder(header)
+	if err != nil {
+		return nil, err
+	}
+	ci.dispatchers[header] = binder
+	return binder, nil
 }
 
 // binder-requests will not contain our manager-header values,
@@ -86,7 +105,11 @@ func (ci *commandDispatcher) Bind(ctx context.Context, requests <-chan manager.R
 // manager `/fuse/ipfs/path/mnt/ipfs` -> ...)
 func prefixResponses(ctx context.Context, header requestHeader, responses manager.Responses) manager.Responses {
 	respChan := make(chan manager.Response)
-	base, _ := multiaddr.NewComponent(header.API.String(), header.ID.String())
+	var base multiaddr.Multiaddr
+	base, _ = multiaddr.NewComponent(header.API.String(), header.ID.String())
+	for _, option := range header.requestOptions.components() {
+		base = base.Encapsulate(option)
+	}
 	go func() {
 		defer close(respChan)
 		for response := range responses {
@@ -138,11 +161,11 @@ type responseHandlerFunc func([]manager.Response) manager.Responses
 // either storing them in the `List` index or closing them (all) if an(y) error is encountered.
 // NOTE: If the context is canceled, the returned stream is closed,
 // but all input responses are still processed as described above.
-func handleResponses(ctx context.Context, index instanceIndex, responses <-chan manager.Response) <-chan manager.Response {
+func handleResponses(ctx context.Context, index instanceIndex, ipfsAPI string, responses <-chan manager.Response) <-chan manager.Response {
 	var (
 		succeeded        []manager.Response
 		relay                                = make(chan manager.Response)
-		processResponses responseHandlerFunc = commitResponsesTo(index)
+		processResponses responseHandlerFunc = commitResponsesTo(index, ipfsAPI)
 	)
 	go func() {
 		defer close(relay)
@@ -178,19 +201,28 @@ func handleResponses(ctx context.Context, index instanceIndex, responses <-chan
 }
 
 // commit these responses to the index, and return no additional status messages
-func commitResponsesTo(index instanceIndex) responseHandlerFunc {
+func commitResponsesTo(index instanceIndex, ipfsAPI string) responseHandlerFunc {
 	return func(responses []manager.Response) manager.Responses {
 		noResponse := make(chan manager.Response)
 		close(noResponse)
 		for i := range responses {
 			instance := responses[i]
-			key, _ := instance.Request.ValueForProtocol(int(filesystem.PathProtocol))
-			index.store(key, &instance)
+			index.store(instanceKey(instance.Request), &instance, ipfsAPI)
 		}
 		return noResponse
 	}
 }
 
+// instanceKey returns the index key for a request
+// host paths are unique per system, so we use them when present (e.g. FUSE)
+// otherwise the full request is used (e.g. 9P listeners)
+func instanceKey(request manager.Request) indexKey {
+	if hostPath, err := request.ValueForProtocol(int(filesystem.PathProtocol)); err == nil {
+		return hostPath
+	}
+	return request.String()
+}
+
 // close these responses, and return typed error status messages
 func closeResponses(responses []manager.Response) manager.Responses {
 	undoneStatusMessages := make(chan manager.Response, len(responses))
@@ -10,43 +10,137 @@ import (
 	config "github.com/ipfs/go-ipfs-config"
 	configfile "github.com/ipfs/go-ipfs-config/serialize"
 	"github.com/ipfs/go-ipfs/core/commands/filesystem/cgofuse"
+	"github.com/ipfs/go-ipfs/core/commands/filesystem/p9"
 	"github.com/ipfs/go-ipfs/filesystem"
+	"github.com/ipfs/go-ipfs/filesystem/interface/cachefs"
+	"github.com/ipfs/go-ipfs/filesystem/interface/filesapi"
 	"github.com/ipfs/go-ipfs/filesystem/interface/ipfscore"
+	"github.com/ipfs/go-ipfs/filesystem/interface/keyfs"
 	"github.com/ipfs/go-ipfs/filesystem/interface/pinfs"
+	"github.com/ipfs/go-ipfs/filesystem/interface/rootfs"
 	"github.com/ipfs/go-ipfs/filesystem/manager"
 	"github.com/ipfs/go-ipfs/filesystem/manager/errors"
 	coreiface "github.com/ipfs/interface-go-ipfs-core"
 )
 
 //TODO: provider caller options to select APIs
-// TODO: extend core interface to support MFS and friends
 func newCoreDispatchers(ctx context.Context, coreapi coreiface.CoreAPI) (dispatchMap, error) {
+	dispatch := make(dispatchMap)
+	for _, hostAPI := range supportedHostAPIs {
+		for _, nodeAPI := range supportedNodeAPIs {
+			header := requestHeader{API: hostAPI, ID: nodeAPI}
+			fsb, err := newCoreBinder(ctx, coreapi, header)
+			if err != nil {
+				return nil, err
+			}
+			dispatch[header] = fsb
+		}
+	}
+	return dispatch, nil
+}
+
+// newCoreBinder constructs the binder for the header,
+// using a file system instance that is configured by the header's options.
+func newCoreBinder(ctx context.Context, coreapi coreiface.CoreAPI, header requestHeader) (manager.Binder, error) {
+	if header.Publish != "" && header.ID != filesystem.KeyFS && header.ID != filesystem.RootFS {
+		return nil, fmt.Errorf("option %q is not supported by %v", filesystem.PublishOptionName, header.ID)
+	}
+	if header.References != "" && header.ID != filesystem.KeyFS && header.ID != filesystem.RootFS {
+		return nil, fmt.Errorf("option %q is not supported by %v", filesystem.ReferencesOptionName, header.ID)
+	}
+	if header.ReadAhead != "" &&
+		header.ID != filesystem.IPFS && header.ID != filesystem.IPNS && header.ID != filesystem.RootFS {
+		return nil, fmt.Errorf("option %q is not supported by %v", filesystem.ReadAheadOptionName, header.ID)
+	}
+
 	var (
-		fsb      manager.Binder
-		err      error
-		dispatch = make(dispatchMap)
-		header   = requestHeader{API: filesystem.Fuse}
+		fs  filesystem.Interface
+		err error
 	)
-	for _, nodeAPI := range []filesystem.ID{
-		filesystem.IPFS,
-		filesystem.IPNS,
-		filesystem.PinFS,
-	} {
-		header.ID = nodeAPI
-		switch nodeAPI {
-		case filesystem.IPFS, filesystem.IPNS:
-			fsb, err = cgofuse.NewBinder(ctx, ipfscore.NewInterface(ctx, coreapi, nodeAPI))
-		case filesystem.PinFS:
-			fsb, err = cgofuse.NewBinder(ctx, pinfs.NewInterface(ctx, coreapi))
-		default:
-			err = fmt.Errorf("unsupported API %v", nodeAPI) // TODO: better message
+	if header.ID == filesystem.RootFS {
+		// the root contains an instance of each system, which share the header's options
+		systems := make([]filesystem.Interface, len(rootSystems))
+		for i, id := range rootSystems {
+			if systems[i], err = newCoreSystem(ctx, coreapi, id, header.requestOptions); err != nil {
+				return nil, err
+			}
+		}
+		if fs, err = rootfs.NewInterface(ctx, systems...); err != nil {
+			return nil, err
 		}
+	} else if fs, err = newCoreSystem(ctx, coreapi, header.ID, header.requestOptions); err != nil {
+		return nil, err
+	}
+
+	if header.Cache != "" {
+		ttl, err := cachefs.ParseTTL(header.Cache)
 		if err != nil {
 			return nil, err
 		}
-		dispatch[header] = fsb
+		if fs, err = cachefs.NewInterface(ctx, fs, cachefs.WithTTL(ttl)); err != nil {
+			return nil, err
+		}
+	}
+
+	switch header.API {
+	case filesystem.Fuse:
+		return cgofuse.NewBinder(ctx, fs)
+	case filesystem.Plan9Protocol:
+		return p9.NewBinder(ctx, fs)
+	default:
+		return nil, fmt.Errorf("unsupported API %v", header.API)
+	}
+}
+
+// the systems which are mounted within the root system
+var rootSystems = []filesystem.ID{
+	filesystem.IPFS,
+	filesystem.IPNS,
+	filesystem.KeyFS,
+	filesystem.PinFS,
+	filesystem.Files,
+}
+
+// newCoreSystem constructs a file system instance, applying the options that are relevant to it.
+func newCoreSystem(ctx context.Context, coreapi coreiface.CoreAPI,
+	id filesystem.ID, requestOptions requestOptions) (filesystem.Interface, error) {
+	switch id {
+	case filesystem.IPFS, filesystem.IPNS:
+		// (read-ahead is enabled for the instances we construct here, mounted directly or through the root;
+		// not for those that other systems construct internally, like PinFS's and KeyFS's)
+		window := ipfscore.DefaultReadAhead
+		if requestOptions.ReadAhead != "" {
+			var err error
+			if window, err = ipfscore.ParseReadAhead(requestOptions.ReadAhead); err != nil {
+				return nil, err
+			}
+		}
+		return ipfscore.NewInterface(ctx, coreapi, id, ipfscore.WithReadAhead(window)), nil
+	case filesystem.PinFS:
+		return pinfs.NewInterface(ctx, coreapi), nil
+	case filesystem.KeyFS:
+		var options []keyfs.Option
+		if requestOptions.Publish != "" {
+			policy, err := keyfs.ParsePublishPolicy(requestOptions.Publish)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, keyfs.WithPublishPolicy(policy))
+		}
+		if requestOptions.References != "" {
+			linger, limit, err := keyfs.ParseReferenceCache(requestOptions.References)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, keyfs.WithReferenceCache(linger, limit))
+		}
+		return keyfs.NewInterface(ctx, coreapi, options...), nil
+	case filesystem.Files:
+		// MFS is accessed through the node's Files API, rather than a local MFS root
+		return filesapi.NewInterface(ctx, coreapi)
+	default:
+		return nil, fmt.Errorf("unsupported API %v", id) // TODO: better message
 	}
-	return dispatch, nil
 }
 
 func generatePipeline(ctx context.Context, requests manager.Requests) (sectionStream, errors.Stream) {
@@ -12,7 +12,19 @@ import (
 
 const (
 	mountParameter           = "mount"
-	mountArgumentDescription = "Multiaddr style targets to bind to host. " + mountTargetExamples
+	mountArgumentDescription = "Multiaddr style targets to bind to host, or mount tables. " + mountTargetExamples
+
+	mountOptionDescription = `
+Options may follow the API pair of a request.
+'/publish/<policy>' sets when modifications to keys are published (keyfs and rootfs only).
+Either 'immediate', 'close', or a quiet period (e.g. '/fuse/keyfs/publish/10s/path/mnt/keys').
+'/cache/<duration>' caches metadata and directory listings (e.g. '/fuse/ipns/cache/30s/path/ipns').
+Immutable content is cached indefinitely, anything else until the duration expires.
+'/readahead/<blocks>' sets how many blocks are prefetched ahead of sequential reads (ipfs, ipns, and rootfs only).
+8 by default, 0 disables read-ahead (e.g. '/fuse/ipfs/readahead/32/path/ipfs').
+'/references/<linger>[,<limit>]' sets how long (and how many) unused keys are kept open (keyfs and rootfs only).
+A linger of 0 disables this (e.g. '/fuse/keyfs/references/5s,64/path/mnt/keys').
+`
 
 	// shared
 	mountStringArgument = "targets"
@@ -20,15 +32,12 @@ const (
 )
 
 var Mount = &cmds.Command{
+	Options: []cmds.Option{
+		cmds.BoolOption(persistOptionKwd, persistOptionDescription),
+	},
 	Arguments: []cmds.Argument{
+		// NOTE: tables are expanded into requests during PreRun (see `expandMountTables`)
 		cmds.StringArg(mountStringArgument, false, true, mountArgumentDescription),
-		// TODO: we should accept stdin + file arguments since we can
-		// `ipfs mount mtab1.json mtab2.xml`,`... | ipfs mount -`
-		// where everything just gets decoded into a flat list/stream:
-		// (file|stdin data)[]byte -> (magic/header check + unmarshal) => []multiaddr
-		//  ^post: for each file => combine maddrs
-		// this would allow passing IPFS mtab references as well
-		// e.g. `ipfs mount /ipfs/Qm.../my-mount-table.json`
 	},
 	PreRun: mountPreRun,
 	Run:    mountRun,
@@ -39,7 +48,7 @@ var Mount = &cmds.Command{
 	Helptext: cmds.HelpText{ // TODO: docs are still outdated - needs sys_ migrations
 		Tagline:          MountTagline,
 		ShortDescription: mountDescWhatAndWhere,
-		LongDescription:  mountDescWhatAndWhere + "\nExample:\n" + mountDescExample,
+		LongDescription:  mountDescWhatAndWhere + mountOptionDescription + mountTableDescription + "\nExample:\n" + mountDescExample,
 	},
 	Type:    manager.Response{},
 	NoLocal: true, // always execute on fs service instance
@@ -65,7 +74,9 @@ func registerMountSubcommands(parent *cmds.Command) {
 		for _, arg := range parent.Arguments {
 			if arg.Type == cmds.ArgString {
 				arg.Name = "sub" + arg.Name
-				arg.Required = true // NOTE: remove this if file support is added
+				// tables contain complete requests, so they're only accepted by the parent command
+				arg.Required = true
+				arg.Description = strings.ReplaceAll(arg.Description, ", or mount tables", "")
 				arg.Description = strings.ReplaceAll(arg.Description, mountTargetExamples, subExamples)
 			}
 			parentArgs = append(parentArgs, arg)
@@ -74,6 +85,7 @@ func registerMountSubcommands(parent *cmds.Command) {
 	}
 
 	template := &cmds.Command{
+		Options:  parent.Options,
 		Run:      parent.Run,
 		PostRun:  parent.PostRun,
 		Encoders: parent.Encoders,
@@ -92,33 +104,36 @@ func registerMountSubcommands(parent *cmds.Command) {
 	}
 
 	subcommands := make(map[string]*cmds.Command)
-	for _, api := range []filesystem.API{
-		filesystem.Fuse,
-		filesystem.Plan9Protocol,
-	} {
+	for _, api := range supportedHostAPIs {
 		hostName := api.String()
 		subsystems := make(map[string]*cmds.Command)
 
+		// 9P requests take listener addresses rather than host paths
+		var (
+			hostExamples = "(e.g. `/ipfs/path/ipfs /ipns/path/ipns ...`)"
+			nodeExamples = "(e.g. `/mnt/1 /mnt/2 ...`)"
+			nodeFormat   = "/%s/%s/path"
+		)
+		if api == filesystem.Plan9Protocol {
+			hostExamples = "(e.g. `/ipfs/ip4/127.0.0.1/tcp/564 /ipns/unix/tmp/ipns.9p ...`)"
+			nodeExamples = "(e.g. `/ip4/127.0.0.1/tcp/564 /unix/tmp/ipfs.9p ...`)"
+			nodeFormat = "/%s/%s"
+		}
+
 		com := new(cmds.Command)
 		*com = *template
 		prefix := fmt.Sprintf("/%s/", hostName)
-		com.Arguments = deriveArgs(parent.Arguments, "(e.g. `/ipfs/path/ipfs /ipns/path/ipns ...`)")
+		com.Arguments = deriveArgs(parent.Arguments, hostExamples)
 		com.PreRun = genPrerun(prefix)
 		com.Subcommands = subsystems
 		subcommands[hostName] = com
 
-		// TODO: we need 1 canonical "supported" array somewhere, defined at compile time; used by all
-		// as-is there's a few of these literal arrays
-		for _, id := range []filesystem.ID{
-			filesystem.IPFS,
-			filesystem.IPNS,
-			filesystem.PinFS,
-		} {
+		for _, id := range supportedNodeAPIs {
 			nodeName := id.String()
 			com := new(cmds.Command)
 			*com = *template
-			prefix := fmt.Sprintf("/%s/%s/path", hostName, nodeName)
-			com.Arguments = deriveArgs(parent.Arguments, "(e.g. `/mnt/1 /mnt/2 ...`)")
+			prefix := fmt.Sprintf(nodeFormat, hostName, nodeName)
+			com.Arguments = deriveArgs(parent.Arguments, nodeExamples)
 			com.PreRun = genPrerun(prefix)
 			subsystems[nodeName] = com
 		}
@@ -127,6 +142,9 @@ func registerMountSubcommands(parent *cmds.Command) {
 }
 
 func mountPreRun(request *cmds.Request, env cmds.Environment) (err error) {
+	if err = expandMountTables(request, env); err != nil {
+		return
+	}
 	if len(request.Arguments) == 0 {
 		return errors.New("no arguments provided - portable defaults not implemented yet")
 		/* TODO: update defaults - don't depend on go-ipfs config file
@@ -148,6 +166,11 @@ func mountRun(request *cmds.Request, emitter cmds.ResponseEmitter, env cmds.Envi
 		return envError(env)
 	}
 
+	persist, err := persistOption(request)
+	if err != nil {
+		return err
+	}
+
 	fsi, err := fsEnv.Manager(request)
 	if err != nil {
 		return err
@@ -157,11 +180,36 @@ func mountRun(request *cmds.Request, emitter cmds.ResponseEmitter, env cmds.Envi
 		ctx                     = request.Context
 		requests, requestErrors = manager.ParseRequests(ctx, request.Arguments...)
 		responses               = fsi.Bind(ctx, requests)
-		allErrs                 = emitResponses(ctx, emitter.Emit,
-			requestErrors, responses)
+
+		bound []manager.Request
+		relay = make(chan manager.Response)
 	)
+	go func() { // record the requests which were bound, relaying their responses
+		defer close(relay)
+		for response := range responses {
+			if response.Error == nil {
+				bound = append(bound, response.Request)
+			}
+			select {
+			case relay <- response:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
 
-	return flattenErrors("mount", allErrs)
+	allErrs := emitResponses(ctx, emitter.Emit, requestErrors, relay)
+	if err := flattenErrors("mount", allErrs); err != nil {
+		return err // (failed requests are unwound; nothing to persist)
+	}
+
+	if persist && ctx.Err() == nil {
+		ipfsAPI, _ := request.Options[rootIPFSOptionKwd].(string)
+		if err := persistRequests(ipfsAPI, bound...); err != nil {
+			return fmt.Errorf("requests were bound but could not be persisted: %w", err)
+		}
+	}
+	return nil
 }
 
 func formatMount(response cmds.Response, emitter cmds.ResponseEmitter) error {
@@ -0,0 +1,168 @@
+package fscmds
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	cmds "github.com/ipfs/go-ipfs-cmds"
+	files "github.com/ipfs/go-ipfs-files"
+	"github.com/ipfs/go-ipfs/filesystem/manager"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+const (
+	mountTableStdin   = "-"
+	mountTableMaxSize = 1 << 20 // tables are lists of short strings, anything larger is likely a mistake
+
+	mountTableDescription = `
+Arguments which are not requests are treated as mount tables.
+Tables may be read from stdin ('-'), a local file, or an IPFS path
+(e.g. '/ipfs/Qm.../table.json').
+
+Tables are either JSON; a list of requests:
+  ["/fuse/ipfs/path/ipfs", "/fuse/ipns/path/ipns"]
+or an object containing that list, and options to apply to them:
+  {"requests": ["/fuse/ipfs/path/ipfs"], "options": {"persist": true}}
+
+Or plain text, with one request per line
+(blank lines and lines starting with '#' are ignored).
+`
+)
+
+// options which may be provided by a mount table
+// (options provided on the command line take precedence)
+var mountTableOptions = map[string]struct{}{
+	persistOptionKwd: {},
+}
+
+// isMountRequest checks if the argument is addressed to one of our host APIs.
+func isMountRequest(argument string) bool {
+	header := strings.SplitN(strings.TrimPrefix(argument, "/"), "/", 2)[0]
+	for _, api := range supportedHostAPIs {
+		if header == api.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func isIPFSPath(argument string) bool {
+	for _, namespace := range []string{"/ipfs/", "/ipns/", "/ipld/"} {
+		if strings.HasPrefix(argument, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandMountTables replaces table references within the request's arguments,
+// with the requests contained in those tables.
+// Tables are read here (rather than by the service)
+// so that stdin and local files are read from the client's perspective.
+func expandMountTables(request *cmds.Request, env cmds.Environment) error {
+	var (
+		arguments = make([]string, 0, len(request.Arguments))
+		readStdin bool
+	)
+	for _, argument := range request.Arguments {
+		if isMountRequest(argument) {
+			arguments = append(arguments, argument)
+			continue
+		}
+
+		var (
+			table *manager.Table
+			err   error
+		)
+		switch {
+		case argument == mountTableStdin:
+			if readStdin {
+				return cmds.Errorf(cmds.ErrClient, "stdin (%q) may only be provided once", mountTableStdin)
+			}
+			readStdin = true
+			table, err = decodeMountTable(os.Stdin)
+
+		case isIPFSPath(argument):
+			table, err = ipfsMountTable(request, env, argument)
+
+		default:
+			var tableFile *os.File
+			if tableFile, err = os.Open(argument); err != nil {
+				if os.IsNotExist(err) {
+					return cmds.Errorf(cmds.ErrClient,
+						"%q is not a request or mount table %s", argument, mountTargetExamples)
+				}
+				break
+			}
+			table, err = decodeMountTable(tableFile)
+			if cErr := tableFile.Close(); err == nil {
+				err = cErr
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("could not read mount table %q: %w", argument, err)
+		}
+
+		if err := applyMountTableOptions(request, table.Options); err != nil {
+			return fmt.Errorf("mount table %q: %w", argument, err)
+		}
+		arguments = append(arguments, table.Requests...)
+	}
+
+	request.Arguments = arguments
+	return nil
+}
+
+func decodeMountTable(input io.Reader) (*manager.Table, error) {
+	tableData, err := ioutil.ReadAll(io.LimitReader(input, mountTableMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(tableData) > mountTableMaxSize {
+		return nil, fmt.Errorf("table exceeds maximum size (%d bytes)", mountTableMaxSize)
+	}
+	return manager.DecodeTable(bytes.NewReader(tableData))
+}
+
+// ipfsMountTable reads the table from the IPFS node that the request is using.
+func ipfsMountTable(request *cmds.Request, env cmds.Environment, ipfsPath string) (*manager.Table, error) {
+	fsEnv, envIsUsable := env.(FileSystemEnvironment)
+	if !envIsUsable {
+		return nil, envError(env)
+	}
+
+	core, err := fsEnv.IPFS(request)
+	if err != nil {
+		return nil, err
+	}
+
+	tableNode, err := core.Unixfs().Get(request.Context, corepath.New(ipfsPath))
+	if err != nil {
+		return nil, err
+	}
+	defer tableNode.Close()
+
+	tableFile, ok := tableNode.(files.File)
+	if !ok {
+		return nil, errors.New("path is not a file")
+	}
+	return decodeMountTable(tableFile)
+}
+
+func applyMountTableOptions(request *cmds.Request, options map[string]interface{}) error {
+	for name, value := range options {
+		if _, ok := mountTableOptions[name]; !ok {
+			return cmds.Errorf(cmds.ErrClient, "option %q can not be set by a mount table", name)
+		}
+		if _, provided := request.Options[name]; provided {
+			continue
+		}
+		request.Options[name] = value
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package p9
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	"github.com/ipfs/go-ipfs/filesystem/manager"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// p9Binder serves requests over the 9P protocol, on listeners created from the request's multiaddr
+type p9Binder struct {
+	ctx context.Context
+	srv *server
+}
+
+func NewBinder(ctx context.Context, fs filesystem.Interface) (manager.Binder, error) {
+	return &p9Binder{
+		ctx: ctx,
+		srv: newServer(ctx, fs),
+	}, nil
+}
+
+func (pb *p9Binder) Bind(ctx context.Context, requests manager.Requests) manager.Responses {
+	responses := make(chan manager.Response)
+	go func() {
+		defer close(responses)
+		for request := range requests {
+			response := manager.Response{Request: request}
+			listener, err := manet.Listen(request)
+			if err != nil {
+				response.Error = err
+			} else {
+				response.Closer = pb.serve(listener)
+			}
+
+			select {
+			case responses <- response:
+			case <-ctx.Done():
+				if listener != nil {
+					listener.Close()
+				}
+				return
+			}
+		}
+	}()
+
+	return responses
+}
+
+type closer func() error      // io.Closer closure wrapper
+func (f closer) Close() error { return f() }
+
+// serve accepts connections on the listener until the returned closer is called.
+// Closing will close the listener, and all connections that were accepted from it.
+func (pb *p9Binder) serve(listener manet.Listener) closer {
+	var (
+		wg        sync.WaitGroup
+		connLock  sync.Mutex
+		conns     = make(map[net.Conn]struct{})
+		closeOnce sync.Once
+		closeErr  error
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					pb.srv.log.Error(err)
+				}
+				return
+			}
+
+			var netConn net.Conn = conn
+			connLock.Lock()
+			conns[netConn] = struct{}{}
+			connLock.Unlock()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := pb.srv.serve(netConn); err != nil {
+					pb.srv.log.Error(err)
+				}
+				netConn.Close()
+				connLock.Lock()
+				delete(conns, netConn)
+				connLock.Unlock()
+			}()
+		}
+	}()
+
+	// if the binder's context is canceled, stop serving
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-pb.ctx.Done():
+			listener.Close()
+		case <-stop:
+		}
+	}()
+
+	return func() error {
+		closeOnce.Do(func() {
+			close(stop)
+			closeErr = listener.Close()
+			connLock.Lock()
+			for conn := range conns {
+				conn.Close()
+			}
+			connLock.Unlock()
+			wg.Wait()
+		})
+		return closeErr
+	}
+}
@@ -0,0 +1,9 @@
+// Package p9 defines a `manager.Binder` which serves `filesystem.Interface`s over the 9P2000.L protocol.
+//
+// Requests are expected to contain a listener multiaddr.
+// e.g. `/ip4/127.0.0.1/tcp/564`, `/unix/tmp/ipfs.9p`
+// (after the manager has stripped its header from `/9p/ipfs/ip4/127.0.0.1/tcp/564`)
+//
+// Clients such as the Linux v9fs driver may then attach to the listener.
+// e.g. `mount -t 9p -o trans=tcp,port=564,version=9p2000.L 127.0.0.1 /mnt/ipfs`
+package p9
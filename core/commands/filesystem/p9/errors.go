@@ -0,0 +1,66 @@
+package p9
+
+import (
+	"errors"
+	"fmt"
+
+	fserrors "github.com/ipfs/go-ipfs/filesystem/errors"
+)
+
+type errNo = uint32
+
+// 9P2000.L transmits Linux error numbers, regardless of the server's host platform.
+const (
+	ePERM      errNo = 1
+	eNOENT     errNo = 2
+	eIO        errNo = 5
+	eBADF      errNo = 9
+	eACCES     errNo = 13
+	eEXIST     errNo = 17
+	eNOTDIR    errNo = 20
+	eISDIR     errNo = 21
+	eINVAL     errNo = 22
+	eROFS      errNo = 30
+	eNOSYS     errNo = 38
+	eNOTEMPTY  errNo = 39
+	eNODATA    errNo = 61
+	eOPNOTSUPP errNo = 95
+)
+
+// translation table for interface.Error -> Linux error
+var kindToErrNo = map[fserrors.Kind]errNo{
+	fserrors.Other:            eIO,
+	fserrors.InvalidItem:      eINVAL,
+	fserrors.InvalidOperation: eNOSYS,
+	fserrors.Permission:       eACCES,
+	fserrors.IO:               eIO,
+	fserrors.Exist:            eEXIST,
+	fserrors.NotExist:         eNOENT,
+	fserrors.IsDir:            eISDIR,
+	fserrors.NotDir:           eNOTDIR,
+	fserrors.NotEmpty:         eNOTEMPTY,
+	fserrors.ReadOnly:         eROFS,
+}
+
+// protocolError is an error which already carries its 9P error number.
+type protocolError errNo
+
+func (pe protocolError) Error() string { return fmt.Sprintf("errno %d", errNo(pe)) }
+
+func interpretError(err error) errNo {
+	var pErr protocolError
+	if errors.As(err, &pErr) {
+		return errNo(pErr)
+	}
+
+	var fsErr fserrors.Error
+	if errors.As(err, &fsErr) {
+		if errNo, ok := kindToErrNo[fsErr.Kind()]; ok {
+			return errNo
+		}
+	}
+
+	// unlike FUSE, we're not going to panic on errors we don't recognize
+	// (they're typically transport errors from the node)
+	return eIO
+}
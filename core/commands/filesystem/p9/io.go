@@ -0,0 +1,289 @@
+package p9
+
+import (
+	"context"
+	"io"
+	gopath "path"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+)
+
+// Linux open flags (subset)
+const (
+	oRDONLY  = 0
+	oWRONLY  = 1
+	oRDWR    = 2
+	oACCMODE = 3
+	oCREAT   = 0100
+	oEXCL    = 0200
+	oTRUNC   = 01000
+	oAPPEND  = 02000
+)
+
+func ioFlagsFromLinux(flags uint32) filesystem.IOFlags {
+	var ioFlags filesystem.IOFlags
+	switch flags & oACCMODE {
+	case oWRONLY:
+		ioFlags = filesystem.IOWriteOnly
+	case oRDWR:
+		ioFlags = filesystem.IOReadWrite
+	default:
+		ioFlags = filesystem.IOReadOnly
+	}
+
+	for _, pair := range []struct {
+		linux uint32
+		io    filesystem.IOFlags
+	}{
+		{oCREAT, filesystem.IOCreate},
+		{oEXCL, filesystem.IOExclusive},
+		{oTRUNC, filesystem.IOTruncate},
+		{oAPPEND, filesystem.IOAppend},
+	} {
+		if flags&pair.linux != 0 {
+			ioFlags |= pair.io
+		}
+	}
+	return ioFlags
+}
+
+func (s *session) iounit() uint32 { return s.msize - ioHeaderSize }
+
+func (s *session) lopen(msg *message) (*encoder, error) {
+	var (
+		fidID = msg.uint32()
+		flags = msg.uint32()
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	f, err := s.getFid(fidID)
+	if err != nil {
+		return nil, err
+	}
+
+	fType, err := s.fileType(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	if f.file != nil || f.directory != nil {
+		return nil, protocolError(eBADF) // already open
+	}
+
+	if fType == coreiface.TDirectory {
+		if f.directory, err = s.nodeInterface.OpenDirectory(f.path); err != nil {
+			return nil, err
+		}
+	} else {
+		if f.file, err = s.nodeInterface.Open(f.path, ioFlagsFromLinux(flags)); err != nil {
+			return nil, err
+		}
+	}
+
+	response := newEncoder(rlopen, msg.Tag)
+	response.qid(qid{Type: qidType(fType), Path: pathHash(f.path)})
+	response.uint32(s.iounit())
+	return response, nil
+}
+
+func (s *session) read(msg *message) (*encoder, error) {
+	var (
+		fidID  = msg.uint32()
+		offset = msg.uint64()
+		count  = msg.uint32()
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	f, err := s.getFid(fidID)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	if f.file == nil {
+		if f.directory != nil {
+			return nil, protocolError(eISDIR)
+		}
+		return nil, protocolError(eBADF)
+	}
+
+	if iounit := s.iounit(); count > iounit {
+		count = iounit
+	}
+
+	if _, err := f.file.Seek(int64(offset), io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	buffer := make([]byte, count)
+	var read int
+	for read < len(buffer) {
+		n, err := f.file.Read(buffer[read:])
+		read += n
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	response := newEncoder(rread, msg.Tag)
+	response.uint32(uint32(read))
+	response.buffer = append(response.buffer, buffer[:read]...)
+	return response, nil
+}
+
+func (s *session) write(msg *message) (*encoder, error) {
+	var (
+		fidID  = msg.uint32()
+		offset = msg.uint64()
+		count  = msg.uint32()
+		data   = msg.take(int(count))
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	f, err := s.getFid(fidID)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	if f.file == nil {
+		if f.directory != nil {
+			return nil, protocolError(eISDIR)
+		}
+		return nil, protocolError(eBADF)
+	}
+
+	if _, err := f.file.Seek(int64(offset), io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	written, err := f.file.Write(data)
+	if err != nil {
+		return nil, err
+	}
+
+	response := newEncoder(rwrite, msg.Tag)
+	response.uint32(uint32(written))
+	return response, nil
+}
+
+func (s *session) fsync(msg *message) (*encoder, error) {
+	fidID := msg.uint32()
+	if msg.err != nil {
+		return nil, msg.err
+	}
+	f, err := s.getFid(fidID)
+	if err != nil {
+		return nil, err
+	}
+
+	// sync the file if the system supports it, otherwise the whole system (if it supports that)
+	f.Lock()
+	defer f.Unlock()
+	var syncer filesystem.Syncer
+	if f.file != nil {
+		syncer, _ = f.file.(filesystem.Syncer)
+	} else {
+		syncer, _ = s.nodeInterface.(filesystem.Syncer)
+	}
+	if syncer != nil {
+		if err := syncer.Sync(); err != nil {
+			s.log.Error(err)
+			return nil, protocolError(eIO)
+		}
+	}
+	return newEncoder(rfsync, msg.Tag), nil
+}
+
+func (s *session) readdir(msg *message) (*encoder, error) {
+	var (
+		fidID  = msg.uint32()
+		offset = msg.uint64()
+		count  = msg.uint32()
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	f, err := s.getFid(fidID)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	if f.directory == nil {
+		if f.file != nil {
+			return nil, protocolError(eNOTDIR)
+		}
+		return nil, protocolError(eBADF)
+	}
+
+	if iounit := s.iounit(); count > iounit {
+		count = iounit
+	}
+
+	if offset == 0 {
+		if err := f.directory.Reset(); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	// entries are encoded directly into the response body
+	// qid[13] offset[8] type[1] name[s]
+	var (
+		entries = &encoder{}
+		limit   = int(count)
+	)
+	for ent := range f.directory.List(ctx, offset) {
+		if err := ent.Error(); err != nil {
+			if len(entries.buffer) != 0 {
+				break // return what we have, the client will request the error again
+			}
+			return nil, err
+		}
+
+		name := ent.Name()
+		if len(entries.buffer)+qidSize+8+1+2+len(name) > limit {
+			// the entry remains in the stream,
+			// and will be replayed when the client requests the current offset
+			break
+		}
+
+		entryPath := gopath.Join(f.path, name)
+		fType, err := s.fileType(entryPath)
+		if err != nil {
+			return nil, err
+		}
+
+		entries.qid(qid{Type: qidType(fType), Path: pathHash(entryPath)})
+		entries.uint64(ent.Offset())
+		entries.uint8(direntType(fType))
+		entries.string(name)
+	}
+
+	response := newEncoder(rreaddir, msg.Tag)
+	response.uint32(uint32(len(entries.buffer)))
+	response.buffer = append(response.buffer, entries.buffer...)
+	return response, nil
+}
@@ -0,0 +1,316 @@
+package p9
+
+import (
+	gopath "path"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+)
+
+// AT_REMOVEDIR flag value for `Tunlinkat`
+const atRemoveDir = 0x200
+
+func (s *session) lcreate(msg *message) (*encoder, error) {
+	var (
+		fidID = msg.uint32()
+		name  = msg.string()
+		flags = msg.uint32()
+		_     = msg.uint32() // mode
+		_     = msg.uint32() // gid
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	f, err := s.getFid(fidID)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := childPath(f.path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	if f.file != nil || f.directory != nil {
+		return nil, protocolError(eBADF)
+	}
+
+	// the fid now refers to the newly created file, opened
+	ioFlags := ioFlagsFromLinux(flags) | filesystem.IOCreate | filesystem.IOExclusive
+	if f.file, err = s.nodeInterface.Open(path, ioFlags); err != nil {
+		return nil, err
+	}
+	f.path = path
+
+	response := newEncoder(rlcreate, msg.Tag)
+	response.qid(qid{Type: qtFile, Path: pathHash(path)})
+	response.uint32(s.iounit())
+	return response, nil
+}
+
+// makeChild is the common implementation for requests which create a new entry within a directory fid.
+func (s *session) makeChild(dirFidID uint32, name string, maker func(path string) error, fType coreiface.FileType) (qid, error) {
+	dir, err := s.getFid(dirFidID)
+	if err != nil {
+		return qid{}, err
+	}
+
+	path, err := childPath(dir.path, name)
+	if err != nil {
+		return qid{}, err
+	}
+
+	if err := maker(path); err != nil {
+		return qid{}, err
+	}
+
+	return qid{Type: qidType(fType), Path: pathHash(path)}, nil
+}
+
+func (s *session) mkdir(msg *message) (*encoder, error) {
+	var (
+		fidID = msg.uint32()
+		name  = msg.string()
+		_     = msg.uint32() // mode
+		_     = msg.uint32() // gid
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	q, err := s.makeChild(fidID, name, s.nodeInterface.MakeDirectory, coreiface.TDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	response := newEncoder(rmkdir, msg.Tag)
+	response.qid(q)
+	return response, nil
+}
+
+func (s *session) symlink(msg *message) (*encoder, error) {
+	var (
+		fidID  = msg.uint32()
+		name   = msg.string()
+		target = msg.string()
+		_      = msg.uint32() // gid
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	maker := func(path string) error { return s.nodeInterface.MakeLink(path, target) }
+	q, err := s.makeChild(fidID, name, maker, coreiface.TSymlink)
+	if err != nil {
+		return nil, err
+	}
+
+	response := newEncoder(rsymlink, msg.Tag)
+	response.qid(q)
+	return response, nil
+}
+
+func (s *session) mknod(msg *message) (*encoder, error) {
+	var (
+		fidID = msg.uint32()
+		name  = msg.string()
+		mode  = msg.uint32()
+		_     = msg.uint32() // major
+		_     = msg.uint32() // minor
+		_     = msg.uint32() // gid
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	// only regular files are supported
+	if fmt := mode & sIFMT; fmt != 0 && fmt != sIFREG {
+		return nil, protocolError(ePERM)
+	}
+
+	q, err := s.makeChild(fidID, name, s.nodeInterface.Make, coreiface.TFile)
+	if err != nil {
+		return nil, err
+	}
+
+	response := newEncoder(rmknod, msg.Tag)
+	response.qid(q)
+	return response, nil
+}
+
+// hard links share the node between both names, until either is modified
+func (s *session) link(msg *message) (*encoder, error) {
+	var (
+		dirFidID = msg.uint32()
+		fidID    = msg.uint32()
+		name     = msg.string()
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	f, err := s.getFid(fidID)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	maker := func(path string) error { return interfaceutils.HardLink(s.nodeInterface, f.path, path) }
+	if _, err := s.makeChild(dirFidID, name, maker, coreiface.TFile); err != nil {
+		return nil, err
+	}
+
+	return newEncoder(rlink, msg.Tag), nil
+}
+
+func (s *session) rename(msg *message) (*encoder, error) {
+	var (
+		fidID    = msg.uint32()
+		dirFidID = msg.uint32()
+		name     = msg.string()
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	f, err := s.getFid(fidID)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := s.getFid(dirFidID)
+	if err != nil {
+		return nil, err
+	}
+
+	newPath, err := childPath(dir.path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	if err := s.nodeInterface.Rename(f.path, newPath); err != nil {
+		return nil, err
+	}
+	f.path = newPath
+
+	return newEncoder(rrename, msg.Tag), nil
+}
+
+func (s *session) renameat(msg *message) (*encoder, error) {
+	var (
+		oldDirFidID = msg.uint32()
+		oldName     = msg.string()
+		newDirFidID = msg.uint32()
+		newName     = msg.string()
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	oldDir, err := s.getFid(oldDirFidID)
+	if err != nil {
+		return nil, err
+	}
+	newDir, err := s.getFid(newDirFidID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldPath, err := childPath(oldDir.path, oldName)
+	if err != nil {
+		return nil, err
+	}
+	newPath, err := childPath(newDir.path, newName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.nodeInterface.Rename(oldPath, newPath); err != nil {
+		return nil, err
+	}
+
+	return newEncoder(rrenameat, msg.Tag), nil
+}
+
+// removePath removes the entry at path, using the method appropriate for its type.
+func (s *session) removePath(path string, isDir bool) error {
+	if isDir {
+		return s.nodeInterface.RemoveDirectory(path)
+	}
+
+	fType, err := s.fileType(path)
+	if err != nil {
+		return err
+	}
+	switch fType {
+	case coreiface.TDirectory:
+		return protocolError(eISDIR)
+	case coreiface.TSymlink:
+		return s.nodeInterface.RemoveLink(path)
+	default:
+		return s.nodeInterface.Remove(path)
+	}
+}
+
+func (s *session) unlinkat(msg *message) (*encoder, error) {
+	var (
+		dirFidID = msg.uint32()
+		name     = msg.string()
+		flags    = msg.uint32()
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	dir, err := s.getFid(dirFidID)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := childPath(dir.path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.removePath(path, flags&atRemoveDir != 0); err != nil {
+		return nil, err
+	}
+
+	return newEncoder(runlinkat, msg.Tag), nil
+}
+
+func (s *session) remove(msg *message) (*encoder, error) {
+	fidID := msg.uint32()
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	// the fid is clunked regardless of the outcome of the removal
+	f, err := s.fids.remove(fidID)
+	if err != nil {
+		return nil, protocolError(eBADF)
+	}
+	if err := f.close(); err != nil {
+		s.log.Error(err)
+	}
+
+	if f.path == gopath.Dir(f.path) { // root
+		return nil, protocolError(eACCES)
+	}
+
+	fType, err := s.fileType(f.path)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.removePath(f.path, fType == coreiface.TDirectory); err != nil {
+		return nil, err
+	}
+
+	return newEncoder(rremove, msg.Tag), nil
+}
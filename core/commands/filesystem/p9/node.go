@@ -0,0 +1,427 @@
+package p9
+
+import (
+	"hash/fnv"
+	gopath "path"
+	"time"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+)
+
+// Linux mode type bits
+const (
+	sIFMT  = 0170000
+	sIFDIR = 0040000
+	sIFREG = 0100000
+	sIFLNK = 0120000
+)
+
+// Linux dirent types
+const (
+	dtUnknown uint8 = 0
+	dtDir     uint8 = 4
+	dtReg     uint8 = 8
+	dtLnk     uint8 = 10
+)
+
+// Tgetattr mask of the fields we return (mode through blocks)
+const getattrBasic = 0x000007ff
+
+// Tsetattr valid bits
+const (
+	setattrMode = 0x00000001
+	setattrUID  = 0x00000002
+	setattrGID  = 0x00000004
+	setattrSize = 0x00000008
+	// setattrATime = 0x00000010 // we don't store access times
+	setattrMTime = 0x00000020
+	// setattrCTime = 0x00000040
+	// setattrATimeSet = 0x00000080
+	setattrMTimeSet = 0x00000100
+)
+
+const (
+	v9fsMagic        = 0x01021997
+	defaultBlockSize = 4096
+	maxNameLength    = 255
+)
+
+func pathHash(path string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(path))
+	return hasher.Sum64()
+}
+
+func qidType(fType coreiface.FileType) uint8 {
+	switch fType {
+	case coreiface.TDirectory:
+		return qtDir
+	case coreiface.TSymlink:
+		return qtSymlink
+	default:
+		return qtFile
+	}
+}
+
+func direntType(fType coreiface.FileType) uint8 {
+	switch fType {
+	case coreiface.TDirectory:
+		return dtDir
+	case coreiface.TSymlink:
+		return dtLnk
+	case coreiface.TFile:
+		return dtReg
+	default:
+		return dtUnknown
+	}
+}
+
+func (s *session) fileType(path string) (coreiface.FileType, error) {
+	stat, _, err := s.nodeInterface.Info(path, filesystem.StatRequest{Type: true})
+	if err != nil {
+		return 0, err
+	}
+	return stat.Type, nil
+}
+
+func (s *session) qid(path string) (qid, error) {
+	fType, err := s.fileType(path)
+	if err != nil {
+		return qid{}, err
+	}
+	return qid{Type: qidType(fType), Path: pathHash(path)}, nil
+}
+
+func (s *session) walk(msg *message) (*encoder, error) {
+	var (
+		fidID    = msg.uint32()
+		newFidID = msg.uint32()
+		count    = msg.uint16()
+	)
+	if count > maxWalkElements {
+		return nil, protocolError(eINVAL)
+	}
+	names := make([]string, count)
+	for i := range names {
+		names[i] = msg.string()
+	}
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	f, err := s.getFid(fidID)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	if newFidID == fidID && (f.file != nil || f.directory != nil) {
+		return nil, protocolError(eBADF) // can't walk open fids
+	}
+
+	var (
+		path = f.path
+		qids = make([]qid, 0, len(names))
+	)
+walk:
+	for _, name := range names {
+		switch name {
+		case "..":
+			// walks can't escape the subtree that was attached
+			if path != f.root {
+				path = gopath.Dir(path)
+			}
+		case ".":
+		default:
+			if path, err = childPath(path, name); err != nil {
+				break walk
+			}
+		}
+		var q qid
+		if q, err = s.qid(path); err != nil {
+			break walk
+		}
+		qids = append(qids, q)
+	}
+
+	// if the first element can't be walked, the error is returned
+	// otherwise the partial list of qids is, and the new fid is not created
+	if len(qids) == 0 && len(names) != 0 {
+		return nil, err
+	}
+	if len(qids) == len(names) {
+		if newFidID == fidID {
+			f.path = path
+		} else if err := s.fids.add(newFidID, &fid{path: path, root: f.root}); err != nil {
+			return nil, err
+		}
+	}
+
+	response := newEncoder(rwalk, msg.Tag)
+	response.uint16(uint16(len(qids)))
+	for _, q := range qids {
+		response.qid(q)
+	}
+	return response, nil
+}
+
+func (s *session) getattr(msg *message) (*encoder, error) {
+	var (
+		fidID = msg.uint32()
+		_     = msg.uint64() // request mask; we always return the basic set
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	f, err := s.getFid(fidID)
+	if err != nil {
+		return nil, err
+	}
+
+	iStat, filled, err := s.nodeInterface.Info(f.path, filesystem.StatRequestAll)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mode  uint32
+		nlink uint64 = 1
+	)
+	switch iStat.Type {
+	case coreiface.TDirectory:
+		mode, nlink = sIFDIR, 2
+	case coreiface.TSymlink:
+		mode = sIFLNK
+	default:
+		mode = sIFREG
+	}
+	if filled.Links {
+		nlink = iStat.Links
+	}
+	switch {
+	case filled.Mode:
+		mode |= iStat.Mode & 07777
+	case s.filesWritable:
+		mode |= 0774
+	default:
+		mode |= 0554
+	}
+
+	blockSize := iStat.BlockSize
+	if blockSize == 0 {
+		blockSize = defaultBlockSize
+	}
+
+	var (
+		seconds = uint64(s.mountTime.Unix())
+		nanos   = uint64(s.mountTime.Nanosecond())
+		// UFS only stores modification times
+		mSeconds, mNanos = seconds, nanos
+	)
+	if filled.MTime {
+		mSeconds, mNanos = uint64(iStat.MTime.Unix()), uint64(iStat.MTime.Nanosecond())
+	}
+
+	response := newEncoder(rgetattr, msg.Tag)
+	response.uint64(getattrBasic)
+	response.qid(qid{Type: qidType(iStat.Type), Path: pathHash(f.path)})
+	response.uint32(mode)
+	response.uint32(s.uid)
+	response.uint32(s.gid)
+	response.uint64(nlink)
+	response.uint64(0) // rdev
+	response.uint64(iStat.Size)
+	response.uint64(blockSize)
+	response.uint64((iStat.Size + 511) / 512) // blocks are reported in 512-byte units
+
+	response.uint64(seconds) // atime
+	response.uint64(nanos)
+	response.uint64(mSeconds) // mtime
+	response.uint64(mNanos)
+	response.uint64(mSeconds) // ctime; we treat modifications as the last change
+	response.uint64(mNanos)
+	response.uint64(seconds) // btime
+	response.uint64(nanos)
+	response.uint64(0) // gen
+	response.uint64(0) // data_version
+	return response, nil
+}
+
+func (s *session) setattr(msg *message) (*encoder, error) {
+	var (
+		fidID  = msg.uint32()
+		valid  = msg.uint32()
+		mode   = msg.uint32()
+		_      = msg.uint32() // uid
+		_      = msg.uint32() // gid
+		size   = msg.uint64()
+		_      = msg.uint64() // atime seconds; we don't store access times
+		_      = msg.uint64() // atime nanoseconds
+		mTimeS = msg.uint64()
+		mTimeN = msg.uint64()
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	f, err := s.getFid(fidID)
+	if err != nil {
+		return nil, err
+	}
+
+	if valid&(setattrUID|setattrGID) != 0 {
+		return nil, protocolError(eNOSYS)
+	}
+
+	if valid&(setattrMode|setattrMTime) != 0 {
+		modifier, ok := s.nodeInterface.(filesystem.MetadataModifier)
+		if !ok {
+			return nil, protocolError(eNOSYS)
+		}
+		if valid&setattrMode != 0 {
+			if err := modifier.Chmod(f.path, mode); err != nil {
+				return nil, err
+			}
+		}
+		if valid&setattrMTime != 0 {
+			mtime := time.Now()
+			if valid&setattrMTimeSet != 0 {
+				mtime = time.Unix(int64(mTimeS), int64(mTimeN))
+			}
+			if err := modifier.Chtimes(f.path, mtime); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if valid&setattrSize != 0 {
+		f.Lock()
+		defer f.Unlock()
+		if f.file != nil {
+			err = f.file.Truncate(size)
+		} else {
+			var file filesystem.File
+			if file, err = s.nodeInterface.Open(f.path, filesystem.IOWriteOnly); err != nil {
+				return nil, err
+			}
+			err = file.Truncate(size)
+			if cErr := file.Close(); err == nil {
+				err = cErr
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newEncoder(rsetattr, msg.Tag), nil
+}
+
+func (s *session) statfs(msg *message) (*encoder, error) {
+	fidID := msg.uint32()
+	if msg.err != nil {
+		return nil, msg.err
+	}
+	if _, err := s.getFid(fidID); err != nil {
+		return nil, err
+	}
+
+	// systems which don't report their storage are reported as empty
+	var blocks, free, files uint64
+	if stater, ok := s.nodeInterface.(filesystem.StorageStater); ok {
+		storage, err := stater.StorageStat()
+		if err != nil {
+			return nil, err
+		}
+		blocks = storage.Capacity / defaultBlockSize
+		if storage.Capacity > storage.Used {
+			free = (storage.Capacity - storage.Used) / defaultBlockSize
+		}
+		// objects are at least 1 block in size
+		files = storage.Objects + free
+	}
+
+	response := newEncoder(rstatfs, msg.Tag)
+	response.uint32(v9fsMagic)
+	response.uint32(defaultBlockSize)
+	response.uint64(blocks)
+	response.uint64(free)  // bfree
+	response.uint64(free)  // bavail
+	response.uint64(files) // files
+	response.uint64(free)  // ffree
+	response.uint64(0)     // fsid
+	response.uint32(maxNameLength)
+	return response, nil
+}
+
+func (s *session) readlink(msg *message) (*encoder, error) {
+	fidID := msg.uint32()
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	f, err := s.getFid(fidID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.nodeInterface.ExtractLink(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	response := newEncoder(rreadlink, msg.Tag)
+	response.string(target)
+	return response, nil
+}
+
+func (s *session) xattrwalk(*message) (*encoder, error) {
+	return nil, protocolError(eOPNOTSUPP)
+}
+
+func (s *session) xattrcreate(*message) (*encoder, error) {
+	return nil, protocolError(eOPNOTSUPP)
+}
+
+// advisory locks are always granted; the node provides no locking primitives
+const (
+	lockSuccess  = 0
+	lockTypeUnlk = 2
+)
+
+func (s *session) lock(msg *message) (*encoder, error) {
+	if _, err := s.getFid(msg.uint32()); err != nil {
+		return nil, err
+	}
+	response := newEncoder(rlock, msg.Tag)
+	response.uint8(lockSuccess)
+	return response, nil
+}
+
+func (s *session) getlock(msg *message) (*encoder, error) {
+	var (
+		fidID    = msg.uint32()
+		_        = msg.uint8() // type
+		start    = msg.uint64()
+		length   = msg.uint64()
+		procID   = msg.uint32()
+		clientID = msg.string()
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+	if _, err := s.getFid(fidID); err != nil {
+		return nil, err
+	}
+
+	response := newEncoder(rgetlock, msg.Tag)
+	response.uint8(lockTypeUnlk)
+	response.uint64(start)
+	response.uint64(length)
+	response.uint32(procID)
+	response.string(clientID)
+	return response, nil
+}
@@ -0,0 +1,228 @@
+package p9
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+type messageType = uint8
+
+// 9P2000.L message types
+// (subset; requests we don't recognize are responded to with `Rlerror`)
+const (
+	tlerror      messageType = 6
+	rlerror      messageType = 7
+	tstatfs      messageType = 8
+	rstatfs      messageType = 9
+	tlopen       messageType = 12
+	rlopen       messageType = 13
+	tlcreate     messageType = 14
+	rlcreate     messageType = 15
+	tsymlink     messageType = 16
+	rsymlink     messageType = 17
+	tmknod       messageType = 18
+	rmknod       messageType = 19
+	trename      messageType = 20
+	rrename      messageType = 21
+	treadlink    messageType = 22
+	rreadlink    messageType = 23
+	tgetattr     messageType = 24
+	rgetattr     messageType = 25
+	tsetattr     messageType = 26
+	rsetattr     messageType = 27
+	txattrwalk   messageType = 30
+	txattrcreate messageType = 32
+	treaddir     messageType = 40
+	rreaddir     messageType = 41
+	tfsync       messageType = 50
+	rfsync       messageType = 51
+	tlock        messageType = 52
+	rlock        messageType = 53
+	tgetlock     messageType = 54
+	rgetlock     messageType = 55
+	tlink        messageType = 70
+	rlink        messageType = 71
+	tmkdir       messageType = 72
+	rmkdir       messageType = 73
+	trenameat    messageType = 74
+	rrenameat    messageType = 75
+	tunlinkat    messageType = 76
+	runlinkat    messageType = 77
+	tversion     messageType = 100
+	rversion     messageType = 101
+	tauth        messageType = 102
+	tattach      messageType = 104
+	rattach      messageType = 105
+	tflush       messageType = 108
+	rflush       messageType = 109
+	twalk        messageType = 110
+	rwalk        messageType = 111
+	tread        messageType = 116
+	rread        messageType = 117
+	twrite       messageType = 118
+	rwrite       messageType = 119
+	tclunk       messageType = 120
+	rclunk       messageType = 121
+	tremove      messageType = 122
+	rremove      messageType = 123
+)
+
+const (
+	protocolVersion = "9P2000.L"
+	unknownVersion  = "unknown"
+
+	noTag uint16 = ^uint16(0)
+	noFid uint32 = ^uint32(0)
+
+	headerSize = 4 + 1 + 2 // size[4] type[1] tag[2]
+	// ioHeaderSize is the overhead of a Tread/Rwrite header
+	// size[4] type[1] tag[2] fid[4] offset[8] count[4]
+	ioHeaderSize = headerSize + 4 + 8 + 4
+
+	maxMessageSize  = 1 << 20
+	maxWalkElements = 16
+)
+
+// qid types
+const (
+	qtDir     uint8 = 0x80
+	qtSymlink uint8 = 0x02
+	qtFile    uint8 = 0x00
+)
+
+// qid is the server's unique identification for a file.
+type qid struct {
+	Type    uint8
+	Version uint32
+	Path    uint64
+}
+
+const qidSize = 1 + 4 + 8
+
+var errShortMessage = errors.New("message too short")
+
+// decoder reads 9P values from a message body.
+// The first error encountered is retained, and subsequent reads become no-ops.
+type decoder struct {
+	buffer []byte
+	err    error
+}
+
+func (d *decoder) take(size int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.buffer) < size {
+		d.err = errShortMessage
+		return nil
+	}
+	value := d.buffer[:size]
+	d.buffer = d.buffer[size:]
+	return value
+}
+
+func (d *decoder) uint8() (v uint8) {
+	if b := d.take(1); b != nil {
+		v = b[0]
+	}
+	return
+}
+
+func (d *decoder) uint16() (v uint16) {
+	if b := d.take(2); b != nil {
+		v = binary.LittleEndian.Uint16(b)
+	}
+	return
+}
+
+func (d *decoder) uint32() (v uint32) {
+	if b := d.take(4); b != nil {
+		v = binary.LittleEndian.Uint32(b)
+	}
+	return
+}
+
+func (d *decoder) uint64() (v uint64) {
+	if b := d.take(8); b != nil {
+		v = binary.LittleEndian.Uint64(b)
+	}
+	return
+}
+
+func (d *decoder) string() string { return string(d.take(int(d.uint16()))) }
+
+// encoder builds a 9P message; its size field is populated in `bytes`.
+type encoder struct{ buffer []byte }
+
+func newEncoder(mType messageType, tag uint16) *encoder {
+	e := &encoder{buffer: make([]byte, 4, 64)}
+	e.uint8(mType)
+	e.uint16(tag)
+	return e
+}
+
+func (e *encoder) uint8(v uint8) { e.buffer = append(e.buffer, v) }
+func (e *encoder) uint16(v uint16) {
+	e.buffer = append(e.buffer, byte(v), byte(v>>8))
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.buffer = append(e.buffer, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func (e *encoder) uint64(v uint64) {
+	e.uint32(uint32(v))
+	e.uint32(uint32(v >> 32))
+}
+
+func (e *encoder) string(s string) {
+	e.uint16(uint16(len(s)))
+	e.buffer = append(e.buffer, s...)
+}
+
+func (e *encoder) qid(q qid) {
+	e.uint8(q.Type)
+	e.uint32(q.Version)
+	e.uint64(q.Path)
+}
+
+func (e *encoder) bytes() []byte {
+	binary.LittleEndian.PutUint32(e.buffer, uint32(len(e.buffer)))
+	return e.buffer
+}
+
+// message is a decoded 9P message header, along with its (undecoded) body.
+type message struct {
+	Type messageType
+	Tag  uint16
+	decoder
+}
+
+// readMessage reads a single message from the reader,
+// rejecting messages which are larger than `limit`.
+func readMessage(reader io.Reader, limit uint32) (*message, error) {
+	var sizeBuffer [4]byte
+	if _, err := io.ReadFull(reader, sizeBuffer[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.LittleEndian.Uint32(sizeBuffer[:])
+	if size < headerSize {
+		return nil, fmt.Errorf("message size %d is smaller than the header", size)
+	}
+	if size > limit {
+		return nil, fmt.Errorf("message size %d exceeds negotiated limit %d", size, limit)
+	}
+
+	body := make([]byte, size-4)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, err
+	}
+
+	msg := &message{decoder: decoder{buffer: body}}
+	msg.Type = msg.uint8()
+	msg.Tag = msg.uint16()
+	return msg, nil
+}
@@ -0,0 +1,386 @@
+package p9
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	gopath "path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	logging "github.com/ipfs/go-log"
+)
+
+// server holds the values shared by all sessions of a single `filesystem.Interface`.
+type server struct {
+	ctx           context.Context
+	nodeInterface filesystem.Interface
+	log           logging.EventLogger
+
+	filesWritable bool      // switch for metadata fields
+	mountTime     time.Time // reported for all timestamps
+}
+
+func newServer(ctx context.Context, fs filesystem.Interface) *server {
+	logName := strings.ToLower(gopath.Join("9p", fs.ID().String()))
+	srv := &server{
+		ctx:           ctx,
+		nodeInterface: fs,
+		log:           logging.Logger(logName),
+		mountTime:     time.Now(),
+	}
+
+	// TODO: same hardcoded list as the FUSE binder; this should be an option
+	switch fs.ID() {
+	case filesystem.KeyFS, filesystem.Files, filesystem.RootFS:
+		srv.filesWritable = true
+	}
+	return srv
+}
+
+type (
+	// fid is the client's reference to a file within the system.
+	// Its path is absolute to the system's root,
+	// and it holds the open `File` or `Directory` (if any) after `Tlopen`/`Tlcreate`.
+	// Its root is the path that was attached, which walks can't ascend beyond.
+	fid struct {
+		sync.Mutex
+		path      string
+		root      string
+		file      filesystem.File
+		directory filesystem.Directory
+	}
+
+	fidTable struct {
+		sync.Mutex
+		fids map[uint32]*fid
+	}
+)
+
+var (
+	errFidInUse   = errors.New("fid already in use")
+	errFidUnknown = errors.New("fid not found")
+)
+
+func (ft *fidTable) add(id uint32, f *fid) error {
+	ft.Lock()
+	defer ft.Unlock()
+	if id == noFid {
+		return protocolError(eBADF)
+	}
+	if _, exists := ft.fids[id]; exists {
+		return fmt.Errorf("%w: %d", errFidInUse, id)
+	}
+	ft.fids[id] = f
+	return nil
+}
+
+func (ft *fidTable) get(id uint32) (*fid, error) {
+	ft.Lock()
+	defer ft.Unlock()
+	f, exists := ft.fids[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %d", errFidUnknown, id)
+	}
+	return f, nil
+}
+
+func (ft *fidTable) remove(id uint32) (*fid, error) {
+	ft.Lock()
+	defer ft.Unlock()
+	f, exists := ft.fids[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %d", errFidUnknown, id)
+	}
+	delete(ft.fids, id)
+	return f, nil
+}
+
+// reset removes all fids from the table, closing any that are open.
+func (ft *fidTable) reset() (err error) {
+	ft.Lock()
+	defer ft.Unlock()
+	for id, f := range ft.fids {
+		if cErr := f.close(); cErr != nil && err == nil {
+			err = cErr
+		}
+		delete(ft.fids, id)
+	}
+	return
+}
+
+// close releases the fid's open references (if any).
+func (f *fid) close() (err error) {
+	f.Lock()
+	defer f.Unlock()
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	if f.directory != nil {
+		if dErr := f.directory.Close(); err == nil {
+			err = dErr
+		}
+		f.directory = nil
+	}
+	return
+}
+
+type (
+	// session holds the state of a single client connection.
+	session struct {
+		*server
+		conn  net.Conn
+		msize uint32
+		fids  fidTable
+
+		uid, gid uint32 // provided by the client in `Tattach`
+
+		writeLock sync.Mutex
+
+		inflightLock sync.Mutex
+		inflight     map[uint16]chan struct{} // closed when the tagged request has been responded to
+	}
+
+	handlerFunc func(*session, *message) (*encoder, error)
+)
+
+var handlers = map[messageType]handlerFunc{
+	tauth:        (*session).auth,
+	tattach:      (*session).attach,
+	tflush:       (*session).flush,
+	twalk:        (*session).walk,
+	tclunk:       (*session).clunk,
+	tremove:      (*session).remove,
+	tstatfs:      (*session).statfs,
+	tgetattr:     (*session).getattr,
+	tsetattr:     (*session).setattr,
+	treadlink:    (*session).readlink,
+	txattrwalk:   (*session).xattrwalk,
+	txattrcreate: (*session).xattrcreate,
+	tlopen:       (*session).lopen,
+	tread:        (*session).read,
+	twrite:       (*session).write,
+	tfsync:       (*session).fsync,
+	tlock:        (*session).lock,
+	tgetlock:     (*session).getlock,
+	treaddir:     (*session).readdir,
+	tlcreate:     (*session).lcreate,
+	tmkdir:       (*session).mkdir,
+	tsymlink:     (*session).symlink,
+	tmknod:       (*session).mknod,
+	tlink:        (*session).link,
+	trename:      (*session).rename,
+	trenameat:    (*session).renameat,
+	tunlinkat:    (*session).unlinkat,
+}
+
+// serve handles messages from the connection until it is closed.
+func (srv *server) serve(conn net.Conn) error {
+	s := &session{
+		server:   srv,
+		conn:     conn,
+		msize:    maxMessageSize,
+		fids:     fidTable{fids: make(map[uint32]*fid)},
+		inflight: make(map[uint16]chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		if err := s.fids.reset(); err != nil {
+			s.log.Error(err)
+		}
+	}()
+
+	for {
+		msg, err := readMessage(conn, s.msize)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		// version negotiation resets the session,
+		// so it must not run concurrently with any other request
+		if msg.Type == tversion {
+			wg.Wait()
+			s.respond(msg, s.version)
+			continue
+		}
+
+		done := make(chan struct{})
+		s.inflightLock.Lock()
+		s.inflight[msg.Tag] = done
+		s.inflightLock.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				// the client may have reused the tag already (once it received the response)
+				s.inflightLock.Lock()
+				if s.inflight[msg.Tag] == done {
+					delete(s.inflight, msg.Tag)
+				}
+				s.inflightLock.Unlock()
+				close(done)
+			}()
+
+			handler, ok := handlers[msg.Type]
+			if !ok {
+				s.log.Warnf("unsupported message type %d", msg.Type)
+				s.respond(msg, func(*message) (*encoder, error) { return nil, protocolError(eOPNOTSUPP) })
+				return
+			}
+			s.respond(msg, func(msg *message) (*encoder, error) { return handler(s, msg) })
+		}()
+	}
+}
+
+// respond calls the handler and writes its response (or error) to the connection.
+func (s *session) respond(msg *message, handler func(*message) (*encoder, error)) {
+	response, err := handler(msg)
+	if err == nil && msg.err != nil {
+		err = protocolError(eINVAL) // handler didn't check; the message was malformed
+	}
+	if err != nil {
+		errNo := interpretError(err)
+		if errNo != eNOENT { // don't flood the logs with "not found" errors
+			s.log.Error(err)
+		}
+		response = newEncoder(rlerror, msg.Tag)
+		response.uint32(errNo)
+	}
+
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	if _, err := s.conn.Write(response.bytes()); err != nil {
+		s.log.Error(err)
+	}
+}
+
+func (s *session) version(msg *message) (*encoder, error) {
+	var (
+		msize   = msg.uint32()
+		version = msg.string()
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	if msize < s.msize {
+		s.msize = msize
+	}
+	if !strings.HasPrefix(version, protocolVersion) {
+		version = unknownVersion
+	} else {
+		version = protocolVersion
+	}
+
+	// a version request aborts all existing I/O
+	if err := s.fids.reset(); err != nil {
+		s.log.Error(err)
+	}
+
+	response := newEncoder(rversion, msg.Tag)
+	response.uint32(s.msize)
+	response.string(version)
+	return response, nil
+}
+
+func (s *session) auth(*message) (*encoder, error) {
+	return nil, protocolError(eOPNOTSUPP) // authentication is not required
+}
+
+func (s *session) attach(msg *message) (*encoder, error) {
+	var (
+		fidID = msg.uint32()
+		_     = msg.uint32() // afid
+		_     = msg.string() // uname
+		aname = msg.string()
+		uid   = msg.uint32()
+	)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	if uid != noFid { // n_uname uses the same sentinel value as NOFID
+		s.uid, s.gid = uid, uid
+	}
+
+	// the attach name (if any) is the subpath within the system to use as the root
+	// e.g. `mount -t 9p -o aname=/Qm... ...`
+	root := gopath.Join("/", aname)
+	rootQid, err := s.qid(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.fids.add(fidID, &fid{path: root, root: root}); err != nil {
+		return nil, err
+	}
+
+	response := newEncoder(rattach, msg.Tag)
+	response.qid(rootQid)
+	return response, nil
+}
+
+func (s *session) flush(msg *message) (*encoder, error) {
+	oldTag := msg.uint16()
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	// we don't interrupt requests,
+	// but we must not respond to the flush before the flushed request has responded
+	s.inflightLock.Lock()
+	done, pending := s.inflight[oldTag]
+	s.inflightLock.Unlock()
+	if pending && oldTag != msg.Tag {
+		<-done
+	}
+
+	return newEncoder(rflush, msg.Tag), nil
+}
+
+func (s *session) clunk(msg *message) (*encoder, error) {
+	fidID := msg.uint32()
+	if msg.err != nil {
+		return nil, msg.err
+	}
+
+	f, err := s.fids.remove(fidID)
+	if err != nil {
+		return nil, protocolError(eBADF)
+	}
+	if err := f.close(); err != nil {
+		return nil, err
+	}
+
+	return newEncoder(rclunk, msg.Tag), nil
+}
+
+// getFid retrieves a fid for the request,
+// translating unknown fids into the appropriate protocol error.
+func (s *session) getFid(id uint32) (*fid, error) {
+	f, err := s.fids.get(id)
+	if err != nil {
+		s.log.Debug(err)
+		return nil, protocolError(eBADF)
+	}
+	return f, nil
+}
+
+// childPath validates a directory entry name from the client, and joins it with its parent.
+func childPath(parent, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsRune(name, '/') {
+		return "", protocolError(eINVAL)
+	}
+	return gopath.Join(parent, name), nil
+}
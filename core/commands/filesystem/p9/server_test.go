@@ -0,0 +1,452 @@
+package p9
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+)
+
+// memoryFS is a read-only, single level `filesystem.Interface` of static files
+type memoryFS map[string][]byte
+
+func (memoryFS) ID() filesystem.ID { return filesystem.IPFS }
+func (memoryFS) Close() error      { return nil }
+
+func (m memoryFS) Open(path string, flags filesystem.IOFlags) (filesystem.File, error) {
+	data, ok := m[path]
+	if !ok {
+		return nil, iferrors.NotExist(path)
+	}
+	if flags != filesystem.IOReadOnly {
+		return nil, iferrors.ReadOnly(path)
+	}
+	return &memoryFile{bytes.NewReader(data)}, nil
+}
+
+func (m memoryFS) OpenDirectory(path string) (filesystem.Directory, error) {
+	if path != "/" {
+		return nil, iferrors.NotDir(path)
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name[1:])
+	}
+	sort.Strings(names)
+	return memoryDirectory(names), nil
+}
+
+func (m memoryFS) Info(path string, req filesystem.StatRequest) (*filesystem.Stat, filesystem.StatRequest, error) {
+	if path == "/" {
+		return &filesystem.Stat{Type: coreiface.TDirectory}, req, nil
+	}
+	data, ok := m[path]
+	if !ok {
+		return nil, filesystem.StatRequest{}, iferrors.NotExist(path)
+	}
+	return &filesystem.Stat{Type: coreiface.TFile, Size: uint64(len(data))}, req, nil
+}
+
+func (memoryFS) ExtractLink(path string) (string, error) { return "", iferrors.NotExist(path) }
+func (memoryFS) Make(path string) error                  { return iferrors.ReadOnly(path) }
+func (memoryFS) MakeDirectory(path string) error         { return iferrors.ReadOnly(path) }
+func (memoryFS) MakeLink(path, _ string) error           { return iferrors.ReadOnly(path) }
+func (memoryFS) Remove(path string) error                { return iferrors.ReadOnly(path) }
+func (memoryFS) RemoveDirectory(path string) error       { return iferrors.ReadOnly(path) }
+func (memoryFS) RemoveLink(path string) error            { return iferrors.ReadOnly(path) }
+func (memoryFS) Rename(path, _ string) error             { return iferrors.ReadOnly(path) }
+
+type memoryFile struct{ *bytes.Reader }
+
+func (mf *memoryFile) Write([]byte) (int, error) { return 0, iferrors.ReadOnly("") }
+func (mf *memoryFile) Close() error              { return nil }
+func (mf *memoryFile) Size() (int64, error)      { return mf.Reader.Size(), nil }
+func (mf *memoryFile) Truncate(uint64) error     { return iferrors.ReadOnly("") }
+
+type (
+	memoryDirectory []string
+	memoryEntry     struct {
+		name   string
+		offset uint64
+	}
+)
+
+func (me memoryEntry) Name() string   { return me.name }
+func (me memoryEntry) Offset() uint64 { return me.offset }
+func (me memoryEntry) Error() error   { return nil }
+
+func (md memoryDirectory) Reset() error { return nil }
+func (md memoryDirectory) Close() error { return nil }
+func (md memoryDirectory) List(ctx context.Context, offset uint64) <-chan filesystem.DirectoryEntry {
+	entries := make(chan filesystem.DirectoryEntry)
+	go func() {
+		defer close(entries)
+		for i := offset; i < uint64(len(md)); i++ {
+			select {
+			case entries <- memoryEntry{name: md[i], offset: i + 1}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return entries
+}
+
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	tag  uint16
+}
+
+// call sends the message and returns the body of the response,
+// failing the test if the response type doesn't match the expected type.
+func (tc *testClient) call(request *encoder, expected messageType) *decoder {
+	tc.t.Helper()
+	if _, err := tc.conn.Write(request.bytes()); err != nil {
+		tc.t.Fatal(err)
+	}
+	response, err := readMessage(tc.conn, maxMessageSize)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	if response.Type != expected {
+		tc.t.Fatalf("expected response type %d, got %d (body: %v)", expected, response.Type, response.buffer)
+	}
+	return &response.decoder
+}
+
+func (tc *testClient) newRequest(mType messageType) *encoder {
+	tc.tag++
+	return newEncoder(mType, tc.tag)
+}
+
+// the fid that `newTestClient` attaches to the root of the file system
+const rootFid = 1
+
+// newTestClient serves the file system over a pipe,
+// and returns a client that has negotiated a session and attached to its root.
+func newTestClient(ctx context.Context, t *testing.T, fs filesystem.Interface) *testClient {
+	t.Helper()
+	var (
+		srv            = newServer(ctx, fs)
+		client, server = net.Pipe()
+		tc             = &testClient{t: t, conn: client}
+	)
+	go srv.serve(server)
+
+	request := newEncoder(tversion, noTag)
+	request.uint32(8192)
+	request.string(protocolVersion)
+	response := tc.call(request, rversion)
+	if msize, version := response.uint32(), response.string(); msize != 8192 || version != protocolVersion {
+		t.Fatalf("unexpected version response: %d %q", msize, version)
+	}
+
+	request = tc.newRequest(tattach)
+	request.uint32(rootFid)
+	request.uint32(noFid)
+	request.string("")
+	request.string("")
+	request.uint32(noFid)
+	tc.call(request, rattach)
+	return tc
+}
+
+func TestServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		content = []byte("hello 9P")
+		fs      = memoryFS{"/a": content, "/b": nil}
+		tc      = newTestClient(ctx, t, fs)
+		client  = tc.conn
+	)
+	defer client.Close()
+
+	const fileFid, dirFid = 2, 3
+
+	t.Run("walk", func(t *testing.T) {
+		request := tc.newRequest(twalk)
+		request.uint32(rootFid)
+		request.uint32(fileFid)
+		request.uint16(1)
+		request.string("a")
+		response := tc.call(request, rwalk)
+		if count := response.uint16(); count != 1 {
+			t.Fatalf("expected 1 qid, got %d", count)
+		}
+
+		request = tc.newRequest(twalk)
+		request.uint32(rootFid)
+		request.uint32(fileFid + 100)
+		request.uint16(1)
+		request.string("missing")
+		if errNo := tc.call(request, rlerror).uint32(); errNo != eNOENT {
+			t.Fatalf("expected ENOENT, got %d", errNo)
+		}
+	})
+
+	t.Run("read", func(t *testing.T) {
+		request := tc.newRequest(tlopen)
+		request.uint32(fileFid)
+		request.uint32(oRDONLY)
+		tc.call(request, rlopen)
+
+		request = tc.newRequest(tread)
+		request.uint32(fileFid)
+		request.uint64(0)
+		request.uint32(4096)
+		response := tc.call(request, rread)
+		if data := response.take(int(response.uint32())); !bytes.Equal(data, content) {
+			t.Fatalf("read mismatch, expected %q got %q", content, data)
+		}
+
+		request = tc.newRequest(twrite)
+		request.uint32(fileFid)
+		request.uint64(0)
+		request.uint32(1)
+		request.uint8('x')
+		if errNo := tc.call(request, rlerror).uint32(); errNo != eROFS {
+			t.Fatalf("expected EROFS, got %d", errNo)
+		}
+	})
+
+	t.Run("getattr", func(t *testing.T) {
+		request := tc.newRequest(tgetattr)
+		request.uint32(fileFid)
+		request.uint64(getattrBasic)
+		response := tc.call(request, rgetattr)
+		response.uint64() // valid
+		response.take(qidSize)
+		if mode := response.uint32(); mode&sIFMT != sIFREG {
+			t.Fatalf("expected regular file mode, got %o", mode)
+		}
+		response.take(4 + 4 + 8 + 8) // uid, gid, nlink, rdev
+		if size := response.uint64(); size != uint64(len(content)) {
+			t.Fatalf("expected size %d, got %d", len(content), size)
+		}
+	})
+
+	t.Run("readdir", func(t *testing.T) {
+		request := tc.newRequest(twalk)
+		request.uint32(rootFid)
+		request.uint32(dirFid)
+		request.uint16(0)
+		tc.call(request, rwalk)
+
+		request = tc.newRequest(tlopen)
+		request.uint32(dirFid)
+		request.uint32(oRDONLY)
+		tc.call(request, rlopen)
+
+		var (
+			names  []string
+			offset uint64
+		)
+		for {
+			request = tc.newRequest(treaddir)
+			request.uint32(dirFid)
+			request.uint64(offset)
+			// room for a single entry per response
+			request.uint32(qidSize + 8 + 1 + 2 + 1)
+			response := tc.call(request, rreaddir)
+			if response.uint32() == 0 {
+				break
+			}
+			response.take(qidSize)
+			offset = response.uint64()
+			response.uint8()
+			names = append(names, response.string())
+		}
+		if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+			t.Fatalf("unexpected directory listing: %v", names)
+		}
+	})
+
+	t.Run("clunk", func(t *testing.T) {
+		for _, fidID := range []uint32{fileFid, dirFid} {
+			request := tc.newRequest(tclunk)
+			request.uint32(fidID)
+			tc.call(request, rclunk)
+		}
+		request := tc.newRequest(tclunk)
+		request.uint32(fileFid)
+		if errNo := tc.call(request, rlerror).uint32(); errNo != eBADF {
+			t.Fatalf("expected EBADF, got %d", errNo)
+		}
+	})
+
+	t.Run("attach subtree", func(t *testing.T) {
+		const subtreeFid, parentFid = 4, 5
+		request := tc.newRequest(tattach)
+		request.uint32(subtreeFid)
+		request.uint32(noFid)
+		request.string("")
+		request.string("a")
+		request.uint32(noFid)
+		tc.call(request, rattach)
+
+		// `..` of the attached root is the root itself
+		request = tc.newRequest(twalk)
+		request.uint32(subtreeFid)
+		request.uint32(parentFid)
+		request.uint16(2)
+		request.string("..")
+		request.string("..")
+		response := tc.call(request, rwalk)
+		if count := response.uint16(); count != 2 {
+			t.Fatalf("expected 2 qids, got %d", count)
+		}
+		for i := 0; i < 2; i++ {
+			if qType := response.uint8(); qType != qtFile {
+				t.Fatalf("walk escaped the attached subtree (qid type %d)", qType)
+			}
+			response.take(qidSize - 1)
+		}
+	})
+
+	if err := client.Close(); err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+}
+
+// storageFS is a `memoryFS` that reports the statistics of its storage
+type storageFS struct {
+	memoryFS
+	stat filesystem.StorageStat
+}
+
+func (sf *storageFS) StorageStat() (*filesystem.StorageStat, error) {
+	stat := sf.stat
+	return &stat, nil
+}
+
+func TestStatfs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	statfs := func(t *testing.T, fs filesystem.Interface) (blocks, free, files uint64) {
+		tc := newTestClient(ctx, t, fs)
+		defer tc.conn.Close()
+
+		request := tc.newRequest(tstatfs)
+		request.uint32(rootFid)
+		response := tc.call(request, rstatfs)
+		response.uint32() // type
+		if blockSize := response.uint32(); blockSize != defaultBlockSize {
+			t.Fatalf("expected block size %d, got %d", defaultBlockSize, blockSize)
+		}
+		blocks, free = response.uint64(), response.uint64()
+		if available := response.uint64(); available != free {
+			t.Errorf("expected %d available blocks, got %d", free, available)
+		}
+		files = response.uint64()
+		return
+	}
+
+	t.Run("unreported", func(t *testing.T) {
+		if blocks, free, files := statfs(t, memoryFS{}); blocks != 0 || free != 0 || files != 0 {
+			t.Errorf("expected empty storage, got blocks: %d, free: %d, files: %d", blocks, free, files)
+		}
+	})
+
+	t.Run("reported", func(t *testing.T) {
+		fs := &storageFS{stat: filesystem.StorageStat{
+			Capacity: 10 * defaultBlockSize,
+			Used:     4 * defaultBlockSize,
+			Objects:  3,
+		}}
+		if blocks, free, files := statfs(t, fs); blocks != 10 || free != 6 || files != 3+6 {
+			t.Errorf("unexpected storage values, blocks: %d, free: %d, files: %d", blocks, free, files)
+		}
+	})
+
+	t.Run("over capacity", func(t *testing.T) {
+		fs := &storageFS{stat: filesystem.StorageStat{
+			Capacity: 2 * defaultBlockSize,
+			Used:     4 * defaultBlockSize,
+		}}
+		if blocks, free, _ := statfs(t, fs); blocks != 2 || free != 0 {
+			t.Errorf("unexpected storage values, blocks: %d, free: %d", blocks, free)
+		}
+	})
+}
+
+// syncFS is a `memoryFS` whose system and files count their syncs
+type syncFS struct {
+	memoryFS
+	syncs, fileSyncs int
+	err              error // returned by syncs, if set
+}
+
+type syncFile struct {
+	*memoryFile
+	fs *syncFS
+}
+
+func (sf *syncFS) Sync() error { sf.syncs++; return sf.err }
+func (sf *syncFS) Open(path string, flags filesystem.IOFlags) (filesystem.File, error) {
+	file, err := sf.memoryFS.Open(path, flags)
+	if err != nil {
+		return nil, err
+	}
+	return &syncFile{memoryFile: file.(*memoryFile), fs: sf}, nil
+}
+
+func (sf *syncFile) Sync() error { sf.fs.fileSyncs++; return sf.fs.err }
+
+func TestFsync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		fs = &syncFS{memoryFS: memoryFS{"/a": []byte("data")}}
+		tc = newTestClient(ctx, t, fs)
+	)
+	defer tc.conn.Close()
+
+	const fileFid = 2
+	request := tc.newRequest(twalk)
+	request.uint32(rootFid)
+	request.uint32(fileFid)
+	request.uint16(1)
+	request.string("a")
+	tc.call(request, rwalk)
+
+	request = tc.newRequest(tlopen)
+	request.uint32(fileFid)
+	request.uint32(oRDONLY)
+	tc.call(request, rlopen)
+
+	fsync := func(fidID uint32) *encoder {
+		request := tc.newRequest(tfsync)
+		request.uint32(fidID)
+		request.uint32(0) // datasync
+		return request
+	}
+
+	// open files are synced by themselves
+	tc.call(fsync(fileFid), rfsync)
+	if fs.fileSyncs != 1 || fs.syncs != 0 {
+		t.Fatalf("expected 1 file sync, got %d file syncs and %d system syncs", fs.fileSyncs, fs.syncs)
+	}
+
+	// anything else syncs the whole system
+	tc.call(fsync(rootFid), rfsync)
+	if fs.syncs != 1 {
+		t.Fatalf("expected 1 system sync, got %d", fs.syncs)
+	}
+
+	fs.err = iferrors.IO("/a", errors.New("sync failed"))
+	if errNo := tc.call(fsync(fileFid), rlerror).uint32(); errNo != eIO {
+		t.Fatalf("expected EIO, got %d", errNo)
+	}
+}
@@ -0,0 +1,229 @@
+package fscmds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	cmds "github.com/ipfs/go-ipfs-cmds"
+	"github.com/ipfs/go-ipfs/filesystem/manager"
+	"github.com/kardianos/service"
+	"github.com/multiformats/go-multiaddr"
+)
+
+const (
+	persistOptionKwd         = "persist"
+	persistOptionDescription = "restore these requests when the service (re)starts"
+
+	persistentMountsName    = "mounts.json"
+	persistentRestoreWindow = 30 * time.Second
+)
+
+type (
+	// persistentEntry is a request that the service restores when it starts.
+	persistentEntry struct {
+		Request string `json:"request"`
+		// the IPFS API that was used by the original request (if one was provided)
+		IPFS string `json:"ipfs,omitempty"`
+	}
+	persistentEntries []persistentEntry
+)
+
+// the table is shared by all requests within the service process
+var persistentMountsMu sync.Mutex
+
+func persistentMountsPath() string {
+	return filepath.Join(localServiceDirectory(), persistentMountsName)
+}
+
+// persistOption returns the value of `--persist`.
+func persistOption(request *cmds.Request) (persist bool, err error) {
+	if persistArg, provided := request.Options[persistOptionKwd]; provided {
+		var isBool bool
+		if persist, isBool = persistArg.(bool); !isBool {
+			err = cmds.Errorf(cmds.ErrClient,
+				"%s's argument %v is type: %T, expecting type: %T",
+				persistOptionKwd, persistArg, persistArg, persist)
+		}
+	}
+	return
+}
+
+// loadPersistentMounts reads the table from storage.
+// The caller must hold the table's lock.
+func loadPersistentMounts() (persistentEntries, error) {
+	tableData, err := ioutil.ReadFile(persistentMountsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // no table is the same as an empty table
+		}
+		return nil, err
+	}
+
+	var entries persistentEntries
+	if err := json.Unmarshal(tableData, &entries); err != nil {
+		return nil, fmt.Errorf("could not decode mount table %q: %w", persistentMountsPath(), err)
+	}
+	return entries, nil
+}
+
+// storePersistentMounts writes the table to storage, replacing the existing one.
+// The caller must hold the table's lock.
+func storePersistentMounts(entries persistentEntries) error {
+	tablePath := persistentMountsPath()
+	if len(entries) == 0 {
+		if err := os.Remove(tablePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	tableData, err := json.MarshalIndent(entries, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(tablePath), 0700); err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so that the table is never partially written
+	tempPath := tablePath + ".tmp"
+	if err := ioutil.WriteFile(tempPath, tableData, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, tablePath)
+}
+
+// persistRequests adds the requests to the table (if they're not already in it).
+func persistRequests(ipfsAPI string, requests ...manager.Request) error {
+	persistentMountsMu.Lock()
+	defer persistentMountsMu.Unlock()
+
+	entries, err := loadPersistentMounts()
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		existing[entry.Request] = struct{}{}
+	}
+
+	for _, request := range requests {
+		requestString := request.String()
+		if _, ok := existing[requestString]; ok {
+			continue
+		}
+		existing[requestString] = struct{}{}
+		entries = append(entries, persistentEntry{Request: requestString, IPFS: ipfsAPI})
+	}
+
+	return storePersistentMounts(entries)
+}
+
+// forgetRequests removes the matching entries from the table.
+func forgetRequests(match func(request string) bool) error {
+	persistentMountsMu.Lock()
+	defer persistentMountsMu.Unlock()
+
+	entries, err := loadPersistentMounts()
+	if err != nil {
+		return err
+	}
+
+	retained := entries[:0]
+	for _, entry := range entries {
+		if !match(entry.Request) {
+			retained = append(retained, entry)
+		}
+	}
+
+	if len(retained) == len(entries) {
+		return nil // nothing matched
+	}
+	return storePersistentMounts(retained)
+}
+
+// listPersistentMounts returns the table's entries as (unbound) responses.
+func listPersistentMounts(ctx context.Context) (manager.Responses, error) {
+	persistentMountsMu.Lock()
+	entries, err := loadPersistentMounts()
+	persistentMountsMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make(chan manager.Response)
+	go func() {
+		defer close(responses)
+		for _, entry := range entries {
+			response := manager.Response{}
+			response.Request, response.Error = multiaddr.NewMultiaddr(entry.Request)
+			if response.Error != nil {
+				continue // the response can't be encoded without a request
+			}
+			select {
+			case responses <- response:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return responses, nil
+}
+
+// restorePersistentMounts binds all requests within the table.
+// Entries are retained even if they fail to bind,
+// (the node they depend on may not be available yet for instance).
+func (d *daemon) restorePersistentMounts(logger service.Logger) {
+	persistentMountsMu.Lock()
+	entries, err := loadPersistentMounts()
+	persistentMountsMu.Unlock()
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	// requests are dispatched together, per IPFS API
+	byAPI := make(map[string][]string)
+	for _, entry := range entries {
+		byAPI[entry.IPFS] = append(byAPI[entry.IPFS], entry.Request)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), persistentRestoreWindow)
+	defer cancel()
+
+	for ipfsAPI, arguments := range byAPI {
+		request := &cmds.Request{
+			Context: ctx,
+			Options: make(cmds.OptMap),
+		}
+		if ipfsAPI != "" {
+			request.Options[rootIPFSOptionKwd] = ipfsAPI
+		}
+
+		fsi, err := d.FileSystemEnvironment.Manager(request)
+		if err != nil {
+			logger.Errorf("could not restore %v: %s", arguments, err)
+			continue
+		}
+
+		requests, requestErrors := manager.ParseRequests(ctx, arguments...)
+		logResponse := func(value interface{}) error {
+			if response := value.(manager.Response); response.Error == nil {
+				logger.Info("restored: ", response.String())
+			}
+			return nil
+		}
+		allErrs := emitResponses(ctx, logResponse, requestErrors, fsi.Bind(ctx, requests))
+		if err := flattenErrors("restore", allErrs); err != nil {
+			logger.Error(err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package fscmds
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/ipfs/go-ipfs/filesystem/manager"
+	"github.com/multiformats/go-multiaddr"
+)
+
+func TestPersistentMounts(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "fscmds-persist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	originalRuntimeDir := xdg.RuntimeDir
+	defer func() { xdg.RuntimeDir = originalRuntimeDir }()
+	xdg.RuntimeDir = tempDir
+	if filepath.Dir(localServiceDirectory()) != tempDir {
+		t.Skip("service directory is not within the runtime directory (not interactive)")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	parseRequests := func(t *testing.T, arguments ...string) []manager.Request {
+		t.Helper()
+		requests := make([]manager.Request, len(arguments))
+		for i, argument := range arguments {
+			maddr, err := multiaddr.NewMultiaddr(argument)
+			if err != nil {
+				t.Fatal(err)
+			}
+			requests[i] = maddr
+		}
+		return requests
+	}
+	expectTable := func(t *testing.T, expected ...persistentEntry) {
+		t.Helper()
+		persistentMountsMu.Lock()
+		entries, err := loadPersistentMounts()
+		persistentMountsMu.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != len(expected) {
+			t.Fatalf("expected table %v, got %v", expected, entries)
+		}
+		for i := range expected {
+			if entries[i] != expected[i] {
+				t.Fatalf("expected table %v, got %v", expected, entries)
+			}
+		}
+	}
+
+	const (
+		ipfsRequest = "/fuse/ipfs/path/ipfs"
+		fileRequest = "/fuse/file/path/mfs"
+		nodeAPI     = "/ip4/127.0.0.1/tcp/5001"
+	)
+
+	// no table is the same as an empty table
+	expectTable(t)
+
+	if err := persistRequests("", parseRequests(t, ipfsRequest)...); err != nil {
+		t.Fatal(err)
+	}
+	// requests that are already in the table are not added again
+	if err := persistRequests(nodeAPI, parseRequests(t, ipfsRequest, fileRequest)...); err != nil {
+		t.Fatal(err)
+	}
+	expectTable(t,
+		persistentEntry{Request: ipfsRequest},
+		persistentEntry{Request: fileRequest, IPFS: nodeAPI},
+	)
+
+	responses, err := listPersistentMounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed []string
+	for response := range responses {
+		listed = append(listed, response.String())
+	}
+	if len(listed) != 2 || listed[0] != ipfsRequest || listed[1] != fileRequest {
+		t.Errorf("unexpected listing: %v", listed)
+	}
+
+	if err := forgetRequests(func(request string) bool { return request == ipfsRequest }); err != nil {
+		t.Fatal(err)
+	}
+	expectTable(t, persistentEntry{Request: fileRequest, IPFS: nodeAPI})
+
+	// the table is removed once it's empty
+	if err := forgetRequests(func(string) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(persistentMountsPath()); !os.IsNotExist(err) {
+		t.Errorf("empty table was not removed: %v", err)
+	}
+}
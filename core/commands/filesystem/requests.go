@@ -15,6 +15,15 @@ type (
 	requestHeader struct {
 		filesystem.API
 		filesystem.ID
+		requestOptions
+	}
+
+	// options (if any), which follow the API pair in the request
+	requestOptions struct {
+		Publish    string
+		Cache      string
+		ReadAhead  string
+		References string
 	}
 
 	section struct {
@@ -24,10 +33,26 @@ type (
 	sectionStream = <-chan section
 )
 
+// TODO: these should be derived from the binders that are available at compile time
+var (
+	supportedHostAPIs = []filesystem.API{
+		filesystem.Fuse,
+		filesystem.Plan9Protocol,
+	}
+	supportedNodeAPIs = []filesystem.ID{
+		filesystem.IPFS,
+		filesystem.IPNS,
+		filesystem.PinFS,
+		filesystem.KeyFS,
+		filesystem.Files,
+		filesystem.RootFS,
+	}
+)
+
 // splitRequest returns each component of the request, as an individual typed values.
 func splitRequest(request manager.Request) (hostAPI filesystem.API, nodeAPI filesystem.ID, remainder manager.Request, err error) {
 	// NOTE: we expect the request to contain a pair of API values as its first component (e.g. `/fuse/ipfs/`)
-	// with or without a remainder (e.g. remainder may be `nil`, `.../path/mnt/ipfs/...`, etc.)
+	// with or without a remainder (e.g. remainder may be `nil`, `.../path/mnt/ipfs/...`, `.../ip4/127.0.0.1/tcp/564`, etc.)
 	defer func() { // multiaddr pkg will panic if the request is malformed
 		if grace := recover(); grace != nil { // so we exorcise the goroutine if this happens
 			err = fmt.Errorf("splitRequest panicked: %v - %v", request, grace)
@@ -47,18 +72,9 @@ func splitRequest(request manager.Request) (hostAPI filesystem.API, nodeAPI file
 	// disambiguation
 	// Note the direct use of the return variables in the range clauses.
 	// If both values being inspected appear in our supported list, we'll return them.
-	supportedAPIs := []filesystem.ID{
-		filesystem.IPFS,
-		filesystem.IPNS,
-		filesystem.PinFS,
-		//filesystem.KeyFS,
-		//filesystem.Files,
-	}
-	for _, hostAPI = range []filesystem.API{
-		filesystem.Fuse,
-	} {
+	for _, hostAPI = range supportedHostAPIs {
 		if hostAPI == filesystem.API(hostProtocol) {
-			for _, nodeAPI = range supportedAPIs {
+			for _, nodeAPI = range supportedNodeAPIs {
 				if nodeAPI == filesystem.ID(nodeProtocol) {
 					return
 				}
@@ -70,6 +86,48 @@ func splitRequest(request manager.Request) (hostAPI filesystem.API, nodeAPI file
 	return
 }
 
+// splitOptions separates request options from the request body (if any).
+func splitOptions(body manager.Request) (options requestOptions, remainder manager.Request) {
+	remainder = body
+	for remainder != nil {
+		option, rest := multiaddr.SplitFirst(remainder)
+		if option == nil {
+			return
+		}
+		switch option.Protocol().Code {
+		case filesystem.PublishOption:
+			options.Publish = option.Value()
+		case filesystem.CacheOption:
+			options.Cache = option.Value()
+		case filesystem.ReadAheadOption:
+			options.ReadAhead = option.Value()
+		case filesystem.ReferencesOption:
+			options.References = option.Value()
+		default:
+			return
+		}
+		remainder = rest
+	}
+	return
+}
+
+// components returns the options in their multiaddr form (if any).
+func (options requestOptions) components() (components []multiaddr.Multiaddr) {
+	for _, option := range []struct{ name, value string }{
+		{filesystem.PublishOptionName, options.Publish},
+		{filesystem.CacheOptionName, options.Cache},
+		{filesystem.ReadAheadOptionName, options.ReadAhead},
+		{filesystem.ReferencesOptionName, options.References},
+	} {
+		if option.value == "" {
+			continue
+		}
+		component, _ := multiaddr.NewComponent(option.name, option.value)
+		components = append(components, component)
+	}
+	return
+}
+
 // splitRequests divides the request stream into a series of sections,
 // deliniated by request header data.
 func splitRequests(ctx context.Context, requests manager.Requests) (sectionStream, errors.Stream) {
@@ -91,6 +149,7 @@ func splitRequests(ctx context.Context, requests manager.Requests) (sectionStrea
 			}
 
 			header := requestHeader{API: hostAPI, ID: nodeAPI}
+			header.requestOptions, body = splitOptions(body)
 			requestDestination, alreadyMade := sectionIndex[header]
 
 			if !alreadyMade {
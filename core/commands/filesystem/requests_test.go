@@ -0,0 +1,58 @@
+package fscmds
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	"github.com/multiformats/go-multiaddr"
+)
+
+func TestSplitRequest(t *testing.T) {
+	for _, test := range []struct {
+		request string
+		hostAPI filesystem.API
+		nodeAPI filesystem.ID
+	}{
+		{"/fuse/ipfs/path/ipfs", filesystem.Fuse, filesystem.IPFS},
+		{"/fuse/file/path/mfs", filesystem.Fuse, filesystem.Files},
+		{"/fuse/keyfs/path/keys", filesystem.Fuse, filesystem.KeyFS},
+		{"/9p/file/ip4/127.0.0.1/tcp/564", filesystem.Plan9Protocol, filesystem.Files},
+	} {
+		t.Run(test.request, func(t *testing.T) {
+			request, err := multiaddr.NewMultiaddr(test.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hostAPI, nodeAPI, remainder, err := splitRequest(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hostAPI != test.hostAPI || nodeAPI != test.nodeAPI {
+				t.Errorf("expected API pair %v/%v, got %v/%v", test.hostAPI, test.nodeAPI, hostAPI, nodeAPI)
+			}
+			if remainder == nil {
+				t.Error("request target was lost")
+			}
+
+			// each pair must also be reachable from the command line
+			subcommand := Mount.Subcommands[test.hostAPI.String()]
+			if subcommand == nil || subcommand.Subcommands[test.nodeAPI.String()] == nil {
+				t.Errorf("mount has no subcommand for %v/%v", test.hostAPI, test.nodeAPI)
+			}
+		})
+	}
+}
+
+func TestSplitOptions(t *testing.T) {
+	body, err := multiaddr.NewMultiaddr("/publish/close/references/5s,64/path/keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options, remainder := splitOptions(body)
+	if expected := (requestOptions{Publish: "close", References: "5s,64"}); options != expected {
+		t.Errorf("expected options %#v, got %#v", expected, options)
+	}
+	if remainder == nil || remainder.String() != "/path/keys" {
+		t.Errorf("unexpected remainder: %v", remainder)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"sync"
+	"time"
 
 	"github.com/ipfs/go-ipfs/filesystem/manager"
 )
@@ -14,7 +15,10 @@ type (
 	// commandDispatcher manages requests for/from `go-ipfs-cmds`.
 	// Dispatching requests to one of several multiplexed binders.
 	commandDispatcher struct {
+		dispatchMu  sync.Mutex
 		dispatchers dispatchMap
+		makeBinder  func(requestHeader) (manager.Binder, error) // constructs binders for headers with options
+		ipfsAPI     string                                      // the IPFS API the binders' instances use
 		instanceIndex
 	}
 )
@@ -25,7 +29,7 @@ type (
 
 	instanceIndex interface {
 		fetch(key indexKey) *manager.Response
-		store(key indexKey, value *manager.Response)
+		store(key indexKey, value *manager.Response, ipfsAPI string)
 		List(ctx context.Context) <-chan manager.Response
 	}
 	muIndex struct {
@@ -45,32 +49,69 @@ func (mi *muIndex) fetch(key indexKey) *manager.Response {
 	return mi.indices[key]
 }
 
-func (mi *muIndex) store(key indexKey, value *manager.Response) {
+func (mi *muIndex) store(key indexKey, value *manager.Response, ipfsAPI string) {
 	mi.Lock()
 	defer mi.Unlock()
 	mi.indices[key] = value
 
+	remove := func() {
+		mi.Lock()
+		defer mi.Unlock()
+		delete(mi.indices, key)
+	}
 	maybeWrapCloser := func(original io.Closer) closer {
 		if original == nil {
-			return func() error { delete(mi.indices, key); return nil }
+			return func() error { remove(); return nil }
 		}
 		return func() error {
-			delete(mi.indices, key)
+			remove()
 			return original.Close()
 		}
 	}
-	value.Closer = maybeWrapCloser(value.Closer)
+	value.Closer = indexedInstance{
+		closer:   maybeWrapCloser(value.Closer),
+		instance: value.Closer,
+		mounted:  time.Now(),
+		ipfsAPI:  ipfsAPI,
+	}
+}
+
+// indexedInstance removes its instance from the index when closed,
+// and retains the details needed to describe the instance while it's indexed.
+type indexedInstance struct {
+	closer
+	instance io.Closer
+	mounted  time.Time
+	ipfsAPI  string
+}
+
+func (ii indexedInstance) statistics() *manager.Statistics {
+	stats := &manager.Statistics{
+		Mounted: ii.mounted,
+		IPFSAPI: ii.ipfsAPI,
+	}
+	if monitor, ok := ii.instance.(manager.Monitor); ok {
+		activity := monitor.Activity()
+		stats.Activity = &activity
+	}
+	return stats
 }
 
 func (mi *muIndex) List(ctx context.Context) <-chan manager.Response {
 	mi.RLock()
-	defer mi.RUnlock()
+	// (snapshot the index; instances may be closed while the list is being consumed)
+	snapshot := make([]manager.Response, 0, len(mi.indices))
+	for _, resp := range mi.indices {
+		snapshot = append(snapshot, *resp)
+	}
+	mi.RUnlock()
+
 	respChan := make(chan manager.Response)
 	go func() {
 		defer close(respChan)
-		for _, resp := range mi.indices {
+		for _, resp := range snapshot {
 			select {
-			case respChan <- *resp:
+			case respChan <- resp:
 			case <-ctx.Done():
 				return
 			}
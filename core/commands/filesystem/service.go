@@ -42,7 +42,7 @@ var Service = &cmds.Command{
 	NoRemote:    true,
 	Run:         filesystemRun,
 	Encoders:    cmds.Encoders,
-	Subcommands: make(map[string]*cmds.Command, len(service.ControlAction)),
+	Subcommands: make(map[string]*cmds.Command, len(service.ControlAction)+1),
 	Options: []cmds.Option{
 		cmds.IntOption(decayOptionKwd, decayOptionDescription),
 	},
@@ -74,26 +74,18 @@ func registerServiceSubcommands(parent *cmds.Command) {
 					return envError(env)
 				}
 
-				svc, err := getService(request, fsEnv)
+				svc, err := getService(request, fsEnv, nil)
 				if err != nil {
 					return err
 				}
 
-				// TODO: special case for "status"
-				// we want to display both the system service manager status
-				// and the status of the actual file system service itself
-				// (e.g. is the service regisetred and running, + is the socket dialable)
-				/*output:
-				Service API: Listening - /unix/run/fsd.sock
-				Service Manager: Running - File System Service Daemon
-				---
-				Service API: Not listening
-				Service Manager: Not installed
-				*/
 				return service.Control(svc, actionStr)
 			},
 		}
 	}
+	// status is not a control action, it reports on both
+	// the system service manager, and the file system service itself
+	parent.Subcommands[statusParameter] = serviceStatusCommand
 }
 
 type daemon struct {
@@ -151,6 +143,8 @@ func (d *daemon) Start(s service.Service) error {
 	go http.Serve(manet.NetListener(serviceListener),
 		cmdshttp.NewHandler(d.FileSystemEnvironment, ClientRoot, cmdshttp.NewServerConfig()))
 
+	go d.restorePersistentMounts(logger)
+
 	return logger.Info(stdReady)
 }
 
@@ -165,36 +159,62 @@ func (d *daemon) Stop(s service.Service) (err error) {
 		logger.Error(err)
 		return
 	}
-	serviceMaddr := d.serviceListener.Multiaddr()
-	logger.Info("closing listener: ", serviceMaddr.String())
 
-	// TODO: close all instances first
+	// instances must be closed before the process exits
+	// (otherwise their mountpoints would go stale)
+	if index, iErr := d.FileSystemEnvironment.Index(&cmds.Request{Context: context.Background()}); iErr != nil {
+		err = fmt.Errorf("could not retrieve instances: %w", iErr)
+		logger.Error(err)
+	} else {
+		err = closeInstances(context.Background(), index, logger) // non-fatal
+	}
 
-	if err = d.serviceListener.Close(); err != nil {
-		logger.Error("listener encountered error: ", err)
+	serviceMaddr := d.serviceListener.Multiaddr()
+	logger.Info("closing listener: ", serviceMaddr.String())
+	if lErr := d.serviceListener.Close(); lErr != nil {
+		logger.Error("listener encountered error: ", lErr)
 		// non-fatal error
+		if err == nil {
+			err = lErr
+		}
 	}
 	d.serviceListener = nil
 
-	socketTarget, mErr := serviceMaddr.ValueForProtocol(multiaddr.P_UNIX)
-	switch mErr {
-	case nil:
-		socketTarget = strings.TrimPrefix(socketTarget, `/`)
-		// cleanup system service directory (should be empty post-close)
-		if oErr := os.Remove(filepath.Dir(socketTarget)); oErr != nil {
-			oErr = fmt.Errorf("failed to cleanup service directory: %w", oErr)
-			logger.Error(oErr)
-			if err != nil {
-				err = fmt.Errorf("%w; %s", err, oErr)
-			} else {
-				err = oErr
-			}
+	// cleanup system service directory (should be empty post-close)
+	if oErr := removeServiceDirectory(serviceMaddr); oErr != nil {
+		oErr = fmt.Errorf("failed to cleanup service directory: %w", oErr)
+		logger.Error(oErr)
+		if err != nil {
+			err = fmt.Errorf("%w; %s", err, oErr)
+		} else {
+			err = oErr
 		}
-	default:
 	}
 	return
 }
 
+// removeServiceDirectory removes the directory containing the service's socket,
+// if it's our own directory, and it doesn't hold the persistent mount table.
+// The parent directories of sockets supplied by the user are left alone.
+func removeServiceDirectory(serviceMaddr multiaddr.Multiaddr) error {
+	socketTarget, err := serviceMaddr.ValueForProtocol(multiaddr.P_UNIX)
+	if err != nil {
+		return nil // not a socket
+	}
+	if runtime.GOOS == "windows" { // `/C:\path` -> `C:\path`
+		socketTarget = strings.TrimPrefix(socketTarget, `/`)
+	}
+	serviceDir := filepath.Dir(socketTarget)
+	if serviceDir != localServiceDirectory() {
+		return nil
+	}
+	// the mount table must outlive the service
+	if _, err := os.Stat(filepath.Join(serviceDir, persistentMountsName)); err == nil {
+		return nil
+	}
+	return os.Remove(serviceDir)
+}
+
 func multiaddrOption(request *cmds.Request, parameter string) (multiaddr.Multiaddr, error) {
 	if apiArg, provided := request.Options[parameter]; provided {
 		api, isString := apiArg.(string)
@@ -215,55 +235,50 @@ func filesystemRun(request *cmds.Request, emitter cmds.ResponseEmitter, env cmds
 		return envError(env)
 	}
 
-	service, err := getService(request, fsEnv)
+	// the service runs until it's signaled to stop, or decays
+	// either way, `Stop` is called before `Run` returns
+	stop := make(chan struct{})
+	service, err := getService(request, fsEnv, waitForStop(stop))
 	if err != nil {
 		return err
 	}
 
-	// TODO:
-	// quick hacks just to prevent the process staying around forever
-	// this needs to be done properly
-	decay, ok := request.Options[decayOptionKwd]
-	if ok {
+	if decayArg, ok := request.Options[decayOptionKwd]; ok {
+		decay, isInt := decayArg.(int)
+		if !isInt {
+			return cmds.Errorf(cmds.ErrClient,
+				"%s's argument %v is type: %T, expecting type: %T",
+				decayOptionKwd, decayArg, decayArg, decay)
+		}
 		index, err := fsEnv.Index(request)
 		if err != nil {
-			panic(err)
+			return err
 		}
 		go func() {
+			defer close(stop)
 			for {
 				select {
-				case <-time.After(time.Second * time.Duration(decay.(int))): // XXX: no
+				case <-time.After(time.Second * time.Duration(decay)):
 					isEmpty := true
 					for range index.List(request.Context) {
 						isEmpty = false
 					}
 					if isEmpty {
-						service.Stop()
-						os.Exit(0)
+						return
 					}
+				case <-request.Context.Done():
+					return
 				}
 			}
 		}()
 	}
 
 	return service.Run()
-
-	/* TODO: only on interactive:
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		// TODO: interrupt calls graceful stop,
-		// double interrupt calls ungraceful
-		<-interrupt
-		// TODO: graceful server stop
-		log.Println("interrupt received, closing: ", serviceListener.Addr().String())
-		log.Println("closed: ", serviceListener.Close())
-	}()
-	*/
-
 }
 
-func getService(request *cmds.Request, fsEnv FileSystemEnvironment) (service.Service, error) {
+// getService constructs the system service for our daemon.
+// If provided, `runWait` should block until the service should stop running.
+func getService(request *cmds.Request, fsEnv FileSystemEnvironment, runWait func()) (service.Service, error) {
 	var (
 		fileSystemService = &daemon{
 			FileSystemEnvironment: fsEnv,
@@ -272,6 +287,9 @@ func getService(request *cmds.Request, fsEnv FileSystemEnvironment) (service.Ser
 
 	serviceConfig := serviceConfigTemplate
 	serviceConfig.Arguments = []string{serviceParameter}
+	if runWait != nil {
+		serviceConfig.Option = service.KeyValue{"RunWait": runWait}
+	}
 
 	// TODO: move to platform constrained files
 	if runtime.GOOS == "windows" {
@@ -0,0 +1,58 @@
+package fscmds
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/multiformats/go-multiaddr"
+)
+
+func TestRemoveServiceDirectory(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "fscmds-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	socketMaddr := func(t *testing.T, dir string) multiaddr.Multiaddr {
+		maddr, err := multiaddr.NewMultiaddr(path.Join("/unix/", filepath.ToSlash(filepath.Join(dir, serviceSocketName))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return maddr
+	}
+
+	// the directory of a socket supplied by the user must survive shutdown
+	userDir := filepath.Join(tempDir, "user")
+	if err := os.Mkdir(userDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeServiceDirectory(socketMaddr(t, userDir)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(userDir); err != nil {
+		t.Errorf("user supplied socket directory was removed: %s", err)
+	}
+
+	// while our own is removed
+	originalRuntimeDir := xdg.RuntimeDir
+	defer func() { xdg.RuntimeDir = originalRuntimeDir }()
+	xdg.RuntimeDir = tempDir
+	ourDir := localServiceDirectory()
+	if filepath.Dir(ourDir) != tempDir {
+		t.Skip("service directory is not within the runtime directory (not interactive)")
+	}
+	if err := os.Mkdir(ourDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeServiceDirectory(socketMaddr(t, ourDir)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(ourDir); !os.IsNotExist(err) {
+		t.Errorf("service directory was not removed: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package fscmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	"github.com/ipfs/go-ipfs/filesystem/manager"
+	"github.com/kardianos/service"
+)
+
+// instanceCloseTimeout is how long an instance may take to close,
+// before we try to detach it from the host forcefully.
+const instanceCloseTimeout = 10 * time.Second
+
+// closeInstances closes every instance within the index.
+// Instances that don't close in time are forcefully unmounted (if possible).
+func closeInstances(ctx context.Context, index manager.Index, logger service.Logger) error {
+	// collect the instances first; closing them modifies the index
+	var instances []manager.Response
+	for instance := range index.List(ctx) {
+		instances = append(instances, instance)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		errMu   sync.Mutex
+		allErrs []error
+	)
+	for _, instance := range instances {
+		wg.Add(1)
+		go func(instance manager.Response) {
+			defer wg.Done()
+			if err := closeInstance(instance); err != nil {
+				logger.Error(err)
+				errMu.Lock()
+				allErrs = append(allErrs, err)
+				errMu.Unlock()
+				return
+			}
+			logger.Info("closed: ", instance.String())
+		}(instance)
+	}
+	wg.Wait()
+
+	return flattenErrors("shutdown", allErrs)
+}
+
+func closeInstance(instance manager.Response) error {
+	if instance.Closer == nil {
+		return nil
+	}
+
+	closeErr := make(chan error, 1)
+	go func() { closeErr <- instance.Close() }()
+
+	select {
+	case err := <-closeErr:
+		if err != nil {
+			return fmt.Errorf("%s: %w", instance.String(), err)
+		}
+		return nil
+	case <-time.After(instanceCloseTimeout):
+	}
+
+	// the instance is likely busy, or its host is unresponsive;
+	// detach it from the host so that the mountpoint doesn't go stale
+	target, err := instance.ValueForProtocol(int(filesystem.PathProtocol))
+	if err != nil {
+		return fmt.Errorf("%s: timed out while closing", instance.String())
+	}
+	if err := forceUnmount(target); err != nil {
+		return fmt.Errorf("%s: timed out while closing, and could not be forcefully unmounted: %w",
+			instance.String(), err)
+	}
+	return fmt.Errorf("%s: timed out while closing, and was forcefully unmounted", instance.String())
+}
+
+// waitForStop returns a function which blocks until the process is signaled to stop,
+// or the stop channel is closed.
+// After returning, a subsequent signal will exit the process immediately.
+func waitForStop(stop <-chan struct{}) func() {
+	return func() {
+		signals := make(chan os.Signal, 2)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		select {
+		case <-signals:
+		case <-stop:
+		}
+		go func() {
+			<-signals
+			os.Exit(1)
+		}()
+	}
+}
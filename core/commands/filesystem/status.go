@@ -0,0 +1,188 @@
+package fscmds
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	cmds "github.com/ipfs/go-ipfs-cmds"
+	"github.com/kardianos/service"
+)
+
+const (
+	statusParameter   = "status"
+	statusDescription = "Reports the state of the system service, and the file system service API."
+
+	statusRunning      = "Running"
+	statusStopped      = "Stopped"
+	statusNotInstalled = "Not installed"
+	statusUnknown      = "Unknown"
+)
+
+// serviceStatus combines the state of the system service manager's entry,
+// with the state of the file system service itself.
+type serviceStatus struct {
+	// state according to the system service manager
+	Manager      string `json:"manager"`
+	ManagerError string `json:"managerError,omitempty"`
+	Installed    bool   `json:"installed"`
+	Running      bool   `json:"running"`
+
+	// state of the service API
+	API       string `json:"api"`
+	Listening bool   `json:"listening"`
+	APIError  string `json:"apiError,omitempty"`
+
+	// number of active instances, and the IPFS APIs they're bound to
+	// as reported by the service (only valid if the API is listening)
+	Instances int      `json:"instances"`
+	IPFS      []string `json:"ipfs,omitempty"`
+}
+
+var serviceStatusCommand = &cmds.Command{
+	Run: statusRun,
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(formatStatus),
+	},
+	Helptext: cmds.HelpText{
+		Tagline:          statusDescription,
+		ShortDescription: statusDescription,
+	},
+	Type: serviceStatus{},
+}
+
+func statusRun(request *cmds.Request, emitter cmds.ResponseEmitter, env cmds.Environment) error {
+	fsEnv, envIsUsable := env.(FileSystemEnvironment)
+	if !envIsUsable {
+		return envError(env)
+	}
+
+	svc, err := getService(request, fsEnv, nil)
+	if err != nil {
+		return err
+	}
+	return emitter.Emit(getStatus(request, fsEnv, svc))
+}
+
+// getStatus queries the system service manager for the service's entry,
+// and the service API for its instances.
+func getStatus(request *cmds.Request, fsEnv FileSystemEnvironment, svc service.Service) *serviceStatus {
+	status := new(serviceStatus)
+
+	switch svcStatus, err := svc.Status(); {
+	case err == service.ErrNotInstalled:
+		status.Manager = statusNotInstalled
+	case err != nil:
+		status.Manager, status.ManagerError = statusUnknown, err.Error()
+	default:
+		status.Installed = true
+		switch svcStatus {
+		case service.StatusRunning:
+			status.Manager, status.Running = statusRunning, true
+		case service.StatusStopped:
+			status.Manager = statusStopped
+		default:
+			status.Manager = statusUnknown
+		}
+	}
+
+	if serviceMaddr := fsEnv.ServiceMaddr(); serviceMaddr != nil {
+		status.API = serviceMaddr.String()
+	}
+	client, err := fsEnv.SystemService()
+	if err != nil {
+		status.APIError = err.Error()
+		return status
+	}
+	status.Listening = true
+
+	if status.Instances, status.IPFS, err = listInstances(request, client, fsEnv); err != nil {
+		status.APIError = err.Error()
+	}
+
+	return status
+}
+
+// listInstances asks the service for its active instances,
+// returning their count and the (unique) IPFS APIs they're bound to.
+func listInstances(request *cmds.Request, client cmds.Executor, env cmds.Environment) (int, []string, error) {
+	listRequest, err := cmds.NewRequest(request.Context, []string{listParameter},
+		cmds.OptMap{listLongOptionKwd: true}, nil, nil, ClientRoot)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	listEmitter, listResponse := cmds.NewChanResponsePair(listRequest)
+	go func() {
+		if err := client.Execute(listRequest, listEmitter, env); err != nil {
+			listEmitter.CloseWithError(err)
+		}
+	}()
+
+	var (
+		ctx                   = request.Context
+		responses, listErrors = responseToResponses(ctx, listResponse)
+		instances             int
+		ipfsAPIs              = make(map[string]struct{})
+		allErrs               []error
+	)
+	for responses != nil || listErrors != nil {
+		select {
+		case response, ok := <-responses:
+			if !ok {
+				responses = nil
+				continue
+			}
+			instances++
+			if stats := response.Statistics; stats != nil && stats.IPFSAPI != "" {
+				ipfsAPIs[stats.IPFSAPI] = struct{}{}
+			}
+		case err, ok := <-listErrors:
+			if !ok {
+				listErrors = nil
+				continue
+			}
+			allErrs = append(allErrs, err)
+		case <-ctx.Done():
+			return instances, nil, ctx.Err()
+		}
+	}
+
+	apiList := make([]string, 0, len(ipfsAPIs))
+	for api := range ipfsAPIs {
+		apiList = append(apiList, api)
+	}
+	sort.Strings(apiList)
+	return instances, apiList, flattenErrors("listing", allErrs)
+}
+
+func formatStatus(request *cmds.Request, writer io.Writer, status *serviceStatus) (err error) {
+	printf := func(format string, a ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(writer, format, a...)
+		}
+	}
+
+	printf("Service Manager: %s - %s\n", status.Manager, serviceConfigTemplate.DisplayName)
+	if status.ManagerError != "" {
+		printf("\t%s\n", status.ManagerError)
+	}
+
+	if status.Listening {
+		printf("Service API: Listening - %s\n", status.API)
+	} else {
+		printf("Service API: Not listening - %s\n", status.API)
+	}
+	if status.APIError != "" {
+		printf("\t%s\n", status.APIError)
+	}
+
+	if status.Listening {
+		printf("Active instances: %d\n", status.Instances)
+		if len(status.IPFS) != 0 {
+			printf("IPFS API: %s\n", strings.Join(status.IPFS, ", "))
+		}
+	}
+	return
+}
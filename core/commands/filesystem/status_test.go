@@ -0,0 +1,184 @@
+package fscmds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	cmds "github.com/ipfs/go-ipfs-cmds"
+	"github.com/ipfs/go-ipfs/filesystem/manager"
+	"github.com/kardianos/service"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// statusService reports a fixed status for the system service manager's entry.
+type statusService struct {
+	service.Service
+	status service.Status
+	err    error
+}
+
+func (svc *statusService) Status() (service.Status, error) { return svc.status, svc.err }
+
+// statusEnvironment connects to a service API that (if it's listening) lists the given instances.
+type statusEnvironment struct {
+	FileSystemEnvironment
+	maddr     multiaddr.Multiaddr
+	instances []manager.Response
+	apiErr    error
+}
+
+func (env *statusEnvironment) ServiceMaddr() multiaddr.Multiaddr { return env.maddr }
+
+func (env *statusEnvironment) SystemService() (cmds.Executor, error) {
+	if env.apiErr != nil {
+		return nil, env.apiErr
+	}
+	return env, nil
+}
+
+func (env *statusEnvironment) Execute(request *cmds.Request, emitter cmds.ResponseEmitter, _ cmds.Environment) error {
+	if request.Command != ClientRoot.Subcommands[listParameter] {
+		return errors.New("unexpected request")
+	}
+	for _, instance := range env.instances {
+		if err := emitter.Emit(instance); err != nil {
+			return err
+		}
+	}
+	return emitter.Close()
+}
+
+func TestServiceStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	request, err := cmds.NewRequest(ctx, []string{serviceParameter, statusParameter}, nil, nil, nil, ClientRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maddr, err := multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/5001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mountpoint := func(t *testing.T, target, ipfsAPI string) manager.Response {
+		t.Helper()
+		maddr, err := multiaddr.NewMultiaddr("/fuse/ipfs/path/" + target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return manager.Response{Request: maddr, Statistics: &manager.Statistics{IPFSAPI: ipfsAPI}}
+	}
+	notListening := errors.New("connection refused")
+
+	for _, test := range []struct {
+		name       string
+		svc        *statusService
+		env        *statusEnvironment
+		expected   serviceStatus
+		text, json []string // substrings expected in the output
+		absent     []string // substrings that must not be in the text output
+	}{
+		{
+			name: "not installed",
+			svc:  &statusService{err: service.ErrNotInstalled},
+			env:  &statusEnvironment{maddr: maddr, apiErr: notListening},
+			expected: serviceStatus{
+				Manager: statusNotInstalled, API: maddr.String(), APIError: notListening.Error(),
+			},
+			text:   []string{"Service Manager: Not installed", "Service API: Not listening - " + maddr.String(), notListening.Error()},
+			json:   []string{`"manager":"Not installed"`, `"installed":false`, `"running":false`, `"listening":false`},
+			absent: []string{"Active instances"},
+		},
+		{
+			name: "installed but not running",
+			svc:  &statusService{status: service.StatusStopped},
+			env:  &statusEnvironment{maddr: maddr, apiErr: notListening},
+			expected: serviceStatus{
+				Manager: statusStopped, Installed: true, API: maddr.String(), APIError: notListening.Error(),
+			},
+			text:   []string{"Service Manager: Stopped", "Service API: Not listening"},
+			json:   []string{`"manager":"Stopped"`, `"installed":true`, `"running":false`, `"listening":false`},
+			absent: []string{"Active instances"},
+		},
+		{
+			name: "not listening",
+			svc:  &statusService{status: service.StatusRunning},
+			env:  &statusEnvironment{maddr: maddr, apiErr: notListening},
+			expected: serviceStatus{
+				Manager: statusRunning, Installed: true, Running: true, API: maddr.String(), APIError: notListening.Error(),
+			},
+			text:   []string{"Service Manager: Running", "Service API: Not listening", notListening.Error()},
+			json:   []string{`"running":true`, `"listening":false`, `"apiError":"connection refused"`},
+			absent: []string{"Active instances"},
+		},
+		{
+			name: "running",
+			svc:  &statusService{status: service.StatusRunning},
+			env: &statusEnvironment{maddr: maddr, instances: []manager.Response{
+				mountpoint(t, "a", "/ip4/127.0.0.1/tcp/5002"),
+				mountpoint(t, "b", "/ip4/127.0.0.1/tcp/5001"),
+				mountpoint(t, "c", "/ip4/127.0.0.1/tcp/5002"),
+			}},
+			expected: serviceStatus{
+				Manager: statusRunning, Installed: true, Running: true, API: maddr.String(), Listening: true,
+				Instances: 3, IPFS: []string{"/ip4/127.0.0.1/tcp/5001", "/ip4/127.0.0.1/tcp/5002"},
+			},
+			text: []string{
+				"Service API: Listening - " + maddr.String(),
+				"Active instances: 3",
+				"IPFS API: /ip4/127.0.0.1/tcp/5001, /ip4/127.0.0.1/tcp/5002",
+			},
+			json: []string{`"listening":true`, `"instances":3`, `"ipfs":["/ip4/127.0.0.1/tcp/5001","/ip4/127.0.0.1/tcp/5002"]`},
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			status := getStatus(request, test.env, test.svc)
+			if got, expected := encodeStatus(t, status), encodeStatus(t, &test.expected); got != expected {
+				t.Errorf("status mismatch\n\tgot: %s\n\texpected: %s", got, expected)
+			}
+
+			var text bytes.Buffer
+			if err := formatStatus(request, &text, status); err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range test.text {
+				if !strings.Contains(text.String(), expected) {
+					t.Errorf("text output is missing %q:\n%s", expected, text.String())
+				}
+			}
+			for _, unexpected := range test.absent {
+				if strings.Contains(text.String(), unexpected) {
+					t.Errorf("text output contains %q:\n%s", unexpected, text.String())
+				}
+			}
+
+			encoded := encodeStatus(t, status)
+			for _, expected := range test.json {
+				if !strings.Contains(encoded, expected) {
+					t.Errorf("JSON output is missing %s: %s", expected, encoded)
+				}
+			}
+			var decoded serviceStatus
+			if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if got := encodeStatus(t, &decoded); got != encoded {
+				t.Errorf("JSON output didn't survive decoding\n\tgot: %s\n\texpected: %s", got, encoded)
+			}
+		})
+	}
+}
+
+func encodeStatus(t *testing.T, status *serviceStatus) string {
+	t.Helper()
+	encoded, err := json.Marshal(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(encoded)
+}
@@ -15,11 +15,15 @@ const (
 	unmountArgumentDescription  = "Multiaddr style targets to detach from host. " + mountTargetExamples
 	unmountAllOptionKwd         = "all"
 	unmountAllOptionDescription = "close all active instances (exclusive: do not provide arguments with this flag)"
+
+	unmountForgetOptionKwd         = "forget"
+	unmountForgetOptionDescription = "also remove the targets from the persistent requests (even if they are not active)"
 )
 
 var Unmount = &cmds.Command{
 	Options: []cmds.Option{
 		cmds.BoolOption(unmountAllOptionKwd, "a", unmountAllOptionDescription),
+		cmds.BoolOption(unmountForgetOptionKwd, unmountForgetOptionDescription),
 	},
 	Arguments: []cmds.Argument{
 		cmds.StringArg(mountStringArgument, false, true, unmountArgumentDescription),
@@ -85,17 +89,17 @@ func unmountRun(request *cmds.Request, emitter cmds.ResponseEmitter, env cmds.En
 		return err
 	}
 
-	var match func(instance manager.Response) bool
+	var match func(target string) bool
 	closeAll, err := closeAllOption(request)
 	if err != nil {
 		return err
 	}
 	if closeAll {
-		match = func(manager.Response) bool { return true }
+		match = func(string) bool { return true }
 	} else {
-		match = func(instance manager.Response) bool {
+		match = func(target string) bool {
 			for _, instanceTarget := range request.Arguments {
-				if instance.String() == instanceTarget {
+				if target == instanceTarget {
 					return true
 				}
 			}
@@ -103,6 +107,12 @@ func unmountRun(request *cmds.Request, emitter cmds.ResponseEmitter, env cmds.En
 		}
 	}
 
+	if forget, _ := request.Options[unmountForgetOptionKwd].(bool); forget {
+		if err := forgetRequests(match); err != nil {
+			return err
+		}
+	}
+
 	var (
 		ctx         = request.Context
 		inputErrors errors.Stream // intentionally nil, unmount has no possible input errors (yet)
@@ -112,7 +122,7 @@ func unmountRun(request *cmds.Request, emitter cmds.ResponseEmitter, env cmds.En
 		relay       = make(chan manager.Response, len(responses))
 		maybeDetach = func(instance manager.Response) {
 			defer wg.Done()
-			if match(instance) {
+			if match(instance.String()) {
 				instance.Error = instance.Close()
 				relay <- instance
 			}
@@ -0,0 +1,9 @@
+//go:build darwin || freebsd
+// +build darwin freebsd
+
+package fscmds
+
+import "golang.org/x/sys/unix"
+
+// forceUnmount detaches the mountpoint from the host, even if it's busy.
+func forceUnmount(target string) error { return unix.Unmount(target, unix.MNT_FORCE) }
@@ -0,0 +1,15 @@
+package fscmds
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// forceUnmount lazily detaches the mountpoint from the host.
+func forceUnmount(target string) error {
+	if err := syscall.Unmount(target, syscall.MNT_DETACH); err == nil {
+		return nil
+	}
+	// unprivileged processes must use the FUSE helper instead
+	return exec.Command("fusermount", "-u", "-z", target).Run()
+}
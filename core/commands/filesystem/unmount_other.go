@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package fscmds
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func forceUnmount(target string) error {
+	return fmt.Errorf("forced unmount is not supported on %s", runtime.GOOS)
+}
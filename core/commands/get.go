@@ -311,18 +311,10 @@ func fileArchive(f files.Node, name string, archive bool, compression int) (io.R
 	} else {
 		// the case for 1. archive, and 2. not archived and not compressed, in which tar is used anyway as a transport format
 
-		// construct the tar writer
-		w, err := files.NewTarWriter(maybeGzw)
-		if checkErrAndClosePipe(err) {
-			return nil, err
-		}
-
 		go func() {
-			// write all the nodes recursively
-			if err := w.WriteFile(f, filename); checkErrAndClosePipe(err) {
+			if err := WriteTar(maybeGzw, f, filename); checkErrAndClosePipe(err) {
 				return
 			}
-			w.Close()         // close tar writer
 			closeGzwAndPipe() // everything seems to be ok
 		}()
 	}
@@ -330,6 +322,22 @@ func fileArchive(f files.Node, name string, archive bool, compression int) (io.R
 	return piper, nil
 }
 
+// WriteTar writes the node, and everything beneath it, to w as a TAR archive,
+// with the node's entry named `name`.
+func WriteTar(w io.Writer, f files.Node, name string) error {
+	// construct the tar writer
+	tw, err := files.NewTarWriter(w)
+	if err != nil {
+		return err
+	}
+
+	// write all the nodes recursively
+	if err := tw.WriteFile(f, name); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
 func newMaybeGzWriter(w io.Writer, compression int) (io.WriteCloser, error) {
 	if compression != gzip.NoCompression {
 		return gzip.NewWriterLevel(w, compression)
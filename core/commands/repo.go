@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	gopath "path"
 	"runtime"
 	"strings"
 	"sync"
 	"text/tabwriter"
 
 	humanize "github.com/dustin/go-humanize"
+	core "github.com/ipfs/go-ipfs/core"
 	cmdenv "github.com/ipfs/go-ipfs/core/commands/cmdenv"
 	corerepo "github.com/ipfs/go-ipfs/core/corerepo"
 	fsrepo "github.com/ipfs/go-ipfs/repo/fsrepo"
@@ -46,11 +48,34 @@ var RepoCmd = &cmds.Command{
 type GcResult struct {
 	Key   cid.Cid
 	Error string `json:",omitempty"`
+	// Size of a block that would be removed (with --dry-run).
+	Size uint64 `json:",omitempty"`
+	// Summary of the blocks that would be removed, sent last (with --dry-run).
+	Summary *GcSummary `json:",omitempty"`
+	// Retention is a reason for Key to be kept (with --explain).
+	Retention *GcRetention `json:",omitempty"`
+}
+
+// GcSummary totals the blocks a garbage collection would remove.
+type GcSummary struct {
+	Blocks uint64
+	Bytes  uint64
+}
+
+// GcRetention describes a root that keeps a block from being collected.
+// An empty Kind means that no root keeps the block.
+type GcRetention struct {
+	Root cid.Cid
+	Kind string
+	Path string // from the root to the block
 }
 
 const (
 	repoStreamErrorsOptionName = "stream-errors"
 	repoQuietOptionName        = "quiet"
+	repoDryRunOptionName       = "dry-run"
+	repoVerboseOptionName      = "verbose"
+	repoExplainOptionName      = "explain"
 )
 
 var repoGcCmd = &cmds.Command{
@@ -60,11 +85,26 @@ var repoGcCmd = &cmds.Command{
 'ipfs repo gc' is a plumbing command that will sweep the local
 set of stored objects and remove ones that are not pinned in
 order to reclaim hard disk space.
+
+Collections are incremental: the references to blocks from pins and
+the MFS root are counted in an index, so a collection only walks the
+parts of the DAGs whose pins (or MFS root) changed since the last one.
+Collections pause between batches of blocks, so adds and pins may
+proceed while they run. An interrupted collection keeps the roots it
+counted, and the next one resumes from the rest.
+
+To audit a collection before running it, use '--dry-run', which reports
+the number and size of the blocks that would be removed (and lists them
+with '--verbose'), or '--explain=<cid>', which reports each pin or root
+that keeps the block, and the path from it to the block.
 `,
 	},
 	Options: []cmds.Option{
 		cmds.BoolOption(repoStreamErrorsOptionName, "Stream errors."),
 		cmds.BoolOption(repoQuietOptionName, "q", "Write minimal output."),
+		cmds.BoolOption(repoDryRunOptionName, "Report what would be removed, without removing anything."),
+		cmds.BoolOption(repoVerboseOptionName, "v", "List the blocks that would be removed (with --dry-run)."),
+		cmds.StringOption(repoExplainOptionName, "Report why the block is retained, without removing anything."),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		n, err := cmdenv.GetNode(env)
@@ -72,6 +112,13 @@ order to reclaim hard disk space.
 			return err
 		}
 
+		if explain, ok := req.Options[repoExplainOptionName].(string); ok {
+			return explainGc(req, re, n, explain)
+		}
+		if dryRun, _ := req.Options[repoDryRunOptionName].(bool); dryRun {
+			return dryRunGc(req, re, n)
+		}
+
 		streamErrors, _ := req.Options[repoStreamErrorsOptionName].(bool)
 
 		gcOutChan := corerepo.GarbageCollectAsync(n, req.Context)
@@ -79,6 +126,9 @@ order to reclaim hard disk space.
 		if streamErrors {
 			errs := false
 			for res := range gcOutChan {
+				if res.Progress != nil {
+					continue
+				}
 				if res.Error != nil {
 					if err := re.Emit(&GcResult{Error: res.Error.Error()}); err != nil {
 						return err
@@ -117,6 +167,37 @@ order to reclaim hard disk space.
 				return err
 			}
 
+			if gcr.Summary != nil {
+				if quiet {
+					return nil
+				}
+				_, err := fmt.Fprintf(w, "would remove %d blocks (%s)\n",
+					gcr.Summary.Blocks, humanize.Bytes(gcr.Summary.Bytes))
+				return err
+			}
+
+			if retention := gcr.Retention; retention != nil {
+				if retention.Kind == "" {
+					_, err := fmt.Fprintf(w, "%s is not retained by any pin or root\n", gcr.Key)
+					return err
+				}
+				if quiet {
+					_, err := fmt.Fprintln(w, retention.Path)
+					return err
+				}
+				_, err := fmt.Fprintf(w, "retained by %s %s via %s\n", retention.Kind, retention.Root, retention.Path)
+				return err
+			}
+
+			if dryRun, _ := req.Options[repoDryRunOptionName].(bool); dryRun {
+				if quiet {
+					_, err := fmt.Fprintln(w, gcr.Key)
+					return err
+				}
+				_, err := fmt.Fprintf(w, "would remove %s (%s)\n", gcr.Key, humanize.Bytes(gcr.Size))
+				return err
+			}
+
 			prefix := "removed "
 			if quiet {
 				prefix = ""
@@ -128,6 +209,83 @@ order to reclaim hard disk space.
 	},
 }
 
+// dryRunGc reports the blocks a collection would remove, followed by their total.
+func dryRunGc(req *cmds.Request, re cmds.ResponseEmitter, n *core.IpfsNode) error {
+	verbose, _ := req.Options[repoVerboseOptionName].(bool)
+	quiet, _ := req.Options[repoQuietOptionName].(bool)
+	listBlocks := verbose || quiet
+
+	var (
+		summary GcSummary
+		errs    bool
+	)
+	for res := range corerepo.GarbageCollectDryRun(n, req.Context) {
+		if res.Progress != nil {
+			continue
+		}
+		if res.Error != nil {
+			errs = true
+			if err := re.Emit(&GcResult{Error: res.Error.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		size, err := n.Blockstore.GetSize(res.KeyRemoved)
+		if err != nil {
+			errs = true
+			if err := re.Emit(&GcResult{Key: res.KeyRemoved, Error: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		summary.Blocks++
+		summary.Bytes += uint64(size)
+
+		if listBlocks {
+			if err := re.Emit(&GcResult{Key: res.KeyRemoved, Size: uint64(size)}); err != nil {
+				return err
+			}
+		}
+	}
+	if errs {
+		return errors.New("encountered errors during gc dry run")
+	}
+
+	return re.Emit(&GcResult{Summary: &summary})
+}
+
+// explainGc reports each root that keeps the block from being collected.
+func explainGc(req *cmds.Request, re cmds.ResponseEmitter, n *core.IpfsNode, explain string) error {
+	c, err := cid.Decode(explain)
+	if err != nil {
+		return err
+	}
+
+	retentions, err := corerepo.ExplainRetention(req.Context, n, c)
+	if err != nil {
+		return err
+	}
+	if len(retentions) == 0 {
+		return re.Emit(&GcResult{Key: c, Retention: &GcRetention{}})
+	}
+
+	for _, retention := range retentions {
+		path := gopath.Join(append([]string{"/ipfs", retention.Root.String()}, retention.Path...)...)
+		if err := re.Emit(&GcResult{
+			Key: c,
+			Retention: &GcRetention{
+				Root: retention.Root,
+				Kind: string(retention.Kind),
+				Path: path,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 const (
 	repoSizeOnlyOptionName = "size-only"
 	repoHumanOptionName    = "human"
@@ -9,15 +9,24 @@ import (
 	version "github.com/ipfs/go-ipfs"
 	core "github.com/ipfs/go-ipfs/core"
 	coreapi "github.com/ipfs/go-ipfs/core/coreapi"
+	repo "github.com/ipfs/go-ipfs/repo"
 
 	options "github.com/ipfs/interface-go-ipfs-core/options"
 	id "github.com/libp2p/go-libp2p/p2p/protocol/identify"
 )
 
+const (
+	gatewayConfigKey      = "Gateway"
+	writableKeysConfigKey = "WritableKeys" // (within the gateway's section)
+)
+
 type GatewayConfig struct {
 	Headers      map[string][]string
 	Writable     bool
 	PathPrefixes []string
+	// WritableKeys (names or IDs) are the keys whose /ipns paths may be written to.
+	// If nil, every key held by the node is writable.
+	WritableKeys []string
 }
 
 // A helper function to clean up a set of headers:
@@ -87,11 +96,17 @@ func GatewayOption(writable bool, paths ...string) ServeOption {
 				"X-Stream-Output",
 			}, headers[ACEHeadersName]...))
 
+		writableKeys, err := gatewayWritableKeys(n.Repo)
+		if err != nil {
+			return nil, err
+		}
+
 		gateway := newGatewayHandler(GatewayConfig{
 			Headers:      headers,
 			Writable:     writable,
 			PathPrefixes: cfg.Gateway.PathPrefixes,
-		}, api)
+			WritableKeys: writableKeys,
+		}, api, n.Repo.Datastore())
 
 		for _, p := range paths {
 			mux.Handle(p+"/", gateway)
@@ -100,6 +115,38 @@ func GatewayOption(writable bool, paths ...string) ServeOption {
 	}
 }
 
+// gatewayWritableKeys returns the value of `Gateway.WritableKeys`, or nil if it's not set.
+// (the key is not part of the config's structure, so it's read from the raw config)
+func gatewayWritableKeys(r repo.Repo) ([]string, error) {
+	section, err := r.GetConfigKey(gatewayConfigKey)
+	if err != nil {
+		return nil, err
+	}
+	gatewayConfig, ok := section.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected a map, got %T", gatewayConfigKey, section)
+	}
+	value := gatewayConfig[writableKeysConfigKey]
+	if value == nil {
+		return nil, nil // not set
+	}
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s.%s: expected a list of key names or IDs, got %T",
+			gatewayConfigKey, writableKeysConfigKey, value)
+	}
+	keys := make([]string, 0, len(values))
+	for _, value := range values {
+		key, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s.%s: expected a key name or ID, got %T",
+				gatewayConfigKey, writableKeysConfigKey, value)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
 func VersionOption() ServeOption {
 	return func(_ *core.IpfsNode, _ net.Listener, mux *http.ServeMux) (*http.ServeMux, error) {
 		mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
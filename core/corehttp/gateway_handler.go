@@ -18,6 +18,7 @@ import (
 	humanize "github.com/dustin/go-humanize"
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
 	files "github.com/ipfs/go-ipfs-files"
 	assets "github.com/ipfs/go-ipfs/assets"
 	dag "github.com/ipfs/go-merkledag"
@@ -39,8 +40,10 @@ var onlyAscii = regexp.MustCompile("[[:^ascii:]]")
 // gatewayHandler is a HTTP handler that serves IPFS objects (accessible by default at /ipfs/<path>)
 // (it serves requests like GET /ipfs/QmVRzPKPzNtSrEzBFm2UZfxmPAgnaLke4DMcerbsGGSaFe/link)
 type gatewayHandler struct {
-	config GatewayConfig
-	api    coreiface.CoreAPI
+	config    GatewayConfig
+	api       coreiface.CoreAPI
+	records   ds.Datastore // holds the IPNS records published by the node
+	keyWrites keyLocks     // serializes writes to the value of each key
 }
 
 // StatusResponseWriter enables us to override HTTP Status Code passed to
@@ -62,10 +65,11 @@ func (sw *statusResponseWriter) WriteHeader(code int) {
 	sw.ResponseWriter.WriteHeader(code)
 }
 
-func newGatewayHandler(c GatewayConfig, api coreiface.CoreAPI) *gatewayHandler {
+func newGatewayHandler(c GatewayConfig, api coreiface.CoreAPI, records ds.Datastore) *gatewayHandler {
 	i := &gatewayHandler{
-		config: c,
-		api:    api,
+		config:  c,
+		api:     api,
+		records: records,
 	}
 	return i
 }
@@ -237,6 +241,21 @@ func (i *gatewayHandler) getOrHeadHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// serve the blocks themselves, rather than the content they encode, if requested
+	contentType, err := requestedContentType(r)
+	if err != nil {
+		webError(w, "invalid format", err, http.StatusBadRequest)
+		return
+	}
+	switch contentType {
+	case carContentType:
+		i.serveCar(w, r, urlPath, resolvedPath)
+		return
+	case rawContentType:
+		i.serveRawBlock(w, r, urlPath, resolvedPath)
+		return
+	}
+
 	dr, err := i.api.Unixfs().Get(r.Context(), resolvedPath)
 	if err != nil {
 		webError(w, "ipfs cat "+escapedURLPath, err, http.StatusNotFound)
@@ -247,6 +266,13 @@ func (i *gatewayHandler) getOrHeadHandler(w http.ResponseWriter, r *http.Request
 
 	defer dr.Close()
 
+	if dir, ok := dr.(files.Directory); ok {
+		if format, ok := requestedArchive(r); ok {
+			i.serveArchive(w, r, urlPath, resolvedPath, dir, format)
+			return
+		}
+	}
+
 	var responseEtag string
 
 	// we need to figure out whether this is a directory before doing most of the heavy lifting below
@@ -267,6 +293,7 @@ func (i *gatewayHandler) getOrHeadHandler(w http.ResponseWriter, r *http.Request
 	i.addUserHeaders(w) // ok, _now_ write user's headers.
 	w.Header().Set("X-IPFS-Path", urlPath)
 	w.Header().Set("Etag", responseEtag)
+	w.Header().Add("Vary", "Accept") // (blocks may be requested instead)
 
 	// set these headers _after_ the error, for we may just not have it
 	// and don't want the client to cache a 500 response...
@@ -527,6 +554,12 @@ func (i *gatewayHandler) servePretty404IfPresent(w http.ResponseWriter, r *http.
 }
 
 func (i *gatewayHandler) postHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, ipnsPathPrefix) {
+		// keys have a single mutable root, so new content must be given a name within it
+		i.putHandler(w, r)
+		return
+	}
+
 	p, err := i.api.Unixfs().Add(r.Context(), files.NewReaderFile(r.Body))
 	if err != nil {
 		internalWebError(w, err)
@@ -543,9 +576,9 @@ func (i *gatewayHandler) putHandler(w http.ResponseWriter, r *http.Request) {
 	ds := i.api.Dag()
 
 	// Parse the path
-	rootCid, newPath, err := parseIpfsPath(r.URL.Path)
+	root, newPath, status, err := i.parseWritablePath(ctx, r.URL.Path)
 	if err != nil {
-		webError(w, "WritableGateway: failed to parse the path", err, http.StatusBadRequest)
+		webError(w, "WritableGateway: failed to parse the path", err, status)
 		return
 	}
 	if newPath == "" || newPath == "/" {
@@ -554,49 +587,56 @@ func (i *gatewayHandler) putHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	newDirectory, newFileName := gopath.Split(newPath)
 
-	// Resolve the old root.
+	// Create the new file.
+	// (before the root is locked, so that slow clients don't hold up writes to the same key)
+	newFilePath, err := i.api.Unixfs().Add(ctx, files.NewReaderFile(r.Body))
+	if err != nil {
+		webError(w, "WritableGateway: could not create DAG from request", err, http.StatusInternalServerError)
+		return
+	}
 
-	rnode, err := ds.Get(ctx, rootCid)
+	newFile, err := ds.Get(ctx, newFilePath.Cid())
 	if err != nil {
-		webError(w, "WritableGateway: Could not create DAG from request", err, http.StatusInternalServerError)
+		webError(w, "WritableGateway: failed to resolve new file", err, http.StatusInternalServerError)
 		return
 	}
 
-	pbnd, ok := rnode.(*dag.ProtoNode)
-	if !ok {
-		webError(w, "Cannot read non protobuf nodes through gateway", dag.ErrNotProtobuf, http.StatusBadRequest)
+	// Resolve the old root.
+
+	if err := i.lockRoot(ctx, &root); err != nil {
+		webError(w, "WritableGateway: failed to resolve the key's value", err, http.StatusInternalServerError)
 		return
 	}
+	defer root.release()
 
-	// Create the new file.
-	newFilePath, err := i.api.Unixfs().Add(ctx, files.NewReaderFile(r.Body))
+	rnode, err := ds.Get(ctx, root.cid)
 	if err != nil {
-		webError(w, "WritableGateway: could not create DAG from request", err, http.StatusInternalServerError)
+		webError(w, "WritableGateway: Could not create DAG from request", err, http.StatusInternalServerError)
 		return
 	}
 
-	newFile, err := ds.Get(ctx, newFilePath.Cid())
-	if err != nil {
-		webError(w, "WritableGateway: failed to resolve new file", err, http.StatusInternalServerError)
+	pbnd, ok := rnode.(*dag.ProtoNode)
+	if !ok {
+		webError(w, "Cannot read non protobuf nodes through gateway", dag.ErrNotProtobuf, http.StatusBadRequest)
 		return
 	}
 
 	// Patch the new file into the old root.
 
-	root, err := mfs.NewRoot(ctx, ds, pbnd, nil)
+	mroot, err := mfs.NewRoot(ctx, ds, pbnd, nil)
 	if err != nil {
 		webError(w, "WritableGateway: failed to create MFS root", err, http.StatusBadRequest)
 		return
 	}
 
 	if newDirectory != "" {
-		err := mfs.Mkdir(root, newDirectory, mfs.MkdirOpts{Mkparents: true, Flush: false})
+		err := mfs.Mkdir(mroot, newDirectory, mfs.MkdirOpts{Mkparents: true, Flush: false})
 		if err != nil {
 			webError(w, "WritableGateway: failed to create MFS directory", err, http.StatusInternalServerError)
 			return
 		}
 	}
-	dirNode, err := mfs.Lookup(root, newDirectory)
+	dirNode, err := mfs.Lookup(mroot, newDirectory)
 	if err != nil {
 		webError(w, "WritableGateway: failed to lookup directory", err, http.StatusInternalServerError)
 		return
@@ -618,16 +658,13 @@ func (i *gatewayHandler) putHandler(w http.ResponseWriter, r *http.Request) {
 		webError(w, "WritableGateway: failed to link file into directory", err, http.StatusInternalServerError)
 		return
 	}
-	nnode, err := root.GetDirectory().GetNode()
+	nnode, err := mroot.GetDirectory().GetNode()
 	if err != nil {
 		webError(w, "WritableGateway: failed to finalize", err, http.StatusInternalServerError)
 		return
 	}
-	newcid := nnode.Cid()
 
-	i.addUserHeaders(w) // ok, _now_ write user's headers.
-	w.Header().Set("IPFS-Hash", newcid.String())
-	http.Redirect(w, r, gopath.Join(ipfsPathPrefix, newcid.String(), newPath), http.StatusCreated)
+	i.respondWritten(w, r, root, nnode.Cid(), newPath)
 }
 
 func (i *gatewayHandler) deleteHandler(w http.ResponseWriter, r *http.Request) {
@@ -635,9 +672,9 @@ func (i *gatewayHandler) deleteHandler(w http.ResponseWriter, r *http.Request) {
 
 	// parse the path
 
-	rootCid, newPath, err := parseIpfsPath(r.URL.Path)
+	root, newPath, status, err := i.parseWritablePath(ctx, r.URL.Path)
 	if err != nil {
-		webError(w, "WritableGateway: failed to parse the path", err, http.StatusBadRequest)
+		webError(w, "WritableGateway: failed to parse the path", err, status)
 		return
 	}
 	if newPath == "" || newPath == "/" {
@@ -648,7 +685,13 @@ func (i *gatewayHandler) deleteHandler(w http.ResponseWriter, r *http.Request) {
 
 	// lookup the root
 
-	rootNodeIPLD, err := i.api.Dag().Get(ctx, rootCid)
+	if err := i.lockRoot(ctx, &root); err != nil {
+		webError(w, "WritableGateway: failed to resolve the key's value", err, http.StatusInternalServerError)
+		return
+	}
+	defer root.release()
+
+	rootNodeIPLD, err := i.api.Dag().Get(ctx, root.cid)
 	if err != nil {
 		webError(w, "WritableGateway: failed to resolve root CID", err, http.StatusInternalServerError)
 		return
@@ -661,7 +704,7 @@ func (i *gatewayHandler) deleteHandler(w http.ResponseWriter, r *http.Request) {
 
 	// construct the mfs root
 
-	root, err := mfs.NewRoot(ctx, i.api.Dag(), rootNode, nil)
+	mroot, err := mfs.NewRoot(ctx, i.api.Dag(), rootNode, nil)
 	if err != nil {
 		webError(w, "WritableGateway: failed to construct the MFS root", err, http.StatusBadRequest)
 		return
@@ -669,7 +712,7 @@ func (i *gatewayHandler) deleteHandler(w http.ResponseWriter, r *http.Request) {
 
 	// lookup the parent directory
 
-	parentNode, err := mfs.Lookup(root, directory)
+	parentNode, err := mfs.Lookup(mroot, directory)
 	if err != nil {
 		webError(w, "WritableGateway: failed to look up parent", err, http.StatusInternalServerError)
 		return
@@ -690,16 +733,14 @@ func (i *gatewayHandler) deleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	nnode, err := root.GetDirectory().GetNode()
+	nnode, err := mroot.GetDirectory().GetNode()
 	if err != nil {
 		webError(w, "WritableGateway: failed to finalize", err, http.StatusInternalServerError)
+		return
 	}
-	ncid := nnode.Cid()
 
-	i.addUserHeaders(w) // ok, _now_ write user's headers.
-	w.Header().Set("IPFS-Hash", ncid.String())
 	// note: StatusCreated is technically correct here as we created a new resource.
-	http.Redirect(w, r, gopath.Join(ipfsPathPrefix+ncid.String(), directory), http.StatusCreated)
+	i.respondWritten(w, r, root, nnode.Cid(), directory)
 }
 
 func (i *gatewayHandler) addUserHeaders(w http.ResponseWriter) {
@@ -0,0 +1,184 @@
+package corehttp
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	gopath "path"
+	"strings"
+	"time"
+
+	files "github.com/ipfs/go-ipfs-files"
+	corecommands "github.com/ipfs/go-ipfs/core/commands"
+	ipath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+var errArchiveEntryName = errors.New("entry name is not a single path component")
+
+type archiveFormat struct {
+	contentType, extension string
+	write                  func(w io.Writer, dir files.Directory, name string) error
+}
+
+// archive formats which directories may be downloaded as, with `?download=<format>`
+var archiveFormats = map[string]archiveFormat{
+	"tar": {"application/x-tar", ".tar", func(w io.Writer, dir files.Directory, name string) error {
+		return corecommands.WriteTar(w, dir, name) // (same as `ipfs get --archive`)
+	}},
+	"zip": {"application/zip", ".zip", writeZip},
+}
+
+// requestedArchive returns the archive format requested for a directory, if any.
+func requestedArchive(r *http.Request) (archiveFormat, bool) {
+	format, ok := archiveFormats[r.URL.Query().Get("download")]
+	return format, ok
+}
+
+// serveArchive responds with the directory, and everything beneath it, as a single archive.
+func (i *gatewayHandler) serveArchive(w http.ResponseWriter, r *http.Request, urlPath string, resolvedPath ipath.Resolved, dir files.Directory, format archiveFormat) {
+	// (weak, as archive entries carry the time they were written)
+	etag := `W/"` + resolvedPath.Cid().String() + format.extension + `"`
+	if etagMatches(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// the name is the root of every entry, so it must not contain a path of its own
+	name := archiveName(r.URL.Query().Get("filename"))
+	if name == "" {
+		if name = archiveName(getFilename(urlPath)); name == "" {
+			name = resolvedPath.Cid().String()
+		}
+	}
+
+	i.addUserHeaders(w)
+	w.Header().Set("X-IPFS-Path", urlPath)
+	w.Header().Set("Etag", etag)
+	w.Header().Set("Content-Type", format.contentType)
+	utf8Name := url.PathEscape(name + format.extension)
+	asciiName := url.PathEscape(onlyAscii.ReplaceAllLiteralString(name, "_") + format.extension)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"; filename*=UTF-8''%s", asciiName, utf8Name))
+	if strings.HasPrefix(urlPath, ipfsPathPrefix) {
+		w.Header().Set("Cache-Control", "public, max-age=29030400, immutable")
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	// NOTE: like CAR streams, the status has been sent by the time the tree is fetched;
+	// if fetching fails, the archive ends early (without its trailer)
+	w.WriteHeader(http.StatusOK)
+	if err := format.write(w, checkedDirectory{dir}, name); err != nil {
+		log.Warnf("%s archive of %s ended early: %s", strings.TrimPrefix(format.extension, "."), urlPath, err)
+	}
+}
+
+// archiveName returns the last component of the name (if any)
+func archiveName(name string) string {
+	if name = gopath.Base(gopath.Clean("/" + name)); name == "/" {
+		return ""
+	}
+	return name
+}
+
+// checkedDirectory ends the iteration of its entries (with an error),
+// at the first entry whose name would place it outside of its directory within an archive
+// (UnixFS does not prevent these names, so they must not be trusted)
+type checkedDirectory struct{ files.Directory }
+
+type checkedIterator struct {
+	files.DirIterator
+	err error
+}
+
+func (cd checkedDirectory) Entries() files.DirIterator {
+	return &checkedIterator{DirIterator: cd.Directory.Entries()}
+}
+
+func (ci *checkedIterator) Next() bool {
+	if ci.err != nil || !ci.DirIterator.Next() {
+		return false
+	}
+	if name := ci.Name(); name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		ci.err = fmt.Errorf("%w: %q", errArchiveEntryName, name)
+		return false
+	}
+	return true
+}
+
+func (ci *checkedIterator) Node() files.Node {
+	if dir, ok := ci.DirIterator.Node().(files.Directory); ok {
+		return checkedDirectory{dir}
+	}
+	return ci.DirIterator.Node()
+}
+
+func (ci *checkedIterator) Err() error {
+	if ci.err != nil {
+		return ci.err
+	}
+	return ci.DirIterator.Err()
+}
+
+// writeZip writes the directory, and everything beneath it, to w as a ZIP archive,
+// rooted at `name`.
+func writeZip(w io.Writer, dir files.Directory, name string) error {
+	zw := zip.NewWriter(w)
+	if err := writeZipNode(zw, dir, name); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeZipNode adds the node to the archive,
+// using the same entry modes as the TAR writer.
+func writeZipNode(zw *zip.Writer, nd files.Node, fpath string) error {
+	header := &zip.FileHeader{
+		Name:     fpath,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	}
+
+	switch nd := nd.(type) {
+	case *files.Symlink:
+		// symlinks are stored with their target as their content
+		header.SetMode(os.ModeSymlink | 0777)
+		entry, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(entry, nd.Target)
+		return err
+
+	case files.File:
+		header.SetMode(0644)
+		entry, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(entry, nd)
+		return err
+
+	case files.Directory:
+		header.Name += "/"
+		header.Method = zip.Store
+		header.SetMode(os.ModeDir | 0777)
+		if _, err := zw.CreateHeader(header); err != nil {
+			return err
+		}
+		it := nd.Entries()
+		for it.Next() {
+			if err := writeZipNode(zw, it.Node(), gopath.Join(fpath, it.Name())); err != nil {
+				return err
+			}
+		}
+		return it.Err()
+
+	default:
+		return fmt.Errorf("file type %T is not supported", nd)
+	}
+}
@@ -0,0 +1,131 @@
+package corehttp
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	dag "github.com/ipfs/go-merkledag"
+	ipath "github.com/ipfs/interface-go-ipfs-core/path"
+	gocar "github.com/ipld/go-car"
+)
+
+const (
+	carContentType = "application/vnd.ipld.car"
+	rawContentType = "application/vnd.ipld.raw"
+)
+
+// formats which may be requested with the `format` query parameter,
+// as an alternative to the `Accept` header
+var formatContentTypes = map[string]string{
+	"car": carContentType,
+	"raw": rawContentType,
+}
+
+// requestedContentType returns the content type of a format other than the default (UnixFS) response,
+// if one was requested (the query parameter takes precedence over the Accept header).
+func requestedContentType(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		contentType, ok := formatContentTypes[format]
+		if !ok {
+			return "", fmt.Errorf("unsupported format %q", format)
+		}
+		return contentType, nil
+	}
+
+	for _, acceptHeader := range r.Header.Values("Accept") {
+		for _, spec := range strings.Split(acceptHeader, ",") {
+			contentType, _, err := mime.ParseMediaType(spec)
+			if err != nil {
+				continue
+			}
+			switch contentType {
+			case carContentType, rawContentType:
+				return contentType, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// etagMatches reports if the client already has the response identified by the ETag
+// (If-None-Match uses the weak comparison)
+func etagMatches(r *http.Request, etag string) bool {
+	return strings.TrimPrefix(r.Header.Get("If-None-Match"), `W/`) == strings.TrimPrefix(etag, `W/`)
+}
+
+// setBlockHeaders sets the headers common to responses which contain blocks (rather than the content they encode).
+func (i *gatewayHandler) setBlockHeaders(w http.ResponseWriter, urlPath, contentType, etag, filename string) {
+	i.addUserHeaders(w)
+	w.Header().Set("X-IPFS-Path", urlPath)
+	w.Header().Set("Etag", etag)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff") // blocks must not be rendered
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	w.Header().Add("Vary", "Accept")
+	// the blocks of a resolved path never change, but the path may (if it's not immutable)
+	if strings.HasPrefix(urlPath, ipfsPathPrefix) {
+		w.Header().Set("Cache-Control", "public, max-age=29030400, immutable")
+	}
+}
+
+// serveRawBlock responds with the block at the resolved path, as-is.
+func (i *gatewayHandler) serveRawBlock(w http.ResponseWriter, r *http.Request, urlPath string, resolvedPath ipath.Resolved) {
+	blockCid := resolvedPath.Cid()
+	etag := `"` + blockCid.String() + `.raw"`
+	if etagMatches(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	reader, err := i.api.Block().Get(r.Context(), resolvedPath)
+	if err != nil {
+		webError(w, "ipfs block get "+blockCid.String(), err, http.StatusInternalServerError)
+		return
+	}
+	block, err := ioutil.ReadAll(reader)
+	if err != nil {
+		webError(w, "ipfs block get "+blockCid.String(), err, http.StatusInternalServerError)
+		return
+	}
+
+	name := blockCid.String() + ".bin"
+	i.setBlockHeaders(w, urlPath, rawContentType, etag, name)
+	// blocks have no modification time of their own
+	http.ServeContent(w, r, name, time.Unix(1, 0), bytes.NewReader(block))
+}
+
+// serveCar responds with the DAG under the resolved path, as a CAR stream.
+// The stream is written in the same order as `ipfs dag export`,
+// so its blocks may be verified while it's read.
+func (i *gatewayHandler) serveCar(w http.ResponseWriter, r *http.Request, urlPath string, resolvedPath ipath.Resolved) {
+	rootCid := resolvedPath.Cid()
+	// (weak, as archives of the same DAG may differ in their block order between implementations)
+	etag := `W/"` + rootCid.String() + `.car"`
+	if etagMatches(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	i.setBlockHeaders(w, urlPath, carContentType+"; version=1", etag, rootCid.String()+".car")
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	// NOTE: the status has been sent by the time the DAG is fetched;
+	// if fetching fails, the stream ends early and the archive is incomplete
+	// (clients can tell, as they'd be missing blocks the archive refers to)
+	w.WriteHeader(http.StatusOK)
+	if err := gocar.WriteCar(r.Context(),
+		dag.NewSession(r.Context(), i.api.Dag()),
+		[]cid.Cid{rootCid},
+		w,
+	); err != nil {
+		log.Warnf("CAR stream of %s ended early: %s", urlPath, err)
+	}
+}
@@ -0,0 +1,200 @@
+package corehttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	gopath "path"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	namecmd "github.com/ipfs/go-ipfs/core/commands/name"
+	"github.com/ipfs/go-ipfs/namesys"
+	path "github.com/ipfs/go-path"
+	"github.com/ipfs/go-unixfs"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	options "github.com/ipfs/interface-go-ipfs-core/options"
+	ipath "github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+var (
+	errKeyNotHeld     = errors.New("the key is not held by this node")
+	errKeyNotWritable = errors.New("the key is not writable through this gateway")
+)
+
+// writableRoot is the root that a write request modifies.
+// Roots of /ipns paths are the current value of a key held by the node,
+// which is republished after the write.
+type writableRoot struct {
+	cid cid.Cid       // (undefined for keys, until they're locked)
+	key coreiface.Key // (nil for /ipfs paths)
+	// release must be called once the write is complete
+	release func()
+}
+
+func noop() {}
+
+// keyLocks serializes writes to each key, so that they apply to its latest value,
+// without blocking writes to other keys.
+// (a lock is retained for every key that was written to, which are limited to the keys held by the node)
+type keyLocks struct {
+	sync.Mutex
+	locks map[peer.ID]*sync.Mutex
+}
+
+func (kl *keyLocks) lock(keyID peer.ID) (unlock func()) {
+	kl.Lock()
+	if kl.locks == nil {
+		kl.locks = make(map[peer.ID]*sync.Mutex)
+	}
+	keyLock, ok := kl.locks[keyID]
+	if !ok {
+		keyLock = new(sync.Mutex)
+		kl.locks[keyID] = keyLock
+	}
+	kl.Unlock()
+
+	keyLock.Lock()
+	return keyLock.Unlock
+}
+
+// parseWritablePath returns the root and remaining path of a write request,
+// along with the status to respond with if it can't be written to.
+// Roots of keys must be locked with `lockRoot` before they're used.
+func (i *gatewayHandler) parseWritablePath(ctx context.Context, p string) (writableRoot, string, int, error) {
+	if !strings.HasPrefix(p, ipnsPathPrefix) {
+		rootCid, subPath, err := parseIpfsPath(p)
+		return writableRoot{cid: rootCid, release: noop}, subPath, http.StatusBadRequest, err
+	}
+
+	rootPath, err := path.ParsePath(p)
+	if err != nil {
+		return writableRoot{}, "", http.StatusBadRequest, err
+	}
+	rsegs := rootPath.Segments()
+	if len(rsegs) < 2 || rsegs[1] == "" {
+		return writableRoot{}, "", http.StatusBadRequest, fmt.Errorf("ipns path missing key ID")
+	}
+	keyID, err := peer.Decode(rsegs[1])
+	if err != nil {
+		return writableRoot{}, "", http.StatusBadRequest, err
+	}
+
+	key, err := i.writableKey(ctx, keyID)
+	switch err {
+	case nil:
+	case errKeyNotHeld, errKeyNotWritable:
+		return writableRoot{}, "", http.StatusForbidden, err
+	default:
+		return writableRoot{}, "", http.StatusInternalServerError, err
+	}
+
+	return writableRoot{key: key, release: noop}, path.Join(rsegs[2:]), 0, nil
+}
+
+// lockRoot holds the lock of the root's key (if any) and resolves the key's current value.
+// The root must be released once the write is complete.
+func (i *gatewayHandler) lockRoot(ctx context.Context, root *writableRoot) error {
+	if root.key == nil {
+		return nil
+	}
+	unlock := i.keyWrites.lock(root.key.ID())
+	rootCid, err := i.keyRoot(ctx, root.key)
+	if err != nil {
+		unlock()
+		return err
+	}
+	root.cid, root.release = rootCid, unlock
+	return nil
+}
+
+// writableKey returns the node's key with the ID,
+// if the gateway is allowed to write to it.
+// (every held key is writable, unless the config lists the writable keys)
+func (i *gatewayHandler) writableKey(ctx context.Context, keyID peer.ID) (coreiface.Key, error) {
+	keys, err := i.api.Key().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if key.ID() != keyID {
+			continue
+		}
+		if i.config.WritableKeys == nil {
+			return key, nil
+		}
+		for _, allowed := range i.config.WritableKeys {
+			if allowed == key.Name() || allowed == keyID.Pretty() || allowed == coreiface.FormatKeyID(keyID) {
+				return key, nil
+			}
+		}
+		return nil, errKeyNotWritable
+	}
+
+	return nil, errKeyNotHeld
+}
+
+// keyRoot returns the current value of the key.
+// The value is read from the record the node last published,
+// rather than resolved through routing, since only this node publishes to its keys.
+func (i *gatewayHandler) keyRoot(ctx context.Context, key coreiface.Key) (cid.Cid, error) {
+	entry, err := namesys.NewIpnsPublisher(nil, i.records).GetPublished(ctx, key.ID(), false)
+	if err != nil {
+		return cid.Cid{}, err
+	}
+	if entry == nil {
+		// the key hasn't been published to yet; start from an empty directory
+		emptyDir := unixfs.EmptyDirNode()
+		if err := i.api.Dag().Add(ctx, emptyDir); err != nil {
+			return cid.Cid{}, err
+		}
+		return emptyDir.Cid(), nil
+	}
+
+	value, err := path.ParsePath(string(entry.GetValue()))
+	if err != nil {
+		return cid.Cid{}, err
+	}
+	resolved, err := i.api.ResolvePath(ctx, ipath.New(value.String()))
+	if err != nil {
+		return cid.Cid{}, err
+	}
+	return resolved.Cid(), nil
+}
+
+// respondWritten responds with the location of the modified root.
+// Roots of keys are published first, and the new IPNS entry is returned to the client.
+func (i *gatewayHandler) respondWritten(w http.ResponseWriter, r *http.Request, root writableRoot, newCid cid.Cid, subPath string) {
+	if root.key == nil {
+		i.addUserHeaders(w) // ok, _now_ write user's headers.
+		w.Header().Set("IPFS-Hash", newCid.String())
+		http.Redirect(w, r, gopath.Join(ipfsPathPrefix, newCid.String(), subPath), http.StatusCreated)
+		return
+	}
+
+	entry, err := i.api.Name().Publish(r.Context(), ipath.IpfsPath(newCid),
+		options.Name.Key(root.key.Name()),
+		options.Name.AllowOffline(true),
+	)
+	if err != nil {
+		webError(w, "WritableGateway: failed to publish "+root.key.Name(), err, http.StatusInternalServerError)
+		return
+	}
+
+	i.addUserHeaders(w) // ok, _now_ write user's headers.
+	w.Header().Set("IPFS-Hash", newCid.String())
+	w.Header().Set("Location", gopath.Join(ipnsPathPrefix, entry.Name(), subPath))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(&namecmd.IpnsEntry{
+		Name:  entry.Name(),
+		Value: entry.Value().String(),
+	}); err != nil {
+		log.Warnf("WritableGateway: failed to write IPNS entry of %s: %s", root.key.Name(), err)
+	}
+}
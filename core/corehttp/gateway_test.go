@@ -1,11 +1,20 @@
 package corehttp
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
 	"regexp"
 	"strings"
 	"testing"
@@ -14,18 +23,23 @@ import (
 	version "github.com/ipfs/go-ipfs"
 	core "github.com/ipfs/go-ipfs/core"
 	"github.com/ipfs/go-ipfs/core/coreapi"
+	keystore "github.com/ipfs/go-ipfs/keystore"
 	namesys "github.com/ipfs/go-ipfs/namesys"
 	repo "github.com/ipfs/go-ipfs/repo"
 
+	proto "github.com/gogo/protobuf/proto"
 	datastore "github.com/ipfs/go-datastore"
 	syncds "github.com/ipfs/go-datastore/sync"
 	config "github.com/ipfs/go-ipfs-config"
 	files "github.com/ipfs/go-ipfs-files"
+	ipns "github.com/ipfs/go-ipns"
 	path "github.com/ipfs/go-path"
 	iface "github.com/ipfs/interface-go-ipfs-core"
 	nsopts "github.com/ipfs/interface-go-ipfs-core/options/namesys"
 	ipath "github.com/ipfs/interface-go-ipfs-core/path"
+	gocar "github.com/ipld/go-car"
 	ci "github.com/libp2p/go-libp2p-core/crypto"
+	peer "github.com/libp2p/go-libp2p-core/peer"
 	id "github.com/libp2p/go-libp2p/p2p/protocol/identify"
 )
 
@@ -69,11 +83,16 @@ func (m mockNamesys) ResolveAsync(ctx context.Context, name string, opts ...nsop
 }
 
 func (m mockNamesys) Publish(ctx context.Context, name ci.PrivKey, value path.Path) error {
-	return errors.New("not implemented for mockNamesys")
+	id, err := peer.IDFromPrivateKey(name)
+	if err != nil {
+		return err
+	}
+	m["/ipns/"+iface.FormatKeyID(id)] = value
+	return nil
 }
 
 func (m mockNamesys) PublishWithEOL(ctx context.Context, name ci.PrivKey, value path.Path, _ time.Time) error {
-	return errors.New("not implemented for mockNamesys")
+	return m.Publish(ctx, name, value)
 }
 
 func (m mockNamesys) GetResolver(subs string) (namesys.Resolver, bool) {
@@ -89,6 +108,7 @@ func newNodeWithMockNamesys(ns mockNamesys) (*core.IpfsNode, error) {
 	r := &repo.Mock{
 		C: c,
 		D: syncds.MutexWrap(datastore.NewMapDatastore()),
+		K: keystore.NewMemKeystore(),
 	}
 	n, err := core.NewNode(context.Background(), &core.BuildCfg{Repo: r})
 	if err != nil {
@@ -686,6 +706,471 @@ func TestCacheControlImmutable(t *testing.T) {
 	}
 }
 
+func TestGatewayFormats(t *testing.T) {
+	ts, api, ctx := newTestServerAndNode(t, nil)
+	t.Logf("test server url: %s", ts.URL)
+
+	dir := files.NewMapDirectory(map[string]files.Node{
+		"file": files.NewBytesFile([]byte("fnord")),
+	})
+	root, err := api.Unixfs().Add(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := api.ResolvePath(ctx, ipath.Join(root, "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileBlock, err := api.Block().Get(ctx, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileData, err := ioutil.ReadAll(fileBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(t *testing.T, urlPath string, header http.Header) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+urlPath, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		res, err := doWithoutRedirect(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { res.Body.Close() })
+		return res
+	}
+
+	for _, test := range []struct {
+		name, query string
+		header      http.Header
+	}{
+		{name: "raw query", query: "?format=raw"},
+		{name: "raw header", header: http.Header{"Accept": {rawContentType}}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			res := get(t, root.String()+"/file"+test.query, test.header)
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("status is %d, expected 200", res.StatusCode)
+			}
+			if ctype := res.Header.Get("Content-Type"); ctype != rawContentType {
+				t.Errorf("unexpected Content-Type: %s", ctype)
+			}
+			if etag, expected := res.Header.Get("Etag"), `"`+file.Cid().String()+`.raw"`; etag != expected {
+				t.Errorf("unexpected Etag %s, expected %s", etag, expected)
+			}
+			if !strings.Contains(res.Header.Get("Cache-Control"), "immutable") {
+				t.Errorf("expected immutable Cache-Control for /ipfs block")
+			}
+			body, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != string(fileData) {
+				t.Errorf("block does not match")
+			}
+
+			cached := get(t, root.String()+"/file"+test.query,
+				http.Header{"Accept": test.header["Accept"], "If-None-Match": {res.Header.Get("Etag")}})
+			if cached.StatusCode != http.StatusNotModified {
+				t.Errorf("status is %d, expected 304", cached.StatusCode)
+			}
+		})
+	}
+
+	for _, test := range []struct {
+		name, query string
+		header      http.Header
+	}{
+		{name: "car query", query: "?format=car"},
+		{name: "car header", header: http.Header{"Accept": {carContentType}}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			res := get(t, root.String()+test.query, test.header)
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("status is %d, expected 200", res.StatusCode)
+			}
+			if ctype := res.Header.Get("Content-Type"); !strings.HasPrefix(ctype, carContentType) {
+				t.Errorf("unexpected Content-Type: %s", ctype)
+			}
+			car, err := gocar.NewCarReader(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(car.Header.Roots) != 1 || !car.Header.Roots[0].Equals(root.Cid()) {
+				t.Errorf("unexpected roots %v, expected %s", car.Header.Roots, root.Cid())
+			}
+			seen := make(map[string]bool)
+			for {
+				block, err := car.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				seen[block.Cid().String()] = true
+			}
+			for _, c := range []string{root.Cid().String(), file.Cid().String()} {
+				if !seen[c] {
+					t.Errorf("archive is missing block %s", c)
+				}
+			}
+		})
+	}
+
+	if res := get(t, root.String()+"?format=unknown", nil); res.StatusCode != http.StatusBadRequest {
+		t.Errorf("status is %d, expected 400 for unknown format", res.StatusCode)
+	}
+}
+
+func TestGatewayArchiveDownload(t *testing.T) {
+	ts, api, ctx := newTestServerAndNode(t, nil)
+	t.Logf("test server url: %s", ts.URL)
+
+	dir := files.NewMapDirectory(map[string]files.Node{
+		"file": files.NewBytesFile([]byte("fnord")),
+		"sub": files.NewMapDirectory(map[string]files.Node{
+			"link": files.NewLinkFile("../file", nil),
+		}),
+	})
+	root, err := api.Unixfs().Add(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type entry struct {
+		mode    os.FileMode
+		content string
+	}
+	expected := map[string]entry{
+		"data":          {mode: os.ModeDir | 0777},
+		"data/file":     {mode: 0644, content: "fnord"},
+		"data/sub":      {mode: os.ModeDir | 0777},
+		"data/sub/link": {mode: os.ModeSymlink | 0777, content: "../file"},
+	}
+
+	readTar := func(t *testing.T, body io.Reader) map[string]entry {
+		entries := make(map[string]entry)
+		tr := tar.NewReader(body)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return entries
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			content := header.Linkname
+			if header.Typeflag == tar.TypeReg {
+				data, err := ioutil.ReadAll(tr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				content = string(data)
+			}
+			entries[header.Name] = entry{mode: header.FileInfo().Mode(), content: content}
+		}
+	}
+	readZip := func(t *testing.T, body io.Reader) map[string]entry {
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries := make(map[string]entry)
+		for _, file := range zr.File {
+			var content string
+			if !file.Mode().IsDir() {
+				rc, err := file.Open()
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, err := ioutil.ReadAll(rc)
+				rc.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				content = string(data)
+			}
+			entries[strings.TrimSuffix(file.Name, "/")] = entry{mode: file.Mode(), content: content}
+		}
+		return entries
+	}
+
+	for _, test := range []struct {
+		format, contentType string
+		read                func(*testing.T, io.Reader) map[string]entry
+	}{
+		{"tar", "application/x-tar", readTar},
+		{"zip", "application/zip", readZip},
+	} {
+		t.Run(test.format, func(t *testing.T) {
+			// names are reduced to their last component, so that entries remain within the archive's root
+			for _, filename := range []string{"data", "../../data", "/tmp/data"} {
+				res, err := http.Get(ts.URL + root.String() + "?download=" + test.format + "&filename=" + url.QueryEscape(filename))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.StatusCode != http.StatusOK {
+					res.Body.Close()
+					t.Fatalf("status is %d, expected 200", res.StatusCode)
+				}
+				if ctype := res.Header.Get("Content-Type"); ctype != test.contentType {
+					t.Errorf("unexpected Content-Type: %s", ctype)
+				}
+				if disposition := res.Header.Get("Content-Disposition"); !strings.Contains(disposition, `filename="data.`+test.format+`"`) {
+					t.Errorf("unexpected Content-Disposition: %s", disposition)
+				}
+
+				entries := test.read(t, res.Body)
+				res.Body.Close()
+				if !reflect.DeepEqual(entries, expected) {
+					t.Errorf("%s: archive entries do not match\n\twanted: %v\n\tgot: %v", filename, expected, entries)
+				}
+			}
+		})
+	}
+
+	// entries that would be placed outside of their directory end the archive
+	for name, format := range archiveFormats {
+		for _, entryName := range []string{"..", "../escape", "sub/entry"} {
+			dir := files.NewMapDirectory(map[string]files.Node{
+				entryName: files.NewBytesFile([]byte("fnord")),
+			})
+			err := format.write(ioutil.Discard, checkedDirectory{dir}, "data")
+			if !errors.Is(err, errArchiveEntryName) {
+				t.Errorf("%s: expected entry %q to be rejected, got: %v", name, entryName, err)
+			}
+		}
+	}
+}
+
+// recordingNamesys keeps the records of the values it publishes in the datastore,
+// as the node's name system does
+type recordingNamesys struct {
+	mockNamesys
+	records datastore.Datastore
+	seq     uint64
+}
+
+func (rn *recordingNamesys) Publish(ctx context.Context, name ci.PrivKey, value path.Path) error {
+	return rn.PublishWithEOL(ctx, name, value, time.Now().Add(time.Hour))
+}
+
+func (rn *recordingNamesys) PublishWithEOL(ctx context.Context, name ci.PrivKey, value path.Path, eol time.Time) error {
+	id, err := peer.IDFromPrivateKey(name)
+	if err != nil {
+		return err
+	}
+	rn.seq++
+	entry, err := ipns.Create(name, []byte(value), rn.seq, eol)
+	if err != nil {
+		return err
+	}
+	record, err := proto.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := rn.records.Put(namesys.IpnsDsKey(id), record); err != nil {
+		return err
+	}
+	return rn.mockNamesys.Publish(ctx, name, value)
+}
+
+func TestWritableIPNS(t *testing.T) {
+	ns := mockNamesys{}
+	n, err := newNodeWithMockNamesys(ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Namesys = &recordingNamesys{mockNamesys: ns, records: n.Repo.Datastore()}
+	api, err := coreapi.NewCoreAPI(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := n.Context()
+
+	writable, err := api.Key().Generate(ctx, "writable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	readOnly, err := api.Key().Generate(ctx, "read-only")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(newGatewayHandler(GatewayConfig{
+		Writable:     true,
+		WritableKeys: []string{"writable"},
+	}, api, n.Repo.Datastore()))
+	t.Cleanup(func() { ts.Close() })
+
+	do := func(t *testing.T, method, urlPath, body string) *http.Response {
+		req, err := http.NewRequest(method, ts.URL+urlPath, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := doWithoutRedirect(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { res.Body.Close() })
+		return res
+	}
+	expectContent := func(t *testing.T, p, expected string) {
+		file, err := api.Unixfs().Get(ctx, ipath.New(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		data, err := ioutil.ReadAll(files.ToFile(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Errorf("%s: content does not match\n\twanted: %q\n\tgot: %q", p, expected, data)
+		}
+	}
+
+	keyPath := writable.Path().String()
+
+	// the key has no value yet; writes start from an empty directory
+	res := do(t, http.MethodPut, keyPath+"/dir/file", "fnord")
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("status is %d, expected 201", res.StatusCode)
+	}
+	var entry struct{ Name, Value string }
+	if err := json.NewDecoder(res.Body).Decode(&entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Value != ipfsPathPrefix+res.Header.Get("IPFS-Hash") {
+		t.Errorf("published value %s does not match the new root %s", entry.Value, res.Header.Get("IPFS-Hash"))
+	}
+	if location := res.Header.Get("Location"); !strings.HasSuffix(location, "/dir/file") {
+		t.Errorf("unexpected Location: %s", location)
+	}
+	expectContent(t, keyPath+"/dir/file", "fnord")
+
+	// subsequent writes apply to the published value
+	if res := do(t, http.MethodPost, keyPath+"/other", "other"); res.StatusCode != http.StatusCreated {
+		t.Fatalf("status is %d, expected 201", res.StatusCode)
+	}
+	expectContent(t, keyPath+"/dir/file", "fnord")
+	expectContent(t, keyPath+"/other", "other")
+
+	if res := do(t, http.MethodDelete, keyPath+"/dir/file", ""); res.StatusCode != http.StatusCreated {
+		t.Fatalf("status is %d, expected 201", res.StatusCode)
+	}
+	if _, err := api.ResolvePath(ctx, ipath.New(keyPath+"/dir/file")); err == nil {
+		t.Error("file still exists after it was deleted")
+	}
+	expectContent(t, keyPath+"/other", "other")
+
+	// keys outside of the allowlist, and keys not held by the node, are not writable
+	if res := do(t, http.MethodPut, readOnly.Path().String()+"/file", "fnord"); res.StatusCode != http.StatusForbidden {
+		t.Errorf("status is %d, expected 403 for a key outside of the allowlist", res.StatusCode)
+	}
+	_, foreignKey, err := ci.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreignID, err := peer.IDFromPublicKey(foreignKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := do(t, http.MethodPut, "/ipns/"+foreignID.Pretty()+"/file", "fnord"); res.StatusCode != http.StatusForbidden {
+		t.Errorf("status is %d, expected 403 for a key not held by the node", res.StatusCode)
+	}
+
+	// the key's value is read from its record, not resolved through the name system
+	ns[keyPath] = path.Path(emptyDir)
+	res = do(t, http.MethodPut, keyPath+"/third", "third")
+	if res.StatusCode != http.StatusCreated {
+		t.Fatalf("status is %d, expected 201", res.StatusCode)
+	}
+	newRoot := ipfsPathPrefix + res.Header.Get("IPFS-Hash")
+	expectContent(t, newRoot+"/other", "other")
+	expectContent(t, newRoot+"/third", "third")
+
+	// without an allowlist, every held key is writable, and an empty allowlist denies them all
+	for _, test := range []struct {
+		writableKeys []string
+		status       int
+	}{
+		{nil, http.StatusCreated},
+		{[]string{}, http.StatusForbidden},
+	} {
+		ts := httptest.NewServer(newGatewayHandler(GatewayConfig{
+			Writable:     true,
+			WritableKeys: test.writableKeys,
+		}, api, n.Repo.Datastore()))
+		req, err := http.NewRequest(http.MethodPut, ts.URL+readOnly.Path().String()+"/file", strings.NewReader("fnord"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := doWithoutRedirect(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		ts.Close()
+		if res.StatusCode != test.status {
+			t.Errorf("allowlist %#v: status is %d, expected %d", test.writableKeys, res.StatusCode, test.status)
+		}
+	}
+}
+
+// configRepo is a `Repo` that only provides its (raw) config
+type configRepo struct {
+	repo.Repo
+	config map[string]interface{}
+}
+
+func (cr configRepo) GetConfigKey(key string) (interface{}, error) {
+	value, ok := cr.config[key]
+	if !ok {
+		return nil, fmt.Errorf("%s key has no attributes", key)
+	}
+	return value, nil
+}
+
+func TestGatewayWritableKeys(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		config   map[string]interface{}
+		expected []string
+		err      bool
+	}{
+		{"unset", map[string]interface{}{"Gateway": map[string]interface{}{}}, nil, false},
+		{"null", map[string]interface{}{"Gateway": map[string]interface{}{"WritableKeys": nil}}, nil, false},
+		{"empty", map[string]interface{}{"Gateway": map[string]interface{}{
+			"WritableKeys": []interface{}{},
+		}}, []string{}, false},
+		{"set", map[string]interface{}{"Gateway": map[string]interface{}{
+			"WritableKeys": []interface{}{"self", "other"},
+		}}, []string{"self", "other"}, false},
+		{"invalid", map[string]interface{}{"Gateway": map[string]interface{}{"WritableKeys": "self"}}, nil, true},
+		{"unreadable", map[string]interface{}{}, nil, true},
+	} {
+		keys, err := gatewayWritableKeys(configRepo{config: test.config})
+		if (err != nil) != test.err {
+			t.Errorf("%s: expected error: %t, got: %v", test.name, test.err, err)
+		}
+		if !reflect.DeepEqual(keys, test.expected) {
+			t.Errorf("%s: expected keys %v, got %v", test.name, test.expected, keys)
+		}
+	}
+}
+
 func TestGoGetSupport(t *testing.T) {
 	ts, _, _ := newTestServerAndNode(t, nil)
 	t.Logf("test server url: %s", ts.URL)
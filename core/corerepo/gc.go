@@ -88,7 +88,7 @@ func GarbageCollect(n *core.IpfsNode, ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	rmed := gc.GC(ctx, n.Blockstore, n.Repo.Datastore(), n.Pinning, roots)
+	rmed := n.GCIndex.Collect(ctx, n.Blockstore, n.Repo.Datastore(), n.Pinning, roots)
 
 	return CollectResult(ctx, rmed, nil)
 }
@@ -107,6 +107,9 @@ loop:
 			}
 			if res.Error != nil {
 				errors = append(errors, res.Error)
+			} else if res.Progress != nil {
+				log.Debugf("GC %s: %d marked, %d scanned, %d removed", res.Progress.Phase,
+					res.Progress.Marked, res.Progress.Scanned, res.Progress.Removed)
 			} else if res.KeyRemoved.Defined() && cb != nil {
 				cb(res.KeyRemoved)
 			}
@@ -156,7 +159,32 @@ func GarbageCollectAsync(n *core.IpfsNode, ctx context.Context) <-chan gc.Result
 		return out
 	}
 
-	return gc.GC(ctx, n.Blockstore, n.Repo.Datastore(), n.Pinning, roots)
+	return n.GCIndex.Collect(ctx, n.Blockstore, n.Repo.Datastore(), n.Pinning, roots)
+}
+
+// GarbageCollectDryRun reports the blocks that a garbage collection would remove,
+// without removing them.
+func GarbageCollectDryRun(n *core.IpfsNode, ctx context.Context) <-chan gc.Result {
+	roots, err := BestEffortRoots(n.FilesRoot)
+	if err != nil {
+		out := make(chan gc.Result, 1)
+		out <- gc.Result{Error: err}
+		close(out)
+		return out
+	}
+
+	return n.GCIndex.DryRun(ctx, n.Blockstore, n.Pinning, roots)
+}
+
+// ExplainRetention reports the roots that keep the block from being garbage collected.
+// (The MFS root is the only best-effort root.)
+func ExplainRetention(ctx context.Context, n *core.IpfsNode, c cid.Cid) ([]gc.Retention, error) {
+	roots, err := BestEffortRoots(n.FilesRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.GCIndex.Explain(ctx, n.Blockstore, n.Pinning, roots, c)
 }
 
 func PeriodicGC(ctx context.Context, node *core.IpfsNode) error {
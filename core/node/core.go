@@ -24,6 +24,7 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/ipfs/go-ipfs/core/node/helpers"
+	"github.com/ipfs/go-ipfs/gc"
 	"github.com/ipfs/go-ipfs/repo"
 )
 
@@ -40,8 +41,13 @@ func BlockService(lc fx.Lifecycle, bs blockstore.Blockstore, rem exchange.Interf
 	return bsvc
 }
 
+// GCIndex loads the index of reachable blocks used by incremental garbage collection
+func GCIndex(repo repo.Repo, bs blockstore.GCBlockstore) (*gc.Index, error) {
+	return gc.NewIndex(repo.Datastore(), bs)
+}
+
 // Pinning creates new pinner which tells GC which blocks should be kept
-func Pinning(bstore blockstore.Blockstore, ds format.DAGService, repo repo.Repo) (pin.Pinner, error) {
+func Pinning(bstore blockstore.Blockstore, ds format.DAGService, repo repo.Repo, index *gc.Index) (pin.Pinner, error) {
 	rootDS := repo.Datastore()
 
 	syncFn := func() error {
@@ -60,7 +66,7 @@ func Pinning(bstore blockstore.Blockstore, ds format.DAGService, repo repo.Repo)
 		return nil, err
 	}
 
-	return pinning, nil
+	return gc.IndexPinner(pinning, index), nil
 }
 
 var (
@@ -103,7 +109,7 @@ func OnlineExchange(provide bool) interface{} {
 }
 
 // Files loads persisted MFS root
-func Files(mctx helpers.MetricsCtx, lc fx.Lifecycle, repo repo.Repo, dag format.DAGService) (*mfs.Root, error) {
+func Files(mctx helpers.MetricsCtx, lc fx.Lifecycle, repo repo.Repo, dag format.DAGService, index *gc.Index) (*mfs.Root, error) {
 	dsk := datastore.NewKey("/local/filesroot")
 	pf := func(ctx context.Context, c cid.Cid) error {
 		rootDS := repo.Datastore()
@@ -117,7 +123,13 @@ func Files(mctx helpers.MetricsCtx, lc fx.Lifecycle, repo repo.Repo, dag format.
 		if err := rootDS.Put(dsk, c.Bytes()); err != nil {
 			return err
 		}
-		return rootDS.Sync(dsk)
+		if err := rootDS.Sync(dsk); err != nil {
+			return err
+		}
+
+		// (a running collection must not sweep the new root)
+		index.BestEffortRootAdded(c)
+		return nil
 	}
 
 	var nd *merkledag.ProtoNode
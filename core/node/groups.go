@@ -292,6 +292,7 @@ var Core = fx.Options(
 	fx.Provide(BlockService),
 	fx.Provide(Dag),
 	fx.Provide(resolver.NewBasicResolver),
+	fx.Provide(GCIndex),
 	fx.Provide(Pinning),
 	fx.Provide(Files),
 )
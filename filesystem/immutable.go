@@ -0,0 +1,8 @@
+package filesystem
+
+// Immutable may optionally be implemented by an `Interface`,
+// to report which of its paths refer to content that can never change.
+// (e.g. content addressed paths; which callers may cache indefinitely)
+type Immutable interface {
+	IsImmutable(path string) bool
+}
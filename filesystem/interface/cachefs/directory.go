@@ -0,0 +1,121 @@
+package cachefs
+
+import (
+	"context"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+)
+
+// OpenDirectory returns a listing from the cache if present,
+// otherwise it relays the underlying listing, and caches it (if it's listed to completion).
+func (ci *cacheInterface) OpenDirectory(path string) (filesystem.Directory, error) {
+	stream := &cachedDirectoryStream{cacheInterface: ci, path: path}
+	if _, cached := ci.listings.get(path); !cached {
+		directory, err := ci.Interface.OpenDirectory(path)
+		if err != nil {
+			return nil, err
+		}
+		stream.directory = directory
+	}
+
+	directory, err := interfaceutils.UpgradePartialStream(interfaceutils.NewPartialStream(ci.ctx, stream))
+	if err != nil {
+		stream.Close()
+		return nil, err
+	}
+	return &cachedDirectory{Directory: directory, stream: stream}, nil
+}
+
+type (
+	// cachedDirectoryStream sends entries from the cache (if present),
+	// otherwise from the underlying directory.
+	cachedDirectoryStream struct {
+		*cacheInterface
+		path      string
+		directory filesystem.Directory // opened on demand
+		listed    bool                 // the underlying directory was listed (and must be reset before listing again)
+	}
+
+	cachedDirectory struct {
+		filesystem.Directory
+		stream *cachedDirectoryStream
+	}
+
+	nameEntry string
+)
+
+func (ne nameEntry) Name() string { return string(ne) }
+func (nameEntry) Error() error    { return nil }
+
+func (cd *cachedDirectory) Close() error {
+	err := cd.Directory.Close()
+	if sErr := cd.stream.Close(); err == nil {
+		err = sErr
+	}
+	return err
+}
+
+func (cs *cachedDirectoryStream) Close() error {
+	if cs.directory == nil {
+		return nil
+	}
+	return cs.directory.Close()
+}
+
+func (cs *cachedDirectoryStream) SendTo(ctx context.Context, receiver chan<- interfaceutils.PartialEntry) error {
+	if value, ok := cs.listings.get(cs.path); ok {
+		go sendNames(ctx, value.([]string), receiver)
+		return nil
+	}
+
+	if cs.directory == nil { // the cache expired after we were opened
+		directory, err := cs.Interface.OpenDirectory(cs.path)
+		if err != nil {
+			close(receiver)
+			return err
+		}
+		cs.directory = directory
+	} else if cs.listed {
+		if err := cs.directory.Reset(); err != nil {
+			close(receiver)
+			return err
+		}
+	}
+	cs.listed = true
+
+	go cs.record(ctx, cs.directory.List(ctx, 0), receiver)
+	return nil
+}
+
+// record relays the entries to the receiver,
+// and stores their names if the end of the listing is reached.
+func (cs *cachedDirectoryStream) record(ctx context.Context, entries <-chan filesystem.DirectoryEntry, receiver chan<- interfaceutils.PartialEntry) {
+	defer close(receiver)
+	var names []string
+	for entry := range entries {
+		select {
+		case receiver <- entry:
+		case <-ctx.Done():
+			return
+		}
+		if entry.Error() != nil {
+			return
+		}
+		names = append(names, entry.Name())
+	}
+	if ctx.Err() == nil {
+		cs.listings.add(cs.path, names, cs.expiry(cs.path))
+	}
+}
+
+func sendNames(ctx context.Context, names []string, receiver chan<- interfaceutils.PartialEntry) {
+	defer close(receiver)
+	for _, name := range names {
+		select {
+		case receiver <- nameEntry(name):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
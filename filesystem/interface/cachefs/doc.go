@@ -0,0 +1,9 @@
+// Package cachefs provides a constructor to a `filesystem.Interface`,
+// which wraps another `filesystem.Interface`, caching the results of its metadata requests.
+//
+// The results of `Info`, `ExtractLink`, and `OpenDirectory` listings are retained.
+// Paths that the wrapped system reports as immutable (see `filesystem.Immutable`) are retained indefinitely,
+// all others are retained until their time-to-live expires.
+// Modifications made through the wrapper invalidate the cache for the paths they modify.
+// (modifications made outside of the wrapper are only observed after the time-to-live expires)
+package cachefs
@@ -0,0 +1,163 @@
+package cachefs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	fserrors "github.com/ipfs/go-ipfs/filesystem/errors"
+)
+
+const (
+	// DefaultTTL is how long the results for mutable paths are retained by default
+	DefaultTTL = 5 * time.Second
+	// DefaultSize is the default maximum number of paths retained (per type of request)
+	DefaultSize = 4096
+)
+
+// ParseTTL parses the string form of a time-to-live (e.g. "500ms", "10s").
+func ParseTTL(ttl string) (time.Duration, error) {
+	duration, err := time.ParseDuration(ttl)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cache time-to-live %q: %w", ttl, err)
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("invalid cache time-to-live %q: duration must be positive", ttl)
+	}
+	return duration, nil
+}
+
+// Option alters the construction of the cache.
+type Option func(*cacheInterface)
+
+// WithTTL sets how long the results for mutable paths are retained.
+func WithTTL(ttl time.Duration) Option { return func(ci *cacheInterface) { ci.ttl = ttl } }
+
+// WithSize sets the maximum number of paths retained (per type of request).
+func WithSize(size int) Option { return func(ci *cacheInterface) { ci.size = size } }
+
+type cacheInterface struct {
+	filesystem.Interface
+	ctx  context.Context
+	ttl  time.Duration
+	size int
+
+	infos, links, listings *pathCache
+}
+
+// NewInterface wraps the system with a cache.
+// The wrapper only implements the syncing and storage extensions if the wrapped system does.
+func NewInterface(ctx context.Context, fs filesystem.Interface, options ...Option) (filesystem.Interface, error) {
+	ci := &cacheInterface{
+		Interface: fs,
+		ctx:       ctx,
+		ttl:       DefaultTTL,
+		size:      DefaultSize,
+	}
+	for _, option := range options {
+		option(ci)
+	}
+
+	var err error
+	if ci.infos, err = newPathCache(ci.size); err != nil {
+		return nil, err
+	}
+	if ci.links, err = newPathCache(ci.size); err != nil {
+		return nil, err
+	}
+	if ci.listings, err = newPathCache(ci.size); err != nil {
+		return nil, err
+	}
+	return ci.withExtensions(), nil
+}
+
+// expiry returns when a result for the path should expire (the zero value for never)
+func (ci *cacheInterface) expiry(path string) time.Time {
+	if immutable, ok := ci.Interface.(filesystem.Immutable); ok && immutable.IsImmutable(path) {
+		return time.Time{}
+	}
+	return time.Now().Add(ci.ttl)
+}
+
+// invalidate drops cached results for the path, its parent, and its descendants.
+func (ci *cacheInterface) invalidate(path string) {
+	for _, cache := range []*pathCache{ci.infos, ci.links, ci.listings} {
+		cache.invalidate(path)
+	}
+}
+
+// invalidateEntry drops cached results for the path and its parent only.
+func (ci *cacheInterface) invalidateEntry(path string) {
+	for _, cache := range []*pathCache{ci.infos, ci.links, ci.listings} {
+		cache.invalidateEntry(path)
+	}
+}
+
+func (ci *cacheInterface) Info(path string, req filesystem.StatRequest) (*filesystem.Stat, filesystem.StatRequest, error) {
+	if value, ok := ci.infos.get(path); ok {
+		cached := value.(*infoResult)
+		if cached.err != nil {
+			return nil, filesystem.StatRequest{}, cached.err
+		}
+		if covers(cached.filled, req) {
+			stat := *cached.stat // callers may modify their copy
+			return &stat, cached.filled, nil
+		}
+		req = union(cached.filled, req) // fetch what we had and what's needed now
+	}
+
+	stat, filled, err := ci.Interface.Info(path, req)
+	switch {
+	case err == nil:
+		cached := *stat
+		ci.infos.add(path, &infoResult{stat: &cached, filled: filled}, ci.expiry(path))
+	case isNotExist(err): // negative results always expire
+		ci.infos.add(path, &infoResult{err: err}, time.Now().Add(ci.ttl))
+	}
+	return stat, filled, err
+}
+
+func (ci *cacheInterface) ExtractLink(path string) (string, error) {
+	if value, ok := ci.links.get(path); ok {
+		return value.(string), nil
+	}
+	target, err := ci.Interface.ExtractLink(path)
+	if err == nil {
+		ci.links.add(path, target, ci.expiry(path))
+	}
+	return target, err
+}
+
+type infoResult struct {
+	stat   *filesystem.Stat
+	filled filesystem.StatRequest
+	err    error
+}
+
+// covers returns true if `filled` contains every field in `req`
+func covers(filled, req filesystem.StatRequest) bool {
+	return (filled.Type || !req.Type) &&
+		(filled.Size || !req.Size) &&
+		(filled.Blocks || !req.Blocks) &&
+		(filled.Mode || !req.Mode) &&
+		(filled.MTime || !req.MTime) &&
+		(filled.Links || !req.Links)
+}
+
+func union(a, b filesystem.StatRequest) filesystem.StatRequest {
+	return filesystem.StatRequest{
+		Type:   a.Type || b.Type,
+		Size:   a.Size || b.Size,
+		Blocks: a.Blocks || b.Blocks,
+		Mode:   a.Mode || b.Mode,
+		MTime:  a.MTime || b.MTime,
+		Links:  a.Links || b.Links,
+	}
+}
+
+func isNotExist(err error) bool {
+	var fsErr fserrors.Error
+	return errors.As(err, &fsErr) && fsErr.Kind() == fserrors.NotExist
+}
@@ -0,0 +1,213 @@
+package cachefs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+)
+
+// countingFS is a directory containing a single file; it counts the requests made of it
+type countingFS struct {
+	immutable       bool
+	infos, listings int
+}
+
+func (*countingFS) ID() filesystem.ID                  { return filesystem.IPFS }
+func (*countingFS) Close() error                       { return nil }
+func (cf *countingFS) IsImmutable(string) bool         { return cf.immutable }
+func (*countingFS) ExtractLink(string) (string, error) { return "", iferrors.UnsupportedRequest() }
+func (*countingFS) Make(string) error                  { return nil }
+func (*countingFS) MakeDirectory(string) error         { return nil }
+func (*countingFS) MakeLink(string, string) error      { return nil }
+func (*countingFS) Remove(string) error                { return nil }
+func (*countingFS) RemoveDirectory(string) error       { return nil }
+func (*countingFS) RemoveLink(string) error            { return nil }
+func (*countingFS) Rename(string, string) error        { return nil }
+func (*countingFS) Open(path string, _ filesystem.IOFlags) (filesystem.File, error) {
+	return new(discardFile), nil
+}
+
+// syncingFS is a `countingFS` which implements the syncing extension
+type syncingFS struct{ countingFS }
+
+func (*syncingFS) Sync() error { return nil }
+
+// discardFile accepts writes and discards them
+// (the methods it doesn't provide will panic)
+type discardFile struct{ filesystem.File }
+
+func (*discardFile) Write(buff []byte) (int, error) { return len(buff), nil }
+func (*discardFile) Close() error                   { return nil }
+
+func (cf *countingFS) Info(path string, _ filesystem.StatRequest) (*filesystem.Stat, filesystem.StatRequest, error) {
+	cf.infos++
+	stat := &filesystem.Stat{Type: coreiface.TFile}
+	if path == "/" {
+		stat.Type = coreiface.TDirectory
+	}
+	return stat, filesystem.StatRequest{Type: true}, nil
+}
+
+func (cf *countingFS) OpenDirectory(string) (filesystem.Directory, error) {
+	cf.listings++
+	return new(singleEntryDirectory), nil
+}
+
+type (
+	singleEntryDirectory struct{}
+	singleEntry          struct{}
+)
+
+func (singleEntry) Name() string   { return "file" }
+func (singleEntry) Offset() uint64 { return 1 }
+func (singleEntry) Error() error   { return nil }
+
+func (*singleEntryDirectory) Reset() error { return nil }
+func (*singleEntryDirectory) Close() error { return nil }
+func (*singleEntryDirectory) List(context.Context, uint64) <-chan filesystem.DirectoryEntry {
+	entries := make(chan filesystem.DirectoryEntry, 1)
+	entries <- singleEntry{}
+	close(entries)
+	return entries
+}
+
+func TestCache(t *testing.T) {
+	const ttl = 50 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newCache := func(t *testing.T, immutable bool) (filesystem.Interface, *countingFS) {
+		counter := &countingFS{immutable: immutable}
+		fs, err := NewInterface(ctx, counter, WithTTL(ttl))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fs, counter
+	}
+	info := func(t *testing.T, fs filesystem.Interface) {
+		if _, _, err := fs.Info("/file", filesystem.StatRequest{Type: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("immutable", func(t *testing.T) {
+		fs, counter := newCache(t, true)
+		info(t, fs)
+		time.Sleep(ttl * 2)
+		info(t, fs)
+		if counter.infos != 1 {
+			t.Errorf("expected immutable path to be cached indefinitely, got %d requests", counter.infos)
+		}
+	})
+
+	t.Run("mutable", func(t *testing.T) {
+		fs, counter := newCache(t, false)
+		info(t, fs)
+		info(t, fs)
+		if counter.infos != 1 {
+			t.Errorf("expected mutable path to be cached within its TTL, got %d requests", counter.infos)
+		}
+		time.Sleep(ttl * 2)
+		info(t, fs)
+		if counter.infos != 2 {
+			t.Errorf("expected mutable path to expire after its TTL, got %d requests", counter.infos)
+		}
+	})
+
+	t.Run("invalidation", func(t *testing.T) {
+		fs, counter := newCache(t, true)
+		info(t, fs)
+		if err := fs.Remove("/file"); err != nil {
+			t.Fatal(err)
+		}
+		info(t, fs)
+		if counter.infos != 2 {
+			t.Errorf("expected modification to invalidate the cache, got %d requests", counter.infos)
+		}
+	})
+
+	t.Run("writes", func(t *testing.T) {
+		fs, counter := newCache(t, true)
+		info(t, fs)
+		file, err := fs.Open("/file", filesystem.IOWriteOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte("data")); err != nil {
+			t.Fatal(err)
+		}
+		info(t, fs)
+		if counter.infos != 2 {
+			t.Errorf("expected write to invalidate the cache, got %d requests", counter.infos)
+		}
+		if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+		info(t, fs)
+		if counter.infos != 3 {
+			t.Errorf("expected close to invalidate the cache, got %d requests", counter.infos)
+		}
+	})
+
+	t.Run("extensions", func(t *testing.T) {
+		for _, test := range []struct {
+			name    string
+			fs      filesystem.Interface
+			syncing bool
+		}{
+			{"without syncing", new(countingFS), false},
+			{"with syncing", new(syncingFS), true},
+		} {
+			fs, err := NewInterface(ctx, test.fs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := fs.(filesystem.Syncer); ok != test.syncing {
+				t.Errorf("%s: expected wrapper to implement syncing: %t, got: %t", test.name, test.syncing, ok)
+			}
+			if _, ok := fs.(filesystem.StorageStater); ok {
+				t.Errorf("%s: wrapper implements storage statistics, but the wrapped system does not", test.name)
+			}
+			file, err := fs.Open("/file", filesystem.IOWriteOnly)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := file.(filesystem.Syncer); ok {
+				t.Errorf("%s: file wrapper implements syncing, but the wrapped file does not", test.name)
+			}
+			if err := file.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		fs, counter := newCache(t, true)
+		for i := 0; i != 2; i++ {
+			directory, err := fs.OpenDirectory("/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for entry := range directory.List(ctx, 0) {
+				if err := entry.Error(); err != nil {
+					t.Fatal(err)
+				}
+				names = append(names, entry.Name())
+			}
+			if len(names) != 1 || names[0] != "file" {
+				t.Errorf("unexpected listing: %v", names)
+			}
+			if err := directory.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if counter.listings != 1 {
+			t.Errorf("expected directory listing to be cached, got %d requests", counter.listings)
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package cachefs
+
+import (
+	"time"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	ipld "github.com/ipfs/go-ipld-format"
+)
+
+// modifications invalidate the cache for the paths they modify (even if they fail)
+
+func (ci *cacheInterface) Make(path string) error {
+	defer ci.invalidate(path)
+	return ci.Interface.Make(path)
+}
+
+func (ci *cacheInterface) MakeDirectory(path string) error {
+	defer ci.invalidate(path)
+	return ci.Interface.MakeDirectory(path)
+}
+
+func (ci *cacheInterface) MakeLink(path, target string) error {
+	defer ci.invalidate(path)
+	return ci.Interface.MakeLink(path, target)
+}
+
+func (ci *cacheInterface) Remove(path string) error {
+	defer ci.invalidate(path)
+	return ci.Interface.Remove(path)
+}
+
+func (ci *cacheInterface) RemoveDirectory(path string) error {
+	defer ci.invalidate(path)
+	return ci.Interface.RemoveDirectory(path)
+}
+
+func (ci *cacheInterface) RemoveLink(path string) error {
+	defer ci.invalidate(path)
+	return ci.Interface.RemoveLink(path)
+}
+
+func (ci *cacheInterface) Rename(oldName, newName string) error {
+	defer ci.invalidate(newName)
+	defer ci.invalidate(oldName)
+	return ci.Interface.Rename(oldName, newName)
+}
+
+// Open returns files opened for writing wrapped, so that their modifications invalidate the cache
+func (ci *cacheInterface) Open(path string, flags filesystem.IOFlags) (filesystem.File, error) {
+	if flags&filesystem.IOCreate != 0 {
+		defer ci.invalidate(path)
+	}
+	file, err := ci.Interface.Open(path, flags)
+	if err != nil || !flags.Writable() {
+		return file, err
+	}
+	cached := &cachedFile{File: file, ci: ci, path: path}
+	if _, ok := file.(filesystem.Syncer); ok {
+		return &syncingFile{cached}, nil
+	}
+	return cached, nil
+}
+
+// cachedFile invalidates the file's own entry as it's written,
+// and everything beneath its path once it's closed
+type cachedFile struct {
+	filesystem.File
+	ci   *cacheInterface
+	path string
+}
+
+func (cf *cachedFile) Write(buff []byte) (int, error) {
+	defer cf.ci.invalidateEntry(cf.path)
+	return cf.File.Write(buff)
+}
+
+func (cf *cachedFile) Truncate(size uint64) error {
+	defer cf.ci.invalidateEntry(cf.path)
+	return cf.File.Truncate(size)
+}
+
+func (cf *cachedFile) Close() error {
+	defer cf.ci.invalidate(cf.path)
+	return cf.File.Close()
+}
+
+// syncingFile relays `Sync` to native files which support it
+type syncingFile struct{ *cachedFile }
+
+func (sf *syncingFile) Sync() error { return sf.File.(filesystem.Syncer).Sync() }
+
+// the (optional) extensions of the native system are relayed,
+// invalidating the cache where appropriate.
+// Those which hosts only ever probe for are reported as unsupported requests when the native system lacks them
+// (which hosts treat the same as a missing extension).
+// Syncing and storage statistics change how hosts respond when they're absent (e.g. flushes succeed and storage is reported as empty),
+// so the wrapper only implements them if the native system does.
+
+func (ci *cacheInterface) ExtendedAttributes(path string) (map[string]string, error) {
+	attributer, ok := ci.Interface.(filesystem.ExtendedAttributer)
+	if !ok {
+		return nil, iferrors.UnsupportedRequest()
+	}
+	return attributer.ExtendedAttributes(path)
+}
+
+func (ci *cacheInterface) Chmod(path string, mode uint32) error {
+	modifier, ok := ci.Interface.(filesystem.MetadataModifier)
+	if !ok {
+		return iferrors.UnsupportedRequest()
+	}
+	defer ci.invalidate(path)
+	return modifier.Chmod(path, mode)
+}
+
+func (ci *cacheInterface) Chtimes(path string, mtime time.Time) error {
+	modifier, ok := ci.Interface.(filesystem.MetadataModifier)
+	if !ok {
+		return iferrors.UnsupportedRequest()
+	}
+	defer ci.invalidate(path)
+	return modifier.Chtimes(path, mtime)
+}
+
+func (ci *cacheInterface) Node(path string) (ipld.Node, error) {
+	relinker, ok := ci.Interface.(filesystem.Relinker)
+	if !ok {
+		return nil, iferrors.UnsupportedRequest()
+	}
+	return relinker.Node(path)
+}
+
+func (ci *cacheInterface) Link(path string, node ipld.Node) error {
+	relinker, ok := ci.Interface.(filesystem.Relinker)
+	if !ok {
+		return iferrors.UnsupportedRequest()
+	}
+	defer ci.invalidate(path)
+	return relinker.Link(path, node)
+}
+
+func (ci *cacheInterface) Unlink(path string) error {
+	relinker, ok := ci.Interface.(filesystem.Relinker)
+	if !ok {
+		return iferrors.UnsupportedRequest()
+	}
+	defer ci.invalidate(path)
+	return relinker.Unlink(path)
+}
+
+type (
+	syncingCache        struct{ *cacheInterface }
+	statingCache        struct{ *cacheInterface }
+	syncingStatingCache struct{ *cacheInterface }
+)
+
+func (ci *cacheInterface) withExtensions() filesystem.Interface {
+	_, syncer := ci.Interface.(filesystem.Syncer)
+	_, stater := ci.Interface.(filesystem.StorageStater)
+	switch {
+	case syncer && stater:
+		return syncingStatingCache{ci}
+	case syncer:
+		return syncingCache{ci}
+	case stater:
+		return statingCache{ci}
+	default:
+		return ci
+	}
+}
+
+func (sc syncingCache) Sync() error { return sc.Interface.(filesystem.Syncer).Sync() }
+
+func (sc statingCache) StorageStat() (*filesystem.StorageStat, error) {
+	return sc.Interface.(filesystem.StorageStater).StorageStat()
+}
+
+func (sc syncingStatingCache) Sync() error { return sc.Interface.(filesystem.Syncer).Sync() }
+
+func (sc syncingStatingCache) StorageStat() (*filesystem.StorageStat, error) {
+	return sc.Interface.(filesystem.StorageStater).StorageStat()
+}
@@ -0,0 +1,60 @@
+package cachefs
+
+import (
+	gopath "path"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// pathCache stores values by path, until they expire
+type pathCache struct{ *lru.Cache }
+
+type pathEntry struct {
+	value   interface{}
+	expires time.Time // the zero value never expires
+}
+
+func newPathCache(size int) (*pathCache, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &pathCache{cache}, nil
+}
+
+func (pc *pathCache) get(path string) (interface{}, bool) {
+	value, ok := pc.Get(path)
+	if !ok {
+		return nil, false
+	}
+	entry := value.(*pathEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		pc.Remove(path)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (pc *pathCache) add(path string, value interface{}, expires time.Time) {
+	pc.Add(path, &pathEntry{value: value, expires: expires})
+}
+
+// invalidateEntry removes the path and its parent
+func (pc *pathCache) invalidateEntry(path string) {
+	pc.Remove(path)
+	pc.Remove(gopath.Dir(path))
+}
+
+// invalidate removes the path, its parent, and its descendants (if any)
+func (pc *pathCache) invalidate(path string) {
+	pc.invalidateEntry(path)
+
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	for _, key := range pc.Keys() {
+		if strings.HasPrefix(key.(string), prefix) {
+			pc.Remove(key)
+		}
+	}
+}
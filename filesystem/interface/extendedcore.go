@@ -3,8 +3,10 @@ package interfaceutils
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/ipfs/go-cid"
 	files "github.com/ipfs/go-ipfs-files"
 	"github.com/ipfs/go-ipfs/filesystem"
 	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
@@ -41,10 +43,19 @@ type CoreExtender interface {
 	Stat(context.Context, corepath.Path, filesystem.StatRequest) (*filesystem.Stat, filesystem.StatRequest, error)
 	// ExtractLink takes in a path to a link and returns the string it contains
 	ExtractLink(corepath.Path) (string, error)
+	// ExtendedAttributes returns the IPFS attributes for the node at path
+	// (see `filesystem.ExtendedAttributer`)
+	ExtendedAttributes(context.Context, corepath.Path) (map[string]string, error)
+	// StorageStat returns statistics about the node's storage
+	// (see `filesystem.StorageStater`)
+	StorageStat(context.Context) (*filesystem.StorageStat, error)
 }
 
 // TODO: docs
-type CoreExtended struct{ coreiface.CoreAPI }
+type CoreExtended struct {
+	coreiface.CoreAPI
+	repoStat repoStatCache
+}
 
 // TODO: docs
 func (core *CoreExtended) Stat(ctx context.Context, path corepath.Path, req filesystem.StatRequest) (*filesystem.Stat, filesystem.StatRequest, error) {
@@ -96,6 +107,35 @@ func (core *CoreExtended) ExtractLink(path corepath.Path) (string, error) {
 	return files.ToSymlink(linkNode).Target, nil
 }
 
+// ExtendedAttributes resolves the path, and returns the IPFS attributes of the node it references.
+func (core *CoreExtended) ExtendedAttributes(ctx context.Context, path corepath.Path) (map[string]string, error) {
+	resolved, err := core.ResolvePath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	ipldNode, err := core.ResolveNode(ctx, resolved)
+	if err != nil {
+		return nil, err
+	}
+	return NodeAttributes(ipldNode, path.String()), nil
+}
+
+// NodeAttributes returns the IPFS attributes of an IPLD node,
+// using `contentPath` as the path used to reach it.
+func NodeAttributes(ipldNode ipld.Node, contentPath string) map[string]string {
+	nodeCid := ipldNode.Cid()
+	codec, ok := cid.CodecToStr[nodeCid.Type()]
+	if !ok {
+		codec = fmt.Sprintf("0x%x", nodeCid.Type())
+	}
+	return map[string]string{
+		filesystem.XattrCID:    nodeCid.String(),
+		filesystem.XattrCodec:  codec,
+		filesystem.XattrBlocks: strconv.Itoa(len(ipldNode.Links())),
+		filesystem.XattrPath:   contentPath,
+	}
+}
+
 // ResolveNode wraps the core method, but uses our error type for the return.
 func (core *CoreExtended) ResolveNode(ctx context.Context, path corepath.Path) (ipld.Node, error) {
 	n, err := core.CoreAPI.ResolveNode(ctx, path)
@@ -176,11 +216,34 @@ func unixFSAttr(ufsNode *unixfs.FSNode, req filesystem.StatRequest) (*filesystem
 		attr.Size, filledAttrs.Size = ufsNode.FileSize(), true
 	}
 
-	// TODO [eventually]: handle time metadata in new UFS format standard
+	if req.Mode || req.MTime {
+		if err := ApplyUFSMetadata(ufsNode, &attr, &filledAttrs); err != nil {
+			return &attr, filledAttrs, err
+		}
+	}
 
 	return &attr, filledAttrs, nil
 }
 
+// ApplyUFSMetadata fills in the UnixFS 1.5 metadata fields that are present in the node.
+func ApplyUFSMetadata(ufsNode *unixfs.FSNode, attr *filesystem.Stat, filledAttrs *filesystem.StatRequest) error {
+	nodeData, err := ufsNode.GetBytes()
+	if err != nil {
+		return err
+	}
+	metadata, err := UFSMetadataFromBytes(nodeData)
+	if err != nil {
+		return err
+	}
+	if metadata.HasMode {
+		attr.Mode, filledAttrs.Mode = metadata.Mode, true
+	}
+	if metadata.HasMTime {
+		attr.MTime, filledAttrs.MTime = metadata.MTime, true
+	}
+	return nil
+}
+
 func unixfsTypeToCoreType(ut unixpb.Data_DataType) coreiface.FileType {
 	switch ut {
 	case unixpb.Data_Directory, unixpb.Data_HAMTShard:
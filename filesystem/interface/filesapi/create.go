@@ -0,0 +1,83 @@
+package filesapi
+
+import (
+	"errors"
+	"io"
+
+	fserrors "github.com/ipfs/go-ipfs/filesystem/errors"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/ipfs/go-unixfs"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// exists returns an error if the path exists, or if it can't be determined
+func (fi *filesInterface) exists(path string) error {
+	_, err := fi.stat(path)
+	var fsErr fserrors.Error
+	switch {
+	case err == nil:
+		return iferrors.Exist(path)
+	case errors.As(err, &fsErr) && fsErr.Kind() == fserrors.NotExist:
+		return nil
+	default:
+		return err
+	}
+}
+
+func (fi *filesInterface) Make(path string) error {
+	if err := fi.exists(path); err != nil {
+		return err
+	}
+
+	callCtx, cancel := interfaceutils.CallContext(fi.ctx)
+	defer cancel()
+	err := fi.api.Request("files/write", path).
+		Option("create", true).
+		FileBody(new(emptyReader)).
+		Exec(callCtx, nil)
+	if err != nil {
+		return filesErr(path, err)
+	}
+	return nil
+}
+
+func (fi *filesInterface) MakeDirectory(path string) error {
+	callCtx, cancel := interfaceutils.CallContext(fi.ctx)
+	defer cancel()
+	if err := fi.api.Request("files/mkdir", path).Exec(callCtx, nil); err != nil {
+		return filesErr(path, err)
+	}
+	return nil
+}
+
+// MakeLink constructs a UFS symlink node, adds it to the node's DAG service,
+// and copies it into MFS (the Files API has no native link command).
+func (fi *filesInterface) MakeLink(path, linkTarget string) error {
+	if err := fi.exists(path); err != nil {
+		return err
+	}
+
+	dagData, err := unixfs.SymlinkData(linkTarget)
+	if err != nil {
+		return iferrors.Other(path, err)
+	}
+	dagNode := dag.NodeWithData(dagData)
+
+	callCtx, cancel := interfaceutils.CallContext(fi.ctx)
+	defer cancel()
+	if err := fi.core.Dag().Add(callCtx, dagNode); err != nil {
+		return iferrors.IO(path, err)
+	}
+
+	linkPath := corepath.IpfsPath(dagNode.Cid()).String()
+	if err := fi.api.Request("files/cp", linkPath, path).Exec(callCtx, nil); err != nil {
+		return filesErr(path, err)
+	}
+	return nil
+}
+
+type emptyReader struct{}
+
+func (*emptyReader) Read([]byte) (int, error) { return 0, io.EOF }
@@ -0,0 +1,83 @@
+package filesapi
+
+import (
+	"context"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+)
+
+type filesDirectoryStream struct {
+	*filesInterface
+	path string
+}
+
+// OpenDirectory returns a Directory for the given path (as a stream of entries).
+func (fi *filesInterface) OpenDirectory(path string) (filesystem.Directory, error) {
+	fType, err := fi.nodeType(path)
+	if err != nil {
+		return nil, err
+	}
+	if fType != coreiface.TDirectory {
+		return nil, iferrors.NotDir(path)
+	}
+
+	filesStream := &filesDirectoryStream{
+		filesInterface: fi,
+		path:           path,
+	}
+
+	return interfaceutils.UpgradePartialStream(
+		interfaceutils.NewPartialStream(fi.ctx, filesStream))
+}
+
+// lsOutput is the subset of `files ls`'s output that we use
+type lsOutput struct {
+	Entries []struct{ Name string }
+}
+
+func (fi *filesInterface) listNames(ctx context.Context, path string) ([]string, error) {
+	listing := new(lsOutput)
+	if err := fi.api.Request("files/ls", path).Exec(ctx, listing); err != nil {
+		return nil, filesErr(path, err)
+	}
+
+	names := make([]string, len(listing.Entries))
+	for i, ent := range listing.Entries {
+		names[i] = ent.Name
+	}
+	return names, nil
+}
+
+// SendTo receives a channel with which we will send entries to, until the context is caneled, or the end of stream is reached.
+func (fs *filesDirectoryStream) SendTo(ctx context.Context, receiver chan<- interfaceutils.PartialEntry) error {
+	snapshot, err := fs.listNames(ctx, fs.path)
+	if err != nil {
+		close(receiver)
+		return err
+	}
+
+	// start sending translated entries to the receiver
+	go translateEntries(ctx, snapshot, receiver)
+
+	return nil
+}
+
+type filesListingTranslator string
+
+func (filesEntry filesListingTranslator) Name() string { return string(filesEntry) }
+func (filesEntry filesListingTranslator) Error() error { return nil }
+
+func translateEntries(ctx context.Context, in []string, out chan<- interfaceutils.PartialEntry) {
+out:
+	for _, name := range in {
+		select {
+		case out <- filesListingTranslator(name):
+		case <-ctx.Done():
+			break out
+		}
+	}
+	close(out)
+}
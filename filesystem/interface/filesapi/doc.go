@@ -0,0 +1,7 @@
+// Package filesapi provides a constructor to a `filesystem.Interface` which wraps a node's Files API (MFS),
+// via its command (RPC) interface. Unlike package mfs, this does not require a local MFS root,
+// and may be used with remote nodes (e.g. through the HTTP API client).
+//
+// System paths may be any valid slash delimited path.
+// e.g. `filesapi.Info(/myFile)`
+package filesapi
@@ -0,0 +1,233 @@
+package filesapi
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+)
+
+var (
+	_ filesystem.File = (*filesFile)(nil)
+
+	errNotFile = errors.New("not a regular file")
+)
+
+// filesFile translates File operations into `files` commands
+// each of which operates on the file's path at the cursor's offset
+type filesFile struct {
+	*filesInterface
+	path   string
+	flags  filesystem.IOFlags
+	cursor int64
+}
+
+func (fi *filesInterface) Open(path string, flags filesystem.IOFlags) (filesystem.File, error) {
+	if err := interfaceutils.MakeForOpen(fi, path, flags); err != nil {
+		return nil, err
+	}
+
+	fType, err := fi.nodeType(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch fType {
+	case coreiface.TFile:
+	case coreiface.TDirectory:
+		return nil, iferrors.IsDir(path)
+	default:
+		return nil, iferrors.UnsupportedItem(path, errNotFile)
+	}
+
+	file := &filesFile{
+		filesInterface: fi,
+		path:           path,
+		flags:          flags,
+	}
+	if flags&filesystem.IOTruncate != 0 {
+		if err := file.Truncate(0); err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+func (ff *filesFile) Close() error { return nil }
+
+func (ff *filesFile) Size() (int64, error) {
+	stat, err := ff.stat(ff.path)
+	if err != nil {
+		return 0, err
+	}
+	return int64(stat.Size), nil
+}
+
+func (ff *filesFile) Read(buff []byte) (int, error) {
+	if !ff.flags.Readable() {
+		return 0, iferrors.Permission(ff.path, interfaceutils.ErrNotReadable)
+	}
+
+	read, err := ff.readAt(buff, ff.cursor)
+	ff.cursor += int64(read)
+	return read, err
+}
+
+func (ff *filesFile) readAt(buff []byte, offset int64) (int, error) {
+	if len(buff) == 0 {
+		return 0, nil
+	}
+
+	callCtx, cancel := interfaceutils.CallContext(ff.ctx)
+	defer cancel()
+	response, err := ff.api.Request("files/read", ff.path).
+		Option("offset", offset).
+		Option("count", len(buff)).
+		Send(callCtx)
+	if err != nil {
+		return 0, filesErr(ff.path, err)
+	}
+	defer response.Close()
+	if response.Error != nil {
+		if strings.Contains(response.Error.Message, "past end of file") {
+			return 0, io.EOF
+		}
+		return 0, filesErr(ff.path, response.Error)
+	}
+
+	read, err := io.ReadFull(response.Output, buff)
+	switch err {
+	case nil:
+		return read, nil
+	case io.ErrUnexpectedEOF:
+		return read, nil // short read
+	case io.EOF:
+		return 0, io.EOF
+	default:
+		return read, iferrors.IO(ff.path, err)
+	}
+}
+
+func (ff *filesFile) Write(buff []byte) (int, error) {
+	if !ff.flags.Writable() {
+		return 0, iferrors.Permission(ff.path, interfaceutils.ErrNotWritable)
+	}
+
+	if ff.flags&filesystem.IOAppend != 0 {
+		if _, err := ff.Seek(0, io.SeekEnd); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := ff.writeAt(buff, ff.cursor, false); err != nil {
+		return 0, err
+	}
+	ff.cursor += int64(len(buff))
+	return len(buff), nil
+}
+
+func (ff *filesFile) writeAt(buff []byte, offset int64, truncate bool) error {
+	err := ff.write(bytes.NewReader(buff), offset, truncate)
+	if err == nil || truncate || !strings.Contains(err.Error(), "past end of file") {
+		return err
+	}
+
+	// the Files API doesn't write past the end of a file,
+	// so the gap is written as 0s
+	size, sizeErr := ff.Size()
+	if sizeErr != nil {
+		return sizeErr
+	}
+	if offset <= size {
+		return err
+	}
+	return ff.extend(size, offset, buff)
+}
+
+// extend writes 0s from the end of the file to the offset, followed by buff.
+func (ff *filesFile) extend(size, offset int64, buff []byte) error {
+	padding := io.LimitReader(zeros{}, offset-size)
+	return ff.write(io.MultiReader(padding, bytes.NewReader(buff)), size, false)
+}
+
+func (ff *filesFile) write(body io.Reader, offset int64, truncate bool) error {
+	callCtx, cancel := interfaceutils.CallContext(ff.ctx)
+	defer cancel()
+	err := ff.api.Request("files/write", ff.path).
+		Option("offset", offset).
+		Option("truncate", truncate).
+		FileBody(body).
+		Exec(callCtx, nil)
+	if err != nil {
+		return filesErr(ff.path, err)
+	}
+	return nil
+}
+
+// zeros is an endless reader of 0s.
+type zeros struct{}
+
+func (zeros) Read(buff []byte) (int, error) {
+	for i := range buff {
+		buff[i] = 0
+	}
+	return len(buff), nil
+}
+
+func (ff *filesFile) Seek(offset int64, whence int) (int64, error) {
+	var base int64
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		base = ff.cursor
+	case io.SeekEnd:
+		size, err := ff.Size()
+		if err != nil {
+			return ff.cursor, err
+		}
+		base = size
+	default:
+		return ff.cursor, iferrors.UnsupportedItem(ff.path, errors.New("invalid whence"))
+	}
+
+	if target := base + offset; target >= 0 {
+		ff.cursor = target
+		return target, nil
+	}
+	return ff.cursor, iferrors.UnsupportedItem(ff.path, errors.New("negative offset"))
+}
+
+// Truncate resizes the file.
+// The Files API can only truncate to 0 directly, so other sizes are emulated;
+// shrinking rewrites the retained data, and growing writes 0s from the current end to the new one.
+func (ff *filesFile) Truncate(size uint64) error {
+	if !ff.flags.Writable() {
+		return iferrors.Permission(ff.path, interfaceutils.ErrNotWritable)
+	}
+
+	currentSize, err := ff.Size()
+	if err != nil {
+		return err
+	}
+
+	switch target := int64(size); {
+	case target == currentSize:
+		return nil
+	case target > currentSize:
+		return ff.extend(currentSize, target, nil)
+	case target == 0:
+		return ff.writeAt(nil, 0, true)
+	default:
+		retained := make([]byte, target)
+		read, err := ff.readAt(retained, 0)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		return ff.writeAt(retained[:read], 0, true)
+	}
+}
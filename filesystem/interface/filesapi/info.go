@@ -0,0 +1,115 @@
+package filesapi
+
+import (
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// statOutput is the subset of `files stat`'s output that we use
+type statOutput struct {
+	Hash   string
+	Size   uint64
+	Blocks int
+	Type   string
+}
+
+const (
+	filesTypeDirectory = "directory"
+	filesTypeFile      = "file"
+)
+
+func (fi *filesInterface) stat(path string) (*statOutput, error) {
+	callCtx, cancel := interfaceutils.CallContext(fi.ctx)
+	defer cancel()
+
+	stat := new(statOutput)
+	if err := fi.api.Request("files/stat", path).Exec(callCtx, stat); err != nil {
+		return nil, filesErr(path, err)
+	}
+	return stat, nil
+}
+
+// ipfsPath returns the immutable path of the node currently at `path`
+func (fi *filesInterface) ipfsPath(path string) (corepath.Resolved, error) {
+	stat, err := fi.stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return ipfsPath(path, stat)
+}
+
+func ipfsPath(path string, stat *statOutput) (corepath.Resolved, error) {
+	c, err := cid.Decode(stat.Hash)
+	if err != nil {
+		return nil, iferrors.Other(path, err)
+	}
+	return corepath.IpfsPath(c), nil
+}
+
+// nodeType returns the type of the node at `path`
+// MFS reports symlinks as files, so we inspect the UFS node for those
+func (fi *filesInterface) nodeType(path string) (coreiface.FileType, error) {
+	iStat, _, err := fi.Info(path, filesystem.StatRequest{Type: true})
+	if err != nil {
+		return coreiface.TUnknown, err
+	}
+	return iStat.Type, nil
+}
+
+func (fi *filesInterface) Info(path string, req filesystem.StatRequest) (*filesystem.Stat, filesystem.StatRequest, error) {
+	stat, err := fi.stat(path)
+	if err != nil {
+		return new(filesystem.Stat), filesystem.StatRequest{}, err
+	}
+
+	switch stat.Type {
+	case filesTypeDirectory:
+		return &filesystem.Stat{
+			Type: coreiface.TDirectory,
+			Size: stat.Size,
+		}, filesystem.StatRequest{
+			Type: true, Size: true,
+		}, nil
+
+	case filesTypeFile:
+		// files and symlinks; defer to the UFS node itself
+		nodePath, err := ipfsPath(path, stat)
+		if err != nil {
+			return new(filesystem.Stat), filesystem.StatRequest{}, err
+		}
+		callCtx, cancel := interfaceutils.CallContext(fi.ctx)
+		defer cancel()
+		return fi.core.Stat(callCtx, nodePath, req)
+
+	default:
+		return new(filesystem.Stat), filesystem.StatRequest{}, iferrors.Other(path,
+			fmt.Errorf("unexpected node type %q", stat.Type),
+		)
+	}
+}
+
+func (fi *filesInterface) ExtractLink(path string) (string, error) {
+	nodePath, err := fi.ipfsPath(path)
+	if err != nil {
+		return "", err
+	}
+	return fi.core.ExtractLink(nodePath)
+}
+
+func (fi *filesInterface) ExtendedAttributes(path string) (map[string]string, error) {
+	nodePath, err := fi.ipfsPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// MFS paths are not content paths, so we provide the node's immutable path
+	callCtx, cancel := interfaceutils.CallContext(fi.ctx)
+	defer cancel()
+	return fi.core.ExtendedAttributes(callCtx, nodePath)
+}
@@ -0,0 +1,81 @@
+package filesapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	cmds "github.com/ipfs/go-ipfs-cmds"
+	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	gomfs "github.com/ipfs/go-mfs"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+)
+
+// adapts the node's Files API to our filesystem node
+type filesInterface struct {
+	ctx  context.Context
+	core interfaceutils.CoreExtender
+	api  interfaceutils.Requester
+}
+
+// NewInterface returns a `filesystem.Interface` for the node's Files API.
+// The provided core must also implement `interfaceutils.Requester` (as the HTTP API client does).
+func NewInterface(ctx context.Context, core coreiface.CoreAPI) (fs filesystem.Interface, err error) {
+	api, ok := core.(interfaceutils.Requester)
+	if !ok {
+		err = fmt.Errorf("core API %T does not support command requests", core)
+		return
+	}
+
+	fs = &filesInterface{
+		ctx:  ctx,
+		core: &interfaceutils.CoreExtended{CoreAPI: core},
+		api:  api,
+	}
+	return
+}
+
+func (fi *filesInterface) ID() filesystem.ID { return filesystem.Files }
+func (fi *filesInterface) Close() error      { return nil }
+func (fi *filesInterface) StorageStat() (*filesystem.StorageStat, error) {
+	callCtx, cancel := interfaceutils.CallContext(fi.ctx)
+	defer cancel()
+	return fi.core.StorageStat(callCtx)
+}
+func (fi *filesInterface) Rename(oldName, newName string) error {
+	callCtx, cancel := interfaceutils.CallContext(fi.ctx)
+	defer cancel()
+	if err := fi.api.Request("files/mv", oldName, newName).Exec(callCtx, nil); err != nil {
+		return filesErr(newName, err)
+	}
+	return nil
+}
+
+// filesErr translates errors returned from the Files API into our error kinds.
+// The API returns errors as strings, so we have to inspect their message.
+func filesErr(path string, err error) error {
+	var cmdsErr *cmds.Error
+	if !errors.As(err, &cmdsErr) {
+		return iferrors.IO(path, err) // transport error
+	}
+
+	switch msg := cmdsErr.Message; {
+	case strings.Contains(msg, os.ErrNotExist.Error()):
+		return iferrors.NotExist(path)
+	case strings.Contains(msg, os.ErrExist.Error()),
+		strings.Contains(msg, gomfs.ErrDirExists.Error()):
+		return iferrors.Exist(path)
+	case strings.Contains(msg, "not a directory"),
+		strings.Contains(msg, "was not a directory"):
+		return iferrors.NotDir(path)
+	case strings.Contains(msg, "is a directory"),
+		strings.Contains(msg, "was not a file"):
+		return iferrors.IsDir(path)
+	default:
+		return iferrors.Other(path, err)
+	}
+}
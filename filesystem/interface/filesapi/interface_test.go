@@ -0,0 +1,413 @@
+package filesapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	gopath "path"
+	"sort"
+	"strings"
+	"testing"
+
+	cmds "github.com/ipfs/go-ipfs-cmds"
+	httpapi "github.com/ipfs/go-ipfs-http-client"
+	"github.com/ipfs/go-ipfs/filesystem"
+	fserrors "github.com/ipfs/go-ipfs/filesystem/errors"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	ipld "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	gomfs "github.com/ipfs/go-mfs"
+	"github.com/ipfs/go-unixfs"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// filesNode serves the subset of the Files API we use, from a local MFS root,
+// in place of a node's HTTP API
+type filesNode struct {
+	ctx   context.Context
+	mroot *gomfs.Root
+}
+
+func (fn *filesNode) Request(command string, args ...string) httpapi.RequestBuilder {
+	return &filesRequest{
+		node:    fn,
+		command: command,
+		args:    args,
+		options: make(map[string]interface{}),
+	}
+}
+
+// ResolveNode is the only CoreAPI method used by the Files interface (to stat files)
+type coreDag struct {
+	coreiface.CoreAPI
+	dag ipld.DAGService
+}
+
+func (cd *coreDag) ResolveNode(ctx context.Context, path corepath.Path) (ipld.Node, error) {
+	resolved, ok := path.(corepath.Resolved)
+	if !ok {
+		return nil, fmt.Errorf("unexpected path %s", path)
+	}
+	return cd.dag.Get(ctx, resolved.Cid())
+}
+
+type filesRequest struct {
+	node    *filesNode
+	command string
+	args    []string
+	options map[string]interface{}
+	body    io.Reader
+}
+
+func (fr *filesRequest) Arguments(args ...string) httpapi.RequestBuilder {
+	fr.args = append(fr.args, args...)
+	return fr
+}
+func (fr *filesRequest) BodyString(body string) httpapi.RequestBuilder {
+	return fr.Body(strings.NewReader(body))
+}
+func (fr *filesRequest) BodyBytes(body []byte) httpapi.RequestBuilder {
+	return fr.Body(bytes.NewReader(body))
+}
+func (fr *filesRequest) Body(body io.Reader) httpapi.RequestBuilder     { fr.body = body; return fr }
+func (fr *filesRequest) FileBody(body io.Reader) httpapi.RequestBuilder { return fr.Body(body) }
+func (fr *filesRequest) Header(string, string) httpapi.RequestBuilder   { return fr }
+func (fr *filesRequest) Option(key string, value interface{}) httpapi.RequestBuilder {
+	fr.options[key] = value
+	return fr
+}
+
+// Send responds as the HTTP API does; command errors are returned within the response
+func (fr *filesRequest) Send(context.Context) (*httpapi.Response, error) {
+	output, err := fr.execute()
+	if err != nil {
+		return &httpapi.Response{Error: &cmds.Error{Message: err.Error()}}, nil
+	}
+	return &httpapi.Response{Output: ioutil.NopCloser(bytes.NewReader(output))}, nil
+}
+
+func (fr *filesRequest) Exec(ctx context.Context, res interface{}) error {
+	response, err := fr.Send(ctx)
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	defer response.Close()
+	if res == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Output).Decode(res)
+}
+
+func (fr *filesRequest) execute() ([]byte, error) {
+	var (
+		mroot = fr.node.mroot
+		path  = fr.args[0]
+	)
+	switch fr.command {
+	case "files/stat":
+		fsNode, err := gomfs.Lookup(mroot, path)
+		if err != nil {
+			return nil, errNotExist
+		}
+		node, err := fsNode.GetNode()
+		if err != nil {
+			return nil, err
+		}
+		out := statOutput{Hash: node.Cid().String(), Blocks: len(node.Links()), Type: filesTypeFile}
+		if fsNode.Type() == gomfs.TDir {
+			out.Type = filesTypeDirectory
+		}
+		if file, ok := fsNode.(*gomfs.File); ok {
+			size, err := file.Size()
+			if err != nil {
+				return nil, err
+			}
+			out.Size = uint64(size)
+		}
+		return json.Marshal(out)
+
+	case "files/ls":
+		dir, err := lookupDir(mroot, path)
+		if err != nil {
+			return nil, err
+		}
+		names, err := dir.ListNames(fr.node.ctx)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(names)
+		var out lsOutput
+		for _, name := range names {
+			out.Entries = append(out.Entries, struct{ Name string }{name})
+		}
+		return json.Marshal(out)
+
+	case "files/read":
+		file, err := lookupFile(mroot, path)
+		if err != nil {
+			return nil, err
+		}
+		fd, err := file.Open(gomfs.Flags{Read: true})
+		if err != nil {
+			return nil, err
+		}
+		defer fd.Close()
+		size, err := fd.Size()
+		if err != nil {
+			return nil, err
+		}
+		offset, _ := fr.options["offset"].(int64)
+		if offset > size {
+			return nil, fmt.Errorf("offset was past end of file (%d > %d)", offset, size)
+		}
+		if _, err := fd.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(io.LimitReader(fd, int64(fr.options["count"].(int))))
+
+	case "files/write":
+		file, err := lookupFile(mroot, path)
+		if err == errNotExist {
+			if create, _ := fr.options["create"].(bool); create {
+				if err := gomfs.PutNode(mroot, path, dag.NodeWithData(unixfs.FilePBData(nil, 0))); err != nil {
+					return nil, err
+				}
+				file, err = lookupFile(mroot, path)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		fd, err := file.Open(gomfs.Flags{Write: true, Sync: true})
+		if err != nil {
+			return nil, err
+		}
+		defer fd.Close()
+		if truncate, _ := fr.options["truncate"].(bool); truncate {
+			if err := fd.Truncate(0); err != nil {
+				return nil, err
+			}
+		}
+		size, err := fd.Size()
+		if err != nil {
+			return nil, err
+		}
+		offset, _ := fr.options["offset"].(int64)
+		if offset > size {
+			return nil, fmt.Errorf("offset was past end of file (%d > %d)", offset, size)
+		}
+		if _, err := fd.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(fd, fr.body)
+		return nil, err
+
+	case "files/mkdir":
+		return nil, gomfs.Mkdir(mroot, path, gomfs.MkdirOpts{Flush: true})
+
+	case "files/mv":
+		return nil, gomfs.Mv(mroot, path, fr.args[1])
+
+	case "files/rm":
+		parent, err := lookupDir(mroot, gopath.Dir(path))
+		if err != nil {
+			return nil, err
+		}
+		if err := parent.Unlink(gopath.Base(path)); err != nil {
+			return nil, err
+		}
+		return nil, parent.Flush()
+
+	default:
+		return nil, fmt.Errorf("unexpected command %q", fr.command)
+	}
+}
+
+var errNotExist = errors.New("file does not exist")
+
+func lookupDir(mroot *gomfs.Root, path string) (*gomfs.Directory, error) {
+	fsNode, err := gomfs.Lookup(mroot, path)
+	if err != nil {
+		return nil, errNotExist
+	}
+	dir, ok := fsNode.(*gomfs.Directory)
+	if !ok {
+		return nil, fmt.Errorf("%s was not a directory", path)
+	}
+	return dir, nil
+}
+
+func lookupFile(mroot *gomfs.Root, path string) (*gomfs.File, error) {
+	fsNode, err := gomfs.Lookup(mroot, path)
+	if err != nil {
+		return nil, errNotExist
+	}
+	file, ok := fsNode.(*gomfs.File)
+	if !ok {
+		return nil, fmt.Errorf("%s was not a file", path)
+	}
+	return file, nil
+}
+
+func TestFilesInterface(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dagService := mdtest.Mock()
+	mroot, err := gomfs.NewRoot(ctx, dagService, unixfs.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := &filesInterface{
+		ctx:  ctx,
+		core: &interfaceutils.CoreExtended{CoreAPI: &coreDag{dag: dagService}},
+		api:  &filesNode{ctx: ctx, mroot: mroot},
+	}
+
+	expectKind := func(t *testing.T, err error, kind fserrors.Kind) {
+		t.Helper()
+		var fsErr fserrors.Error
+		if !errors.As(err, &fsErr) || fsErr.Kind() != kind {
+			t.Fatalf("expected error of kind %v, got: %v", kind, err)
+		}
+	}
+	writeFile := func(t *testing.T, path string, flags filesystem.IOFlags, data string) {
+		t.Helper()
+		file, err := fs.Open(path, flags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectContent := func(t *testing.T, path, expected string) {
+		t.Helper()
+		file, err := fs.Open(path, filesystem.IOReadOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		data, err := ioutil.ReadAll(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Errorf("%s: content does not match\n\twanted: %q\n\tgot: %q", path, expected, data)
+		}
+	}
+
+	t.Run("open flags", func(t *testing.T) {
+		_, err := fs.Open("/file", filesystem.IOReadOnly)
+		expectKind(t, err, fserrors.NotExist)
+
+		writeFile(t, "/file", filesystem.IOWriteOnly|filesystem.IOCreate, "hello")
+		expectContent(t, "/file", "hello")
+
+		_, err = fs.Open("/file", filesystem.IOWriteOnly|filesystem.IOCreate|filesystem.IOExclusive)
+		expectKind(t, err, fserrors.Exist)
+
+		writeFile(t, "/file", filesystem.IOWriteOnly|filesystem.IOAppend, " world")
+		expectContent(t, "/file", "hello world")
+
+		writeFile(t, "/file", filesystem.IOWriteOnly|filesystem.IOTruncate, "bye")
+		expectContent(t, "/file", "bye")
+
+		file, err := fs.Open("/file", filesystem.IOReadOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		_, err = file.Write([]byte("x"))
+		expectKind(t, err, fserrors.Permission)
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		file, err := fs.Open("/file", filesystem.IOReadWrite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		if err := file.Truncate(5); err != nil {
+			t.Fatal(err)
+		}
+		expectContent(t, "/file", "bye\x00\x00")
+		if err := file.Truncate(2); err != nil {
+			t.Fatal(err)
+		}
+		expectContent(t, "/file", "by")
+
+		// writes past the end are padded with 0s too
+		if _, err := file.Seek(4, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte("e")); err != nil {
+			t.Fatal(err)
+		}
+		expectContent(t, "/file", "by\x00\x00e")
+	})
+
+	t.Run("directories", func(t *testing.T) {
+		if err := fs.MakeDirectory("/dir"); err != nil {
+			t.Fatal(err)
+		}
+		if err := fs.Make("/dir/entry"); err != nil {
+			t.Fatal(err)
+		}
+		expectKind(t, fs.Make("/dir/entry"), fserrors.Exist)
+
+		stat, _, err := fs.Info("/dir", filesystem.StatRequest{Type: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stat.Type != coreiface.TDirectory {
+			t.Errorf("expected directory type, got %v", stat.Type)
+		}
+		_, err = fs.Open("/dir", filesystem.IOReadOnly)
+		expectKind(t, err, fserrors.IsDir)
+
+		directory, err := fs.OpenDirectory("/dir")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for ent := range directory.List(ctx, 0) {
+			if err := ent.Error(); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, ent.Name())
+		}
+		if err := directory.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if len(names) != 1 || names[0] != "entry" {
+			t.Errorf("unexpected directory entries: %v", names)
+		}
+
+		expectKind(t, fs.RemoveDirectory("/dir"), fserrors.NotEmpty)
+		expectKind(t, fs.Remove("/dir"), fserrors.IsDir)
+		if err := fs.Rename("/dir/entry", "/entry"); err != nil {
+			t.Fatal(err)
+		}
+		if err := fs.RemoveDirectory("/dir"); err != nil {
+			t.Fatal(err)
+		}
+		if err := fs.Remove("/entry"); err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = fs.Info("/entry", filesystem.StatRequest{Type: true})
+		expectKind(t, err, fserrors.NotExist)
+	})
+}
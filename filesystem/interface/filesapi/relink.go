@@ -0,0 +1,67 @@
+package filesapi
+
+import (
+	"errors"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	fserrors "github.com/ipfs/go-ipfs/filesystem/errors"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	ipld "github.com/ipfs/go-ipld-format"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+var _ filesystem.Relinker = (*filesInterface)(nil)
+
+func (fi *filesInterface) Node(path string) (ipld.Node, error) {
+	nodePath, err := fi.ipfsPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	callCtx, cancel := interfaceutils.CallContext(fi.ctx)
+	defer cancel()
+	node, err := fi.core.Dag().Get(callCtx, nodePath.Cid())
+	if err != nil {
+		return nil, iferrors.IO(path, err)
+	}
+	return node, nil
+}
+
+// Link copies the node's path into MFS (which links to the node, rather than copying its data).
+func (fi *filesInterface) Link(path string, node ipld.Node) error {
+	stat, err := fi.stat(path)
+	var fsErr fserrors.Error
+	switch {
+	case err == nil:
+		if stat.Type == filesTypeDirectory && stat.Blocks != 0 {
+			return iferrors.NotEmpty(path)
+		}
+		return iferrors.Exist(path)
+	case !errors.As(err, &fsErr) || fsErr.Kind() != fserrors.NotExist:
+		return err
+	}
+
+	callCtx, cancel := interfaceutils.CallContext(fi.ctx)
+	defer cancel()
+	if err := fi.core.Dag().Add(callCtx, node); err != nil { // (nodes from other MFS roots may not be stored yet)
+		return iferrors.IO(path, err)
+	}
+	nodePath := corepath.IpfsPath(node.Cid()).String()
+	if err := fi.api.Request("files/cp", nodePath, path).Exec(callCtx, nil); err != nil {
+		return filesErr(path, err)
+	}
+	return nil
+}
+
+func (fi *filesInterface) Unlink(path string) error {
+	callCtx, cancel := interfaceutils.CallContext(fi.ctx)
+	defer cancel()
+	err := fi.api.Request("files/rm", path).
+		Option("recursive", true).
+		Exec(callCtx, nil)
+	if err != nil {
+		return filesErr(path, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package filesapi
+
+import (
+	"fmt"
+
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+)
+
+func (fi *filesInterface) Remove(path string) error {
+	return fi.remove(path, coreiface.TFile)
+}
+
+func (fi *filesInterface) RemoveLink(path string) error {
+	return fi.remove(path, coreiface.TSymlink)
+}
+
+func (fi *filesInterface) RemoveDirectory(path string) error {
+	return fi.remove(path, coreiface.TDirectory)
+}
+
+func (fi *filesInterface) remove(path string, nodeType coreiface.FileType) error {
+	if path == "/" {
+		return iferrors.Permission(path, fmt.Errorf("cannot remove root"))
+	}
+
+	// compare the node's type with the request type
+	fType, err := fi.nodeType(path)
+	if err != nil {
+		return err
+	}
+
+	callCtx, cancel := interfaceutils.CallContext(fi.ctx)
+	defer cancel()
+
+	switch nodeType {
+	case coreiface.TFile, coreiface.TSymlink:
+		if fType == coreiface.TDirectory {
+			return iferrors.IsDir(path)
+		}
+		if fType != nodeType {
+			return iferrors.UnsupportedItem(path,
+				fmt.Errorf("(Type: %v) does not match request type %v", fType, nodeType))
+		}
+
+	case coreiface.TDirectory:
+		if fType != coreiface.TDirectory {
+			return fmt.Errorf("(Type: %v), %w",
+				fType,
+				iferrors.NotDir(path),
+			)
+		}
+
+		ents, err := fi.listNames(callCtx, path)
+		if err != nil {
+			return err
+		}
+		if len(ents) != 0 {
+			return iferrors.NotEmpty(path)
+		}
+
+	default:
+		return iferrors.Permission(path,
+			fmt.Errorf("unexpected node type: %v", nodeType))
+	}
+
+	err = fi.api.Request("files/rm", path).
+		Option("recursive", nodeType == coreiface.TDirectory).
+		Exec(callCtx, nil)
+	if err != nil {
+		return filesErr(path, err)
+	}
+	return nil
+}
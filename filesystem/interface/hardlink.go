@@ -0,0 +1,56 @@
+package interfaceutils
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+)
+
+var ErrLinkDirectory = errors.New("directories can not be hard linked")
+
+// HardLink gives the node at `oldName` an additional name `newName`, within systems that implement `filesystem.Relinker`.
+// Both names refer to the same node until either is modified;
+// modifications replace the node referred to by that name only (copy-on-write).
+func HardLink(fs filesystem.Interface, oldName, newName string) error {
+	relinker, ok := fs.(filesystem.Relinker)
+	if !ok {
+		return iferrors.UnsupportedRequest()
+	}
+
+	stat, _, err := fs.Info(oldName, filesystem.StatRequest{Type: true})
+	if err != nil {
+		return err
+	}
+	if stat.Type == coreiface.TDirectory {
+		return iferrors.Permission(oldName, ErrLinkDirectory)
+	}
+
+	node, err := relinker.Node(oldName)
+	if err != nil {
+		return err
+	}
+	return relinker.Link(newName, node)
+}
+
+// Relink moves the node at `oldName` in `source`, to `newName` in `target`, by reference.
+// The move is not atomic; if `oldName` can't be unlinked after `newName` is linked,
+// `newName` is unlinked again (only if that fails too, does the node remain under both names).
+func Relink(source filesystem.Relinker, oldName string, target filesystem.Relinker, newName string) error {
+	node, err := source.Node(oldName)
+	if err != nil {
+		return err
+	}
+	if err := target.Link(newName, node); err != nil {
+		return err
+	}
+	if err := source.Unlink(oldName); err != nil {
+		if undoErr := target.Unlink(newName); undoErr != nil {
+			return fmt.Errorf("%w (the node remains linked at %q: %s)", err, newName, undoErr)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package interfaceutils
+
+import (
+	"errors"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	fserrors "github.com/ipfs/go-ipfs/filesystem/errors"
+)
+
+var (
+	ErrNotReadable = errors.New("file was not opened for reading")
+	ErrNotWritable = errors.New("file was not opened for writing")
+)
+
+// MakeForOpen handles the creation modifiers of an `Open` request,
+// making the file at path, if it was requested and the file does not already exist.
+// Callers should strip these modifiers from the flags, if they relay the request to another `Interface`.
+func MakeForOpen(fs filesystem.Interface, path string, flags filesystem.IOFlags) error {
+	if flags&filesystem.IOCreate == 0 {
+		return nil
+	}
+
+	err := fs.Make(path)
+	if err == nil {
+		return nil
+	}
+
+	var fsErr fserrors.Error
+	if errors.As(err, &fsErr) && fsErr.Kind() == fserrors.Exist &&
+		flags&filesystem.IOExclusive == 0 {
+		return nil // existing files are fine unless exclusive creation was requested
+	}
+	return err
+}
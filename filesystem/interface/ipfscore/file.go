@@ -43,7 +43,7 @@ func (cio *cborFile) Seek(offset int64, whence int) (int64, error) {
 }
 
 func (ci *coreInterface) Open(path string, flags filesystem.IOFlags) (filesystem.File, error) {
-	if flags != filesystem.IOReadOnly {
+	if flags.Access() != filesystem.IOReadOnly || flags&filesystem.IOTruncate != 0 {
 		return nil, iferrors.ReadOnly(path)
 	}
 
@@ -71,6 +71,13 @@ func (ci *coreInterface) Open(path string, flags filesystem.IOFlags) (filesystem
 		*/
 	}
 
+	// files are read through our block cache when read-ahead is enabled
+	if ci.blocks != nil {
+		if file, ok := ci.newReadAheadFile(ipldNode); ok {
+			return file, nil
+		}
+	}
+
 	apiNode, err := ci.core.Unixfs().Get(ci.ctx, corePath)
 	if err != nil {
 		return nil, iferrors.Permission(path, err)
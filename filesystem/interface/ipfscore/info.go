@@ -24,3 +24,13 @@ func (ci *coreInterface) Info(path string, req filesystem.StatRequest) (*filesys
 func (ci *coreInterface) ExtractLink(path string) (string, error) {
 	return ci.core.ExtractLink(ci.joinRoot(path))
 }
+
+func (ci *coreInterface) ExtendedAttributes(path string) (map[string]string, error) {
+	if path == "/" {
+		return nil, nil // the namespace root is not a node
+	}
+
+	callCtx, cancel := interfaceutils.CallContext(ci.ctx)
+	defer cancel()
+	return ci.core.ExtendedAttributes(callCtx, ci.joinRoot(path))
+}
@@ -21,20 +21,41 @@ type coreInterface struct {
 	ctx      context.Context
 	core     interfaceutils.CoreExtender
 	systemID filesystem.ID
+
+	readAhead int         // how many blocks are prefetched ahead of sequential reads
+	cacheSize int         // how many blocks are retained by the cache
+	blocks    *blockCache // shared by all files of the instance (nil when read-ahead is disabled)
 }
 
-func NewInterface(ctx context.Context, core coreiface.CoreAPI, systemID filesystem.ID) filesystem.Interface {
-	return &coreInterface{
-		ctx:      ctx,
-		core:     &interfaceutils.CoreExtended{CoreAPI: core},
-		systemID: systemID,
+func NewInterface(ctx context.Context, core coreiface.CoreAPI, systemID filesystem.ID, options ...Option) filesystem.Interface {
+	ci := &coreInterface{
+		ctx:       ctx,
+		core:      &interfaceutils.CoreExtended{CoreAPI: core},
+		systemID:  systemID,
+		cacheSize: DefaultBlockCacheSize,
+	}
+	for _, option := range options {
+		option(ci)
+	}
+	if ci.readAhead > 0 {
+		ci.blocks = newBlockCache(ctx, core.Dag(), ci.cacheSize)
 	}
+	return ci
 }
 
 func (ci *coreInterface) ID() filesystem.ID     { return ci.systemID }
 func (*coreInterface) Close() error             { return nil }
 func (*coreInterface) Rename(_, _ string) error { return errReadOnly }
 
+// IPFS paths are content addressed, IPNS paths are not
+func (ci *coreInterface) IsImmutable(string) bool { return ci.systemID == filesystem.IPFS }
+
+func (ci *coreInterface) StorageStat() (*filesystem.StorageStat, error) {
+	callCtx, cancel := interfaceutils.CallContext(ci.ctx)
+	defer cancel()
+	return ci.core.StorageStat(callCtx)
+}
+
 func (ci *coreInterface) joinRoot(path string) corepath.Path {
 	return corepath.New(gopath.Join("/", strings.ToLower(ci.systemID.String()), path))
 }
@@ -0,0 +1,388 @@
+package ipfscore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	ipld "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/ipfs/go-unixfs"
+	unixpb "github.com/ipfs/go-unixfs/pb"
+)
+
+const (
+	// DefaultReadAhead is the number of blocks mounts prefetch ahead of sequential reads, unless configured otherwise.
+	// (instances constructed without `WithReadAhead` don't read ahead,
+	// so that systems which construct instances internally, like PinFS and KeyFS,
+	// don't prefetch the same blocks as the IPFS and IPNS instances mounted beside them)
+	DefaultReadAhead = 8
+	// DefaultBlockCacheSize is the default number of blocks retained by an instance.
+	DefaultBlockCacheSize = 64
+	// MinBlockCacheSize is the smallest number of blocks retained by an instance.
+	MinBlockCacheSize = 1
+)
+
+// ParseReadAhead parses the string form of a read-ahead window (a number of blocks).
+func ParseReadAhead(window string) (int, error) {
+	blocks, err := strconv.Atoi(window)
+	if err != nil {
+		return 0, fmt.Errorf("invalid read-ahead window %q: %w", window, err)
+	}
+	if blocks < 0 {
+		return 0, fmt.Errorf("invalid read-ahead window %q: must not be negative", window)
+	}
+	return blocks, nil
+}
+
+// Option alters the construction of the interface.
+type Option func(*coreInterface)
+
+// WithReadAhead sets how many blocks are fetched (in parallel) ahead of sequential reads.
+// A window of 0 disables read-ahead; files are then read through the node's UnixFS API.
+func WithReadAhead(window int) Option { return func(ci *coreInterface) { ci.readAhead = window } }
+
+// WithBlockCacheSize sets how many blocks are retained for reuse.
+// The cache is shared by all files of the instance;
+// so that multiple handles to the same content don't fetch it again.
+// Sizes below `MinBlockCacheSize` are raised to it.
+func WithBlockCacheSize(blocks int) Option {
+	if blocks < MinBlockCacheSize {
+		blocks = MinBlockCacheSize
+	}
+	return func(ci *coreInterface) { ci.cacheSize = blocks }
+}
+
+type (
+	// blockCache retains decoded UnixFS file blocks,
+	// and coalesces concurrent requests for the same block into a single fetch.
+	blockCache struct {
+		ctx    context.Context
+		dag    ipld.NodeGetter
+		blocks *lru.Cache // of `cid.Cid`:`*fileBlock`
+
+		sync.Mutex
+		pending map[cid.Cid]*blockFetch
+	}
+
+	// fileBlock is the portion of a UnixFS file node that we use
+	fileBlock struct {
+		data  []byte       // file data contained within the node itself
+		links []*ipld.Link // the node's children, in file order
+		sizes []uint64     // the file size of each child
+	}
+
+	blockFetch struct {
+		done  chan struct{}
+		block *fileBlock
+		err   error
+	}
+)
+
+func newBlockCache(ctx context.Context, dag ipld.NodeGetter, size int) *blockCache {
+	blocks, err := lru.New(size)
+	if err != nil { // only possible when the size is not positive (see `WithBlockCacheSize`)
+		panic(err)
+	}
+	return &blockCache{
+		ctx:     ctx,
+		dag:     dag,
+		blocks:  blocks,
+		pending: make(map[cid.Cid]*blockFetch),
+	}
+}
+
+func decodeFileBlock(node ipld.Node) (*fileBlock, error) {
+	switch node := node.(type) {
+	case *dag.RawNode:
+		return &fileBlock{data: node.RawData()}, nil
+	case *dag.ProtoNode:
+		fsNode, err := unixfs.FSNodeFromBytes(node.Data())
+		if err != nil {
+			return nil, err
+		}
+		switch fsNode.Type() {
+		case unixpb.Data_File, unixpb.Data_Raw:
+		default:
+			return nil, fmt.Errorf("%s node is not part of a file", fsNode.Type())
+		}
+		links := node.Links()
+		if len(links) != fsNode.NumChildren() {
+			return nil, fmt.Errorf("node %s has %d links but describes %d children",
+				node.Cid(), len(links), fsNode.NumChildren())
+		}
+		return &fileBlock{data: fsNode.Data(), links: links, sizes: fsNode.BlockSizes()}, nil
+	default:
+		return nil, unixfs.ErrUnrecognizedType
+	}
+}
+
+// add stores a block that was fetched by other means
+func (bc *blockCache) add(blockCid cid.Cid, block *fileBlock) { bc.blocks.Add(blockCid, block) }
+
+// cached returns the block only if it's already been fetched
+func (bc *blockCache) cached(blockCid cid.Cid) (*fileBlock, bool) {
+	block, ok := bc.blocks.Get(blockCid)
+	if !ok {
+		return nil, false
+	}
+	return block.(*fileBlock), true
+}
+
+// request starts fetching the block if it's not cached or already being fetched.
+// The returned fetch is nil if the block is cached.
+func (bc *blockCache) request(blockCid cid.Cid) *blockFetch {
+	bc.Lock()
+	defer bc.Unlock()
+	if bc.blocks.Contains(blockCid) {
+		return nil
+	}
+	if fetch, ok := bc.pending[blockCid]; ok {
+		return fetch
+	}
+
+	fetch := &blockFetch{done: make(chan struct{})}
+	bc.pending[blockCid] = fetch
+	go bc.fetch(blockCid, fetch)
+	return fetch
+}
+
+// fetches are not bound to any particular file; they're shared by all files waiting on them
+func (bc *blockCache) fetch(blockCid cid.Cid, fetch *blockFetch) {
+	callCtx, cancel := interfaceutils.CallContext(bc.ctx)
+	defer cancel()
+
+	node, err := bc.dag.Get(callCtx, blockCid)
+	if err == nil {
+		fetch.block, err = decodeFileBlock(node)
+	}
+	fetch.err = err
+
+	bc.Lock()
+	if err == nil {
+		bc.blocks.Add(blockCid, fetch.block)
+	}
+	delete(bc.pending, blockCid)
+	bc.Unlock()
+	close(fetch.done)
+}
+
+// get returns the block, fetching it if necessary
+func (bc *blockCache) get(ctx context.Context, blockCid cid.Cid) (*fileBlock, error) {
+	for {
+		if block, ok := bc.cached(blockCid); ok {
+			return block, nil
+		}
+		fetch := bc.request(blockCid)
+		if fetch == nil {
+			continue // fetched between our checks (the cache may have evicted it again since)
+		}
+		select {
+		case <-fetch.done:
+			return fetch.block, fetch.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// segment is a contiguous range of a file
+type segment struct {
+	offset, size int64
+	cid          cid.Cid // undefined if the data is held by the segment itself
+	leaf         bool    // set once the block is known to have no children
+	data         []byte  // data contained within an internal node
+}
+
+// segments returns the ranges of the file that the block's data and children cover
+func (block *fileBlock) segments(offset int64) []segment {
+	segments := make([]segment, 0, len(block.links)+1)
+	if len(block.data) != 0 {
+		segments = append(segments, segment{offset: offset, size: int64(len(block.data)), data: block.data})
+		offset += int64(len(block.data))
+	}
+	for i, link := range block.links {
+		size := int64(block.sizes[i])
+		segments = append(segments, segment{offset: offset, size: size, cid: link.Cid})
+		offset += size
+	}
+	return segments
+}
+
+var _ filesystem.File = (*readAheadFile)(nil)
+
+// readAheadFile reads the blocks of a UnixFS file directly,
+// prefetching upcoming blocks when it's being read sequentially.
+// The file's layout is discovered lazily, as ranges of it are read.
+type readAheadFile struct {
+	sync.Mutex
+	ctx      context.Context
+	blocks   *blockCache
+	root     cid.Cid
+	window   int
+	size     int64
+	segments []segment // ordered by offset; internal nodes are replaced by their children as they're resolved
+	cursor   int64
+	lastEnd  int64 // where the previous read ended
+}
+
+func (ci *coreInterface) newReadAheadFile(root ipld.Node) (filesystem.File, bool) {
+	block, err := decodeFileBlock(root)
+	if err != nil {
+		return nil, false // not a file we know how to traverse
+	}
+	ci.blocks.add(root.Cid(), block)
+
+	size := int64(len(block.data))
+	for _, childSize := range block.sizes {
+		size += int64(childSize)
+	}
+
+	return &readAheadFile{
+		ctx:      ci.ctx,
+		blocks:   ci.blocks,
+		root:     root.Cid(),
+		window:   ci.readAhead,
+		size:     size,
+		segments: []segment{{size: size, cid: root.Cid()}},
+	}, true
+}
+
+func (rf *readAheadFile) Size() (int64, error)        { return rf.size, nil }
+func (rf *readAheadFile) Write(_ []byte) (int, error) { return 0, errReadOnly }
+func (rf *readAheadFile) Truncate(_ uint64) error     { return errReadOnly }
+func (rf *readAheadFile) Close() error                { return nil }
+
+func (rf *readAheadFile) Seek(offset int64, whence int) (int64, error) {
+	rf.Lock()
+	defer rf.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += rf.cursor
+	case io.SeekEnd:
+		offset += rf.size
+	default:
+		return rf.cursor, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return rf.cursor, fmt.Errorf("invalid offset %d", offset)
+	}
+	rf.cursor = offset
+	return offset, nil
+}
+
+func (rf *readAheadFile) Read(buff []byte) (int, error) {
+	rf.Lock()
+	defer rf.Unlock()
+
+	if rf.cursor >= rf.size {
+		return 0, io.EOF
+	}
+
+	callCtx, cancel := interfaceutils.CallContext(rf.ctx)
+	defer cancel()
+
+	var (
+		read       int
+		sequential = rf.cursor == rf.lastEnd
+	)
+	for read != len(buff) && rf.cursor < rf.size {
+		index, data, err := rf.resolve(callCtx, rf.cursor)
+		if err != nil {
+			return read, iferrors.IO(rf.root.String(), err)
+		}
+		if sequential {
+			rf.prefetch(index)
+		}
+
+		segmentOffset := rf.cursor - rf.segments[index].offset
+		if segmentOffset >= int64(len(data)) {
+			return read, iferrors.IO(rf.root.String(),
+				fmt.Errorf("block is smaller than its described size: %w", io.ErrUnexpectedEOF))
+		}
+		copied := copy(buff[read:], data[segmentOffset:])
+		read += copied
+		rf.cursor += int64(copied)
+	}
+	rf.lastEnd = rf.cursor
+	return read, nil
+}
+
+// find returns the index of the segment containing the offset
+func (rf *readAheadFile) find(offset int64) int {
+	return sort.Search(len(rf.segments), func(i int) bool {
+		segment := rf.segments[i]
+		return segment.offset+segment.size > offset
+	})
+}
+
+// resolve returns the data segment containing the offset,
+// fetching (and replacing) internal nodes as they're encountered
+func (rf *readAheadFile) resolve(ctx context.Context, offset int64) (int, []byte, error) {
+	for {
+		index := rf.find(offset)
+		if index == len(rf.segments) {
+			return index, nil, io.ErrUnexpectedEOF
+		}
+		segment := rf.segments[index]
+		if !segment.cid.Defined() {
+			return index, segment.data, nil
+		}
+
+		block, err := rf.blocks.get(ctx, segment.cid)
+		if err != nil {
+			return index, nil, err
+		}
+		if segment.leaf || len(block.links) == 0 {
+			rf.segments[index].leaf = true
+			return index, block.data, nil
+		}
+		rf.expand(index, block)
+	}
+}
+
+// expand replaces the segment with the segments of its block, in place
+func (rf *readAheadFile) expand(index int, block *fileBlock) {
+	var (
+		children = block.segments(rf.segments[index].offset)
+		tail     = rf.segments[index+1:]
+		end      = index + len(children)
+	)
+	// grow the slice to fit the children (the values appended are overwritten below)
+	rf.segments = append(rf.segments, children[1:]...)
+	copy(rf.segments[end:], tail)
+	copy(rf.segments[index:], children)
+}
+
+// prefetch requests the blocks of the segments that follow the index, within the window.
+// Internal nodes that have already been fetched are expanded, so that their children may be requested instead.
+func (rf *readAheadFile) prefetch(index int) {
+	for i := index + 1; i < len(rf.segments) && i <= index+rf.window; i++ {
+		segment := rf.segments[i]
+		if !segment.cid.Defined() {
+			continue
+		}
+		if !segment.leaf {
+			if block, ok := rf.blocks.cached(segment.cid); ok {
+				if len(block.links) == 0 {
+					rf.segments[i].leaf = true
+				} else {
+					rf.expand(i, block)
+					i-- // request the first child instead
+				}
+				continue
+			}
+		}
+		rf.blocks.request(segment.cid)
+	}
+}
@@ -0,0 +1,134 @@
+package ipfscore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	chunker "github.com/ipfs/go-ipfs-chunker"
+	"github.com/ipfs/go-ipfs/filesystem"
+	ipld "github.com/ipfs/go-ipld-format"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	"github.com/ipfs/go-unixfs/importer"
+)
+
+// countingGetter counts how many times each node is requested
+type countingGetter struct {
+	ipld.NodeGetter
+	sync.Mutex
+	gets map[cid.Cid]int
+}
+
+func (cg *countingGetter) Get(ctx context.Context, c cid.Cid) (ipld.Node, error) {
+	cg.Lock()
+	cg.gets[c]++
+	cg.Unlock()
+	return cg.NodeGetter.Get(ctx, c)
+}
+
+func TestReadAhead(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data := make([]byte, 64*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	for _, layout := range []struct {
+		name  string
+		build func(ipld.DAGService, chunker.Splitter) (ipld.Node, error)
+	}{
+		{"balanced", importer.BuildDagFromReader},
+		{"trickle", importer.BuildTrickleDagFromReader},
+	} {
+		t.Run(layout.name, func(t *testing.T) {
+			dagService := mdtest.Mock()
+			root, err := layout.build(dagService, chunker.NewSizeSplitter(bytes.NewReader(data), 256))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			getter := &countingGetter{NodeGetter: dagService, gets: make(map[cid.Cid]int)}
+			ci := &coreInterface{
+				ctx:       ctx,
+				readAhead: 4,
+				blocks:    newBlockCache(ctx, getter, 1024),
+			}
+			open := func(t *testing.T) *readAheadFile {
+				file, ok := ci.newReadAheadFile(root)
+				if !ok {
+					t.Fatal("root was not recognized as a file")
+				}
+				if size, _ := file.Size(); size != int64(len(data)) {
+					t.Fatalf("size mismatch, expected %d got %d", len(data), size)
+				}
+				return file.(*readAheadFile)
+			}
+
+			t.Run("sequential", func(t *testing.T) {
+				for i := 0; i != 2; i++ { // the second file should be served from the cache
+					contents, err := ioutil.ReadAll(open(t))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !bytes.Equal(contents, data) {
+						t.Fatal("contents do not match the source data")
+					}
+				}
+				for c, count := range getter.gets {
+					if count != 1 {
+						t.Errorf("block %s was fetched %d times", c, count)
+					}
+				}
+			})
+
+			t.Run("seek", func(t *testing.T) {
+				file := open(t)
+				buff := make([]byte, 1000)
+				for _, offset := range []int64{40000, 10, 65000, 255, 256, 0} {
+					if _, err := file.Seek(offset, io.SeekStart); err != nil {
+						t.Fatal(err)
+					}
+					read, err := file.Read(buff)
+					if err != nil && err != io.EOF {
+						t.Fatal(err)
+					}
+					end := offset + int64(len(buff))
+					if end > int64(len(data)) {
+						end = int64(len(data))
+					}
+					if !bytes.Equal(buff[:read], data[offset:end]) {
+						t.Fatalf("contents at offset %d do not match the source data", offset)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestReadAheadDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// instances only read ahead when asked to (the core is not used when they don't)
+	ci := NewInterface(ctx, nil, filesystem.IPFS).(*coreInterface)
+	if ci.blocks != nil {
+		t.Errorf("expected read-ahead to be disabled by default, got a window of %d", ci.readAhead)
+	}
+}
+
+func TestBlockCacheSize(t *testing.T) {
+	// sizes that the cache can't be constructed with are raised to the minimum
+	for _, size := range []int{-1, 0} {
+		ci := new(coreInterface)
+		WithBlockCacheSize(size)(ci)
+		if ci.cacheSize != MinBlockCacheSize {
+			t.Errorf("block cache size %d: expected it to be raised to %d, got %d", size, MinBlockCacheSize, ci.cacheSize)
+		}
+		newBlockCache(context.Background(), nil, ci.cacheSize)
+	}
+}
@@ -0,0 +1,241 @@
+package keyfs
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logging "github.com/ipfs/go-log"
+	metrics "github.com/ipfs/go-metrics-interface"
+)
+
+var log = logging.Logger("keyfs")
+
+const (
+	// DefaultReferenceLinger is how long unused references are retained by default.
+	DefaultReferenceLinger = time.Second
+	// DefaultReferenceLimit is the default maximum number of unused references retained (per table).
+	DefaultReferenceLimit = 32
+)
+
+// WithReferenceCache sets how long references to keys are retained after they're last closed,
+// and how many of those unused references may be retained at once.
+// Hosts like FUSE tend to make sequences of single requests (open;close;open;close...)
+// retaining the reference between them saves us from reconstructing it for each request.
+// A linger or limit of 0 disables the cache.
+func WithReferenceCache(linger time.Duration, limit int) Option {
+	return func(ki *keyInterface) { ki.cacheConfig = cacheConfig{linger: linger, limit: limit} }
+}
+
+// ParseReferenceCache parses the string form of the reference cache settings.
+// Either a linger duration, or a duration and a limit separated by a comma (e.g. "5s", "5s,64").
+// (`DefaultReferenceLimit` is used if the limit is not provided)
+func ParseReferenceCache(settings string) (linger time.Duration, limit int, err error) {
+	lingerString, limitString := settings, ""
+	if i := strings.IndexByte(settings, ','); i != -1 {
+		lingerString, limitString = settings[:i], settings[i+1:]
+	}
+
+	if linger, err = time.ParseDuration(lingerString); err != nil || linger < 0 {
+		return 0, 0, fmt.Errorf("invalid reference linger %q: expecting a duration that is not negative", lingerString)
+	}
+
+	limit = DefaultReferenceLimit
+	if limitString != "" {
+		if limit, err = strconv.Atoi(limitString); err != nil || limit < 0 {
+			return 0, 0, fmt.Errorf("invalid reference limit %q: expecting a number that is not negative", limitString)
+		}
+	}
+	return linger, limit, nil
+}
+
+type (
+	cacheConfig struct {
+		linger time.Duration
+		limit  int
+	}
+
+	// cacheMetrics are shared by all tables of an instance
+	cacheMetrics struct {
+		hits, misses metrics.Counter
+	}
+
+	// idleCache retains references that are no longer in use,
+	// until they expire, or are used again.
+	// It is guarded by the lock of the table it belongs to.
+	idleCache struct {
+		cacheConfig
+		*cacheMetrics
+		idle *list.List // of `*refCount`s; least recently used first
+	}
+
+	// refCount tracks the users of a shared reference.
+	// When the last user closes it, the reference becomes idle;
+	// and it's retained within its table's cache until it expires.
+	// The callbacks that commit and close the reference are called without the table's lock,
+	// so that other keys within the table are not blocked by them.
+	refCount struct {
+		table  sync.Locker // the lock of the table that owns the reference
+		cache  *idleCache
+		count  int64
+		idling sync.Mutex // serializes calls to `onIdle`
+
+		// the fields below are guarded by the table's lock
+		element  *list.Element // present while the reference is idle
+		timer    *time.Timer   // present while the reference is idle
+		expired  bool          // set once the reference is removed from the table
+		onIdle   func() error  // called when the count reaches 0
+		onRemove func()        // called (with the table's lock) to remove the reference from the table
+		onClose  func() error  // called after the reference was removed from the table
+	}
+)
+
+func newCacheMetrics(ctx context.Context) *cacheMetrics {
+	return &cacheMetrics{
+		hits: metrics.NewCtx(ctx, "keyfs.references.hits_total",
+			"Number of idle key references that were reused").Counter(),
+		misses: metrics.NewCtx(ctx, "keyfs.references.misses_total",
+			"Number of key references that had to be constructed").Counter(),
+	}
+}
+
+func newIdleCache(config cacheConfig, metrics *cacheMetrics) *idleCache {
+	return &idleCache{cacheConfig: config, cacheMetrics: metrics, idle: list.New()}
+}
+
+// newRefCount returns a counter (starting at 1) for a reference within the table.
+func (c *idleCache) newRefCount(table sync.Locker, onIdle func() error, onRemove func(), onClose func() error) *refCount {
+	return &refCount{
+		table:    table,
+		cache:    c,
+		count:    1,
+		onIdle:   onIdle,
+		onRemove: onRemove,
+		onClose:  onClose,
+	}
+}
+
+// increment may be called by any user of the reference (the count is never 0 for them)
+func (rc *refCount) increment() { atomic.AddInt64(&rc.count, 1) }
+
+// reuse must be called by the table (while locked) when returning an existing reference
+func (rc *refCount) reuse() {
+	if rc.element != nil {
+		rc.cache.revive(rc)
+	}
+	rc.increment()
+}
+
+// decrement returns the error from becoming idle (if the count reaches 0)
+func (rc *refCount) decrement() error {
+	rc.table.Lock()
+	idle := atomic.AddInt64(&rc.count, -1) == 0
+	rc.table.Unlock()
+	if !idle {
+		return nil
+	}
+
+	// the reference remains within the table while it's committed
+	// so that it may be reused during, rather than reconstructed from a stale value
+	rc.idling.Lock()
+	err := rc.onIdle()
+	rc.idling.Unlock()
+
+	rc.table.Lock()
+	if atomic.LoadInt64(&rc.count) != 0 || // reused in the meantime
+		rc.element != nil || rc.expired { // or idled by the user that reused it
+		rc.table.Unlock()
+		return err
+	}
+
+	var expired []*refCount
+	if rc.cache.linger <= 0 || rc.cache.limit <= 0 {
+		rc.cache.remove(rc)
+		expired = []*refCount{rc}
+	} else {
+		expired = rc.cache.park(rc)
+	}
+	rc.table.Unlock()
+
+	if cErr := closeExpired(expired); err == nil {
+		err = cErr
+	}
+	return err
+}
+
+// park adds the reference to the cache,
+// returning the references that were evicted to make room for it
+// (which must be closed by the caller, after unlocking the table)
+func (c *idleCache) park(rc *refCount) (evicted []*refCount) {
+	rc.element = c.idle.PushBack(rc)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(c.linger, func() {
+		rc.table.Lock()
+		if rc.timer != timer { // revived in the meantime
+			rc.table.Unlock()
+			return
+		}
+		c.expire(rc)
+		rc.table.Unlock()
+		if err := closeExpired([]*refCount{rc}); err != nil {
+			log.Error(err)
+		}
+	})
+	rc.timer = timer
+
+	// evict the oldest references if we're over the limit
+	for c.idle.Len() > c.limit {
+		oldest := c.idle.Front().Value.(*refCount)
+		c.expire(oldest)
+		evicted = append(evicted, oldest)
+	}
+	return
+}
+
+func (c *idleCache) revive(rc *refCount) {
+	c.hits.Inc()
+	c.idle.Remove(rc.element)
+	rc.timer.Stop()
+	rc.element, rc.timer = nil, nil
+}
+
+// expire removes an idle reference from the cache, and its table
+// (the caller must close it via `closeExpired`, after unlocking the table)
+func (c *idleCache) expire(rc *refCount) {
+	c.idle.Remove(rc.element)
+	rc.timer.Stop()
+	rc.element, rc.timer = nil, nil
+	c.remove(rc)
+}
+
+func (c *idleCache) remove(rc *refCount) {
+	rc.expired = true
+	rc.onRemove()
+}
+
+// purge expires every idle reference
+// (the caller must close them via `closeExpired`, after unlocking the table)
+func (c *idleCache) purge() (expired []*refCount) {
+	for c.idle.Len() != 0 {
+		oldest := c.idle.Front().Value.(*refCount)
+		c.expire(oldest)
+		expired = append(expired, oldest)
+	}
+	return
+}
+
+// closeExpired closes references that were removed from their table
+func closeExpired(expired []*refCount) (err error) {
+	for _, rc := range expired {
+		if cErr := rc.onClose(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+	return
+}
@@ -0,0 +1,105 @@
+package keyfs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIdleCache(t *testing.T) {
+	const linger = 50 * time.Millisecond
+	var (
+		table   sync.Mutex
+		cache   = newIdleCache(cacheConfig{linger: linger, limit: 1}, newCacheMetrics(context.Background()))
+		expired = make(map[string]bool)
+	)
+	newRef := func(name string) *refCount {
+		return cache.newRefCount(&table,
+			func() error { return nil },
+			func() { expired[name] = true }, // table is locked during this
+			func() error { return nil },
+		)
+	}
+	isExpired := func(name string) bool {
+		table.Lock()
+		defer table.Unlock()
+		return expired[name]
+	}
+
+	t.Run("linger", func(t *testing.T) {
+		ref := newRef("linger")
+		if err := ref.decrement(); err != nil {
+			t.Fatal(err)
+		}
+		if isExpired("linger") {
+			t.Fatal("reference expired before its linger time")
+		}
+		time.Sleep(linger * 4)
+		if !isExpired("linger") {
+			t.Fatal("reference did not expire after its linger time")
+		}
+	})
+
+	t.Run("revive", func(t *testing.T) {
+		ref := newRef("revive")
+		if err := ref.decrement(); err != nil {
+			t.Fatal(err)
+		}
+		table.Lock()
+		ref.reuse()
+		table.Unlock()
+
+		time.Sleep(linger * 4)
+		if isExpired("revive") {
+			t.Fatal("reference expired while in use")
+		}
+		if err := ref.decrement(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		first, second := newRef("first"), newRef("second")
+		if err := first.decrement(); err != nil {
+			t.Fatal(err)
+		}
+		if err := second.decrement(); err != nil {
+			t.Fatal(err)
+		}
+		if !isExpired("first") {
+			t.Fatal("oldest reference was not evicted when the cache exceeded its limit")
+		}
+		if isExpired("second") {
+			t.Fatal("newest reference was evicted")
+		}
+	})
+}
+
+func TestParseReferenceCache(t *testing.T) {
+	for _, test := range []struct {
+		settings string
+		linger   time.Duration
+		limit    int
+		invalid  bool
+	}{
+		{settings: "5s", linger: 5 * time.Second, limit: DefaultReferenceLimit},
+		{settings: "5s,64", linger: 5 * time.Second, limit: 64},
+		{settings: "0", linger: 0, limit: DefaultReferenceLimit},
+		{settings: "-1s", invalid: true},
+		{settings: "5s,-1", invalid: true},
+		{settings: "5s,many", invalid: true},
+	} {
+		linger, limit, err := ParseReferenceCache(test.settings)
+		switch {
+		case test.invalid:
+			if err == nil {
+				t.Errorf("%q: expected an error, got %v %d", test.settings, linger, limit)
+			}
+		case err != nil:
+			t.Errorf("%q: %s", test.settings, err)
+		case linger != test.linger || limit != test.limit:
+			t.Errorf("%q: expected %v %d, got %v %d", test.settings, test.linger, test.limit, linger, limit)
+		}
+	}
+}
@@ -16,11 +16,6 @@ import (
 
 func (ki *keyInterface) createSplit(path string) (self bool, remote filesystem.Interface, fsPath string, err error) {
 	keyName, remainder := splitPath(path)
-	if remainder == "" { // no subpath, request is for us
-		self = true
-		fsPath = keyName
-		return
-	}
 
 	var coreKey coreiface.Key
 	if coreKey, err = ki.checkKey(keyName); err != nil {
@@ -28,6 +23,16 @@ func (ki *keyInterface) createSplit(path string) (self bool, remote filesystem.I
 		return
 	}
 
+	if remainder == "" { // no subpath, request is for us
+		if coreKey != nil {
+			err = iferrors.Exist(path)
+			return
+		}
+		self = true
+		fsPath = keyName
+		return
+	}
+
 	if coreKey == nil { // the request was valid, but not for a key we own
 		fsPath = path
 		remote = ki.ipns // let the host fs handle the requested operation
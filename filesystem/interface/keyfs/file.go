@@ -6,7 +6,10 @@ import (
 	"sync"
 
 	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
 	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
 )
 
 var _ filesystem.File = (*keyFile)(nil)
@@ -19,14 +22,16 @@ var _ filesystem.File = (*keyFile)(nil)
 // as this value is unique per reference while the underlying cursor position may have been modified by another caller.
 type keyFile struct {
 	fileRef
-	cursor int64
-	flags  filesystem.IOFlags // TODO: [cbcd58ed-86e1-4a2f-87ad-5598d4ea1de5]
+	name      string
+	cursor    int64
+	flags     filesystem.IOFlags // operations are gated by the flags this reference was opened with
+	publisher *publisher
 }
 
 type fileRef struct {
 	filesystem.File
 	*sync.Mutex
-	counter refCounter
+	counter *refCount
 	io.Closer
 }
 
@@ -34,6 +39,11 @@ type fileRef struct {
 func (fi fileRef) Close() error { return fi.Closer.Close() }
 
 func (ki *keyInterface) Open(path string, flags filesystem.IOFlags) (filesystem.File, error) {
+	if err := interfaceutils.MakeForOpen(ki, path, flags); err != nil {
+		return nil, err
+	}
+	flags &^= filesystem.IOCreate | filesystem.IOExclusive // handled above
+
 	fs, key, fsPath, deferFunc, err := ki.selectFS(path)
 	if err != nil {
 		return nil, err
@@ -56,6 +66,10 @@ func (kio *keyFile) Size() (int64, error) {
 }
 
 func (kio *keyFile) Read(buff []byte) (int, error) {
+	if !kio.flags.Readable() {
+		return 0, iferrors.Permission(kio.name, interfaceutils.ErrNotReadable)
+	}
+
 	kio.fileRef.Lock()
 	defer kio.fileRef.Unlock()
 	if _, err := kio.fileRef.Seek(kio.cursor, io.SeekStart); err != nil {
@@ -69,9 +83,21 @@ func (kio *keyFile) Read(buff []byte) (int, error) {
 }
 
 func (kio *keyFile) Write(buff []byte) (int, error) {
+	if !kio.flags.Writable() {
+		return 0, iferrors.Permission(kio.name, interfaceutils.ErrNotWritable)
+	}
+
 	kio.fileRef.Lock()
 	defer kio.fileRef.Unlock()
 
+	if kio.flags&filesystem.IOAppend != 0 {
+		end, err := kio.fileRef.Size()
+		if err != nil {
+			return 0, err
+		}
+		kio.cursor = end
+	}
+
 	if _, err := kio.fileRef.Seek(kio.cursor, io.SeekStart); err != nil {
 		return 0, err
 	}
@@ -107,27 +133,49 @@ func (kio *keyFile) Seek(offset int64, whence int) (int64, error) {
 }
 
 func (kio *keyFile) Truncate(size uint64) error {
+	if !kio.flags.Writable() {
+		return iferrors.Permission(kio.name, interfaceutils.ErrNotWritable)
+	}
+
 	kio.fileRef.Lock()
 	defer kio.fileRef.Unlock()
 	return kio.fileRef.Truncate(size)
 }
 
+// Sync commits the file's modifications, and publishes them to its key.
+func (kio *keyFile) Sync() error {
+	kio.fileRef.Lock()
+	if syncer, ok := kio.fileRef.File.(filesystem.Syncer); ok {
+		if err := syncer.Sync(); err != nil {
+			kio.fileRef.Unlock()
+			return err
+		}
+	}
+	kio.fileRef.Unlock()
+	return kio.publisher.flush(kio.name)
+}
+
 // getFile will either construct a `File` representation of the key
 // or fetch an existing one from a table of shared references
 // (handling reference count internally/automatically via keyFile's `Close` method)
 
 func (ki *keyInterface) getFile(key coreiface.Key, flags filesystem.IOFlags) (filesystem.File, error) {
-	// TODO: [cbcd58ed-86e1-4a2f-87ad-5598d4ea1de5]
-	// the `File`s we `Open` should always have full access
-	// but `keyFile` references that are returned should store the provided flags
-	// and gate operations based on it
-	// e.g. keyFile.Write(){if !self.flags.write; return errRO}
-	// right now we don't do this, we just store them
+	// the `File`s we `Open` always have full access (since they're shared)
+	// but the `keyFile` references that are returned gate operations based on the provided flags
 
 	keyName := key.Name()
 	opener := func() (filesystem.File, error) {
+		callCtx, cancel := interfaceutils.CallContext(ki.ctx)
+		defer cancel()
+		resolved, err := ki.core.ResolvePath(callCtx, key.Path())
+		if err != nil {
+			return nil, iferrors.IO(keyName, err)
+		}
+		root := corepath.IpfsPath(resolved.Cid())
+		ki.publisher.seen(keyName, root)
+
 		ki.ufs.SetModifier(ki.publisherGenUFS(keyName))
-		return ki.ufs.Open(key.Path().String(), filesystem.IOReadWrite)
+		return ki.ufs.Open(root.String(), filesystem.IOReadWrite)
 	}
 
 	fileRef, err := ki.references.getFileRef(key.Name(), opener)
@@ -136,5 +184,12 @@ func (ki *keyInterface) getFile(key coreiface.Key, flags filesystem.IOFlags) (fi
 	}
 
 	// return a wrapper around it with a unique cursor and flagset
-	return &keyFile{fileRef: fileRef, flags: flags}, nil
+	file := &keyFile{fileRef: fileRef, name: keyName, flags: flags, publisher: ki.publisher}
+	if flags&filesystem.IOTruncate != 0 {
+		if err := file.Truncate(0); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return file, nil
 }
@@ -0,0 +1,90 @@
+package keyfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	fserrors "github.com/ipfs/go-ipfs/filesystem/errors"
+)
+
+func TestOpenFlags(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fs := NewInterface(ctx, newTestCore(), WithPublishPolicy(PublishImmediately))
+	defer fs.Close()
+
+	if err := fs.MakeDirectory("/dir"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectKind := func(t *testing.T, err error, kind fserrors.Kind) {
+		t.Helper()
+		var fsErr fserrors.Error
+		if !errors.As(err, &fsErr) || fsErr.Kind() != kind {
+			t.Errorf("expected error of kind %v, got: %v", kind, err)
+		}
+	}
+
+	// (names that aren't our keys are relayed to IPNS, so only subpaths are checked here)
+	_, err := fs.Open("/dir/missing", filesystem.IOWriteOnly)
+	expectKind(t, err, fserrors.NotExist)
+
+	for _, test := range []struct{ name, path string }{
+		{"key", "/file"},               // backed by the key's UFS node
+		{"key directory", "/dir/file"}, // backed by the key's MFS root
+	} {
+		path := test.path
+		t.Run(test.name, func(t *testing.T) {
+			writeFile(t, fs, path, filesystem.IOWriteOnly|filesystem.IOCreate, "data")
+			expectContent(t, fs, path, "data")
+
+			_, err := fs.Open(path, filesystem.IOWriteOnly|filesystem.IOCreate|filesystem.IOExclusive)
+			expectKind(t, err, fserrors.Exist)
+
+			// appends ignore the cursor
+			file, err := fs.Open(path, filesystem.IOWriteOnly|filesystem.IOAppend)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := file.Write([]byte("more")); err != nil {
+				t.Fatal(err)
+			}
+			if err := file.Close(); err != nil {
+				t.Fatal(err)
+			}
+			expectContent(t, fs, path, "datamore")
+
+			// access modes are enforced
+			file, err = fs.Open(path, filesystem.IOReadOnly)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = file.Write([]byte("x"))
+			expectKind(t, err, fserrors.Permission)
+			expectKind(t, file.Truncate(0), fserrors.Permission)
+			if err := file.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			file, err = fs.Open(path, filesystem.IOWriteOnly)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = file.Read(make([]byte, 1))
+			expectKind(t, err, fserrors.Permission)
+			if err := file.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			writeFile(t, fs, path, filesystem.IOWriteOnly|filesystem.IOTruncate, "new")
+			expectContent(t, fs, path, "new")
+		})
+	}
+}
@@ -49,3 +49,57 @@ func (ki *keyInterface) ExtractLink(path string) (string, error) {
 	}
 	return fs.ExtractLink(fsPath)
 }
+
+func (ki *keyInterface) ExtendedAttributes(path string) (map[string]string, error) {
+	if path == "/" {
+		return nil, nil
+	}
+
+	fs, key, fsPath, deferFunc, err := ki.selectFS(path)
+	if err != nil {
+		return nil, iferrors.Other(path, err)
+	}
+	defer deferFunc()
+
+	if key == nil { // IPNS proxy
+		attributer, ok := fs.(filesystem.ExtendedAttributer)
+		if !ok {
+			return nil, iferrors.UnsupportedRequest()
+		}
+		return attributer.ExtendedAttributes(fsPath)
+	}
+
+	callCtx, cancel := interfaceutils.CallContext(ki.ctx)
+	defer cancel()
+
+	var (
+		attrs       map[string]string
+		contentPath = key.Path().String()
+	)
+	if fs == ki { // key references a file or link directly
+		if attrs, err = ki.core.ExtendedAttributes(callCtx, key.Path()); err != nil {
+			return nil, err
+		}
+	} else { // key references a directory; use the (possibly unpublished) node from its root
+		attributer, ok := fs.(filesystem.ExtendedAttributer)
+		if !ok {
+			return nil, iferrors.UnsupportedRequest()
+		}
+		if attrs, err = attributer.ExtendedAttributes(fsPath); err != nil {
+			return nil, err
+		}
+		if fsPath != "" && fsPath != "/" {
+			contentPath += fsPath
+		}
+	}
+	attrs[filesystem.XattrPath] = contentPath
+
+	// IPNS attributes
+	// (the value is omitted if the name can't be resolved; the other attributes are still valid)
+	attrs[filesystem.XattrIPNSName] = key.Path().String()
+	if value, err := ki.core.ResolvePath(callCtx, key.Path()); err == nil {
+		attrs[filesystem.XattrIPNSValue] = value.String()
+	}
+
+	return attrs, nil
+}
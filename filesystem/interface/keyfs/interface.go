@@ -23,58 +23,119 @@ type keyInterface struct {
 	ufs        ufs.UFS              // key `File` constructor
 	references referenceTable       // the table which manages (shared) key `File` and `Interface` references
 	ipns       filesystem.Interface // any requests to keys we don't own get proxied to ipns
+	publisher  *publisher           // coalesces modifications to keys
+
+	cacheConfig  cacheConfig // how references are retained after they're last closed
+	cacheMetrics *cacheMetrics
 }
 
 // TODO: docs
-func NewInterface(ctx context.Context, core coreiface.CoreAPI) filesystem.Interface {
-	return &keyInterface{
-		ctx:        ctx,
-		core:       &interfaceutils.CoreExtended{CoreAPI: core},
-		ufs:        ufs.NewInterface(ctx, core),
-		ipns:       ipfscore.NewInterface(ctx, core, filesystem.IPNS),
-		references: newReferenceTable(),
+func NewInterface(ctx context.Context, core coreiface.CoreAPI, options ...Option) filesystem.Interface {
+	ki := &keyInterface{
+		ctx:       ctx,
+		core:      &interfaceutils.CoreExtended{CoreAPI: core},
+		ufs:       ufs.NewInterface(ctx, core),
+		ipns:      ipfscore.NewInterface(ctx, core, filesystem.IPNS),
+		publisher: newPublisher(ctx, core),
+		cacheConfig: cacheConfig{
+			linger: DefaultReferenceLinger,
+			limit:  DefaultReferenceLimit,
+		},
+		cacheMetrics: newCacheMetrics(ctx),
+	}
+	for _, option := range options {
+		option(ki)
 	}
+	// pending modifications are published when the last reference to a key is closed
+	ki.references = newReferenceTable(ki.publisher.flush, ki.cacheConfig, ki.cacheMetrics)
+	return ki
 }
 
 func (ki *keyInterface) ID() filesystem.ID { return filesystem.KeyFS }
-func (ki *keyInterface) Close() error      { return nil } // TODO: cascade close for all open references
+
+// Close closes the idle references, and publishes the pending modifications of every key.
+func (ki *keyInterface) Close() error {
+	err := ki.references.purge()
+	if pErr := ki.publisher.flushAll(); err == nil {
+		err = pErr
+	}
+	return err
+}
+
+// Sync commits the modifications of all open keys, and publishes them.
+func (ki *keyInterface) Sync() error {
+	err := ki.references.syncAll()
+	if pErr := ki.publisher.flushAll(); err == nil {
+		err = pErr
+	}
+	return err
+}
+func (ki *keyInterface) StorageStat() (*filesystem.StorageStat, error) {
+	callCtx, cancel := interfaceutils.CallContext(ki.ctx)
+	defer cancel()
+	return ki.core.StorageStat(callCtx)
+}
 
 // TODO: having both of these is dumb; do something about it
 func (ki *keyInterface) publisherGenUFS(keyName string) ufs.ModifiedFunc {
 	return func(nd ipld.Node) error {
-		return localPublish(ki.ctx, ki.core, keyName, corepath.IpfsPath(nd.Cid()))
+		return ki.publisher.modified(keyName, corepath.IpfsPath(nd.Cid()))
 	}
 }
 
 // TODO: having both of these is dumb; do something about it
 func (ki *keyInterface) publisherGenMFS(keyName string) gomfs.PubFunc {
-	return func(ctx context.Context, cid cid.Cid) error {
-		return localPublish(ctx, ki.core, keyName, corepath.IpfsPath(cid))
+	return func(_ context.Context, cid cid.Cid) error {
+		return ki.publisher.modified(keyName, corepath.IpfsPath(cid))
 	}
 }
 
-// TODO: test this; quick port for now
-// cross FS requests are going to be busted if we get them (/key -> /other-key/newhome)
+// Rename moves nodes within and between keys.
+// Keys may be renamed, moved into another key's root, or made from a node within another key's root.
+// Nodes are moved between keys by reference (their data is not copied),
+// and both of the affected keys are published.
 func (ki *keyInterface) Rename(oldName, newName string) error {
-	keyName, remainder := splitPath(oldName)
-	if remainder == "" { // rename on key itself
-		callCtx, cancel := interfaceutils.CallContext(ki.ctx)
-		defer cancel()
-		_, _, err := ki.core.Key().Rename(callCtx, keyName, newName[1:])
+	oldKey, oldRemainder := splitPath(oldName)
+	newKey, newRemainder := splitPath(newName)
+
+	switch {
+	case oldRemainder == "" && newRemainder == "":
+		return ki.renameKey(oldName, newName)
+	case oldKey == newKey && oldRemainder == "":
+		return iferrors.UnsupportedItem(newName, errKeyIntoSelf)
+	case oldKey == newKey && newRemainder != "": // within the same root
+		fs, _, _, deferFunc, err := ki.selectFS(oldName)
 		if err != nil {
-			return iferrors.IO(newName, err)
+			return err
 		}
-		return nil
+		defer deferFunc()
+		return fs.Rename(oldRemainder, newRemainder)
+	default:
+		return ki.relink(oldName, newName)
 	}
+}
 
-	// subrequest
-	fs, _, _, deferFunc, err := ki.selectFS(oldName)
+func (ki *keyInterface) renameKey(oldName, newName string) error {
+	keyName, newKeyName := oldName[1:], newName[1:]
+	existing, err := ki.checkKey(newKeyName)
 	if err != nil {
-		return err
+		return iferrors.IO(newName, err)
+	}
+	if existing != nil {
+		return ki.existErr(newName, existing)
 	}
-	defer deferFunc()
-
-	_, subNewName := splitPath(newName)
 
-	return fs.Rename(remainder, subNewName)
+	// publish pending modifications while the key still has its old name
+	if err := ki.publisher.flush(keyName); err != nil {
+		return err
+	}
+	if err := ki.references.invalidate(keyName); err != nil {
+		return err
+	}
+	callCtx, cancel := interfaceutils.CallContext(ki.ctx)
+	defer cancel()
+	if _, _, err := ki.core.Key().Rename(callCtx, keyName, newKeyName); err != nil {
+		return iferrors.IO(newName, err)
+	}
+	return nil
 }
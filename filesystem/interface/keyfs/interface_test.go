@@ -0,0 +1,334 @@
+package keyfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipfs/filesystem"
+	ipld "github.com/ipfs/go-ipld-format"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	coreoptions "github.com/ipfs/interface-go-ipfs-core/options"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// testCore is an in memory `CoreAPI`, providing only what the key file system uses
+// (the methods it doesn't provide will panic)
+type testCore struct {
+	coreiface.CoreAPI
+	dag ipld.DAGService
+
+	sync.Mutex
+	keys       map[string]*testKey       // by name
+	keyCount   int                       // of generated keys (for their IDs)
+	values     map[peer.ID]corepath.Path // published values
+	publishes  map[string]int            // number of publishes, by key name
+	resolveErr error                     // if set, names fail to resolve with it
+	removeErr  error                     // if set, keys fail to be removed with it
+	onPublish  func(keyName string)      // if set, called before each publish (without the lock)
+}
+
+type (
+	testKey struct {
+		name string
+		id   peer.ID
+	}
+	testKeys  struct{ *testCore }
+	testNames struct{ *testCore }
+	testDag   struct{ ipld.DAGService }
+)
+
+func newTestCore() *testCore {
+	return &testCore{
+		dag:       mdtest.Mock(),
+		keys:      make(map[string]*testKey),
+		values:    make(map[peer.ID]corepath.Path),
+		publishes: make(map[string]int),
+	}
+}
+
+func (tk *testKey) Name() string        { return tk.name }
+func (tk *testKey) ID() peer.ID         { return tk.id }
+func (tk *testKey) Path() corepath.Path { return corepath.New("/ipns/" + string(tk.id)) }
+
+func (tc *testCore) Key() coreiface.KeyAPI        { return testKeys{tc} }
+func (tc *testCore) Name() coreiface.NameAPI      { return testNames{tc} }
+func (tc *testCore) Dag() coreiface.APIDagService { return testDag{tc.dag} }
+func (td testDag) Pinning() ipld.NodeAdder        { return td.DAGService }
+func (tc *testCore) WithOptions(...coreoptions.ApiOption) (coreiface.CoreAPI, error) {
+	return tc, nil
+}
+
+func (tc *testCore) ResolvePath(ctx context.Context, path corepath.Path) (corepath.Resolved, error) {
+	if resolved, ok := path.(corepath.Resolved); ok {
+		return resolved, nil
+	}
+
+	components := strings.Split(strings.Trim(path.String(), "/"), "/")
+	if len(components) < 2 {
+		return nil, fmt.Errorf("invalid path %q", path)
+	}
+	if components[0] == "ipns" {
+		tc.Lock()
+		value, ok := tc.values[peer.ID(components[1])]
+		err := tc.resolveErr
+		tc.Unlock()
+		switch {
+		case err != nil:
+			return nil, err
+		case !ok:
+			return nil, fmt.Errorf("could not resolve name %q", components[1])
+		}
+		components = append(strings.Split(strings.Trim(value.String(), "/"), "/"), components[2:]...)
+	}
+	if components[0] != "ipfs" {
+		return nil, fmt.Errorf("unexpected namespace in path %q", path)
+	}
+
+	c, err := cid.Decode(components[1])
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range components[2:] {
+		node, err := tc.dag.Get(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		link, _, err := node.ResolveLink([]string{name})
+		if err != nil {
+			return nil, err
+		}
+		c = link.Cid
+	}
+	return corepath.IpfsPath(c), nil
+}
+
+func (tc *testCore) ResolveNode(ctx context.Context, path corepath.Path) (ipld.Node, error) {
+	resolved, err := tc.ResolvePath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return tc.dag.Get(ctx, resolved.Cid())
+}
+
+func (tc *testCore) publishCount(keyName string) int {
+	tc.Lock()
+	defer tc.Unlock()
+	return tc.publishes[keyName]
+}
+
+func (tc *testCore) setResolveErr(err error) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.resolveErr = err
+}
+
+func (tc *testCore) setRemoveErr(err error) {
+	tc.Lock()
+	defer tc.Unlock()
+	tc.removeErr = err
+}
+
+func (tc *testCore) hasKey(keyName string) bool {
+	tc.Lock()
+	defer tc.Unlock()
+	_, ok := tc.keys[keyName]
+	return ok
+}
+
+func (tk testKeys) Generate(_ context.Context, name string, _ ...coreoptions.KeyGenerateOption) (coreiface.Key, error) {
+	tk.Lock()
+	defer tk.Unlock()
+	if _, exists := tk.keys[name]; exists {
+		return nil, fmt.Errorf("key with name '%s' already exists", name)
+	}
+	tk.keyCount++
+	key := &testKey{name: name, id: peer.ID(fmt.Sprintf("key-%d", tk.keyCount))}
+	tk.keys[name] = key
+	return key, nil
+}
+
+func (tk testKeys) Rename(_ context.Context, oldName, newName string, _ ...coreoptions.KeyRenameOption) (coreiface.Key, bool, error) {
+	tk.Lock()
+	defer tk.Unlock()
+	key, ok := tk.keys[oldName]
+	if !ok {
+		return nil, false, fmt.Errorf("no key named %s was found", oldName)
+	}
+	if _, exists := tk.keys[newName]; exists {
+		return nil, false, fmt.Errorf("key by that name already exists")
+	}
+	delete(tk.keys, oldName)
+	renamed := &testKey{name: newName, id: key.id}
+	tk.keys[newName] = renamed
+	return renamed, false, nil
+}
+
+func (tk testKeys) List(context.Context) ([]coreiface.Key, error) {
+	tk.Lock()
+	defer tk.Unlock()
+	names := make([]string, 0, len(tk.keys))
+	for name := range tk.keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	keys := make([]coreiface.Key, len(names))
+	for i, name := range names {
+		keys[i] = tk.keys[name]
+	}
+	return keys, nil
+}
+
+func (tk testKeys) Self(context.Context) (coreiface.Key, error) {
+	return nil, errors.New("the test node has no identity")
+}
+
+func (tk testKeys) Remove(_ context.Context, name string) (coreiface.Key, error) {
+	tk.Lock()
+	defer tk.Unlock()
+	if tk.removeErr != nil {
+		return nil, tk.removeErr
+	}
+	key, ok := tk.keys[name]
+	if !ok {
+		return nil, fmt.Errorf("no key named %s was found", name)
+	}
+	delete(tk.keys, name)
+	return key, nil
+}
+
+func (tn testNames) Publish(_ context.Context, path corepath.Path, opts ...coreoptions.NamePublishOption) (coreiface.IpnsEntry, error) {
+	settings, err := coreoptions.NamePublishOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	tn.Lock()
+	onPublish := tn.onPublish
+	tn.Unlock()
+	if onPublish != nil {
+		onPublish(settings.Key)
+	}
+
+	tn.Lock()
+	defer tn.Unlock()
+	key, ok := tn.keys[settings.Key]
+	if !ok {
+		return nil, fmt.Errorf("no key named %s was found", settings.Key)
+	}
+	tn.values[key.id] = path
+	tn.publishes[settings.Key]++
+	return nil, nil
+}
+
+func (tn testNames) Resolve(ctx context.Context, name string, _ ...coreoptions.NameResolveOption) (corepath.Path, error) {
+	return tn.ResolvePath(ctx, corepath.New(name))
+}
+
+func (tn testNames) Search(context.Context, string, ...coreoptions.NameResolveOption) (<-chan coreiface.IpnsResult, error) {
+	return nil, errors.New("search is not supported by the test node")
+}
+
+func writeFile(t *testing.T, fs filesystem.Interface, path string, flags filesystem.IOFlags, data string) {
+	t.Helper()
+	file, err := fs.Open(path, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectContent(t *testing.T, fs filesystem.Interface, path, expected string) {
+	t.Helper()
+	file, err := fs.Open(path, filesystem.IOReadOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != expected {
+		t.Errorf("%s: content does not match\n\twanted: %q\n\tgot: %q", path, expected, data)
+	}
+}
+
+func TestExtendedAttributes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	core := newTestCore()
+	fs := NewInterface(ctx, core, WithPublishPolicy(PublishImmediately))
+	defer fs.Close()
+	attributer := fs.(filesystem.ExtendedAttributer)
+
+	if err := fs.Make("/file"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.MakeDirectory("/dir"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, fs, "/dir/sub", filesystem.IOWriteOnly|filesystem.IOCreate, "data")
+
+	t.Run("key", func(t *testing.T) {
+		attrs, err := attributer.ExtendedAttributes("/file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := core.keys["file"]
+		value := core.values[key.id]
+		for attr, expected := range map[string]string{
+			filesystem.XattrCID:       value.(corepath.Resolved).Cid().String(),
+			filesystem.XattrPath:      key.Path().String(),
+			filesystem.XattrIPNSName:  key.Path().String(),
+			filesystem.XattrIPNSValue: value.String(),
+		} {
+			if attrs[attr] != expected {
+				t.Errorf("%s: expected %q, got %q", attr, expected, attrs[attr])
+			}
+		}
+	})
+
+	t.Run("unresolvable key", func(t *testing.T) {
+		// hold the key's root, so that it's not resolved again
+		file, err := fs.Open("/dir/sub", filesystem.IOReadOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		core.setResolveErr(errors.New("name is unreachable"))
+		defer core.setResolveErr(nil)
+
+		attrs, err := attributer.ExtendedAttributes("/dir/sub")
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := core.keys["dir"]
+		if expected := key.Path().String() + "/sub"; attrs[filesystem.XattrPath] != expected {
+			t.Errorf("expected path %q, got %q", expected, attrs[filesystem.XattrPath])
+		}
+		if attrs[filesystem.XattrIPNSName] != key.Path().String() {
+			t.Errorf("expected name %q, got %q", key.Path(), attrs[filesystem.XattrIPNSName])
+		}
+		if attrs[filesystem.XattrCID] == "" {
+			t.Error("node attributes are missing")
+		}
+		if value, ok := attrs[filesystem.XattrIPNSValue]; ok {
+			t.Errorf("unresolvable name has a value: %q", value)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package keyfs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	dag "github.com/ipfs/go-merkledag"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+func (ki *keyInterface) Chmod(path string, mode uint32) error {
+	return ki.setMetadata(path, interfaceutils.UFSMetadata{Mode: mode & 07777, HasMode: true})
+}
+
+func (ki *keyInterface) Chtimes(path string, mtime time.Time) error {
+	return ki.setMetadata(path, interfaceutils.UFSMetadata{MTime: mtime, HasMTime: true})
+}
+
+func (ki *keyInterface) setMetadata(path string, metadata interfaceutils.UFSMetadata) error {
+	fs, key, fsPath, deferFunc, err := ki.selectFS(path)
+	if err != nil {
+		return err
+	}
+	defer deferFunc()
+
+	switch {
+	case key == nil: // root or IPNS proxy
+		return iferrors.ReadOnly(path)
+
+	case fs == ki: // key references a file or link directly
+		return ki.setKeyMetadata(key, metadata)
+
+	default: // key references a directory; modify the node within its root
+		modifier, ok := fs.(filesystem.MetadataModifier)
+		if !ok {
+			return iferrors.UnsupportedRequest()
+		}
+		if metadata.HasMode {
+			return modifier.Chmod(fsPath, metadata.Mode)
+		}
+		return modifier.Chtimes(fsPath, metadata.MTime)
+	}
+}
+
+// setKeyMetadata stores the metadata in a copy of the key's node, and publishes it to the key
+func (ki *keyInterface) setKeyMetadata(key coreiface.Key, metadata interfaceutils.UFSMetadata) error {
+	// the key's node must be current before we derive a new one from it
+	if err := ki.publisher.flush(key.Name()); err != nil {
+		return err
+	}
+
+	callCtx, cancel := interfaceutils.CallContext(ki.ctx)
+	defer cancel()
+
+	ipldNode, err := ki.core.ResolveNode(callCtx, key.Path())
+	if err != nil {
+		return err
+	}
+
+	protoNode, ok := ipldNode.(*dag.ProtoNode)
+	if !ok {
+		return iferrors.UnsupportedItem(key.Name(),
+			fmt.Errorf("node type %T can not hold metadata", ipldNode),
+		)
+	}
+
+	nodeData, err := interfaceutils.SetUFSMetadata(protoNode.Data(), metadata)
+	if err != nil {
+		return iferrors.Other(key.Name(), err)
+	}
+
+	modifiedNode := protoNode.Copy().(*dag.ProtoNode)
+	modifiedNode.SetData(nodeData)
+
+	if err := ki.core.Dag().Add(callCtx, modifiedNode); err != nil {
+		return iferrors.IO(key.Name(), err)
+	}
+
+	return ki.publisher.publish(key.Name(), corepath.IpfsPath(modifiedNode.Cid()))
+}
@@ -0,0 +1,246 @@
+package keyfs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// PublishPolicy determines when modifications to a key are published to IPNS.
+// Positive values are a quiet period; modifications are published
+// once the key has not been modified for that duration.
+// Regardless of policy, pending modifications are published
+// when the last reference to the key is closed (or synced).
+type PublishPolicy time.Duration
+
+const (
+	// PublishImmediately publishes every modification as it happens.
+	PublishImmediately PublishPolicy = 0
+	// PublishOnClose only publishes when the key is closed (or synced).
+	PublishOnClose PublishPolicy = -1
+
+	DefaultPublishPolicy = PublishPolicy(2 * time.Second)
+
+	publishImmediatelyString = "immediate"
+	publishOnCloseString     = "close"
+)
+
+// ParsePublishPolicy parses the string form of a policy.
+// Either "immediate", "close", or a duration (e.g. "500ms", "10s").
+func ParsePublishPolicy(policy string) (PublishPolicy, error) {
+	switch policy {
+	case publishImmediatelyString:
+		return PublishImmediately, nil
+	case publishOnCloseString:
+		return PublishOnClose, nil
+	}
+	delay, err := time.ParseDuration(policy)
+	if err != nil {
+		return 0, fmt.Errorf("invalid publish policy %q: expecting %q, %q, or a duration",
+			policy, publishImmediatelyString, publishOnCloseString)
+	}
+	if delay <= 0 {
+		return 0, fmt.Errorf("invalid publish policy %q: duration must be positive", policy)
+	}
+	return PublishPolicy(delay), nil
+}
+
+func (policy PublishPolicy) String() string {
+	switch {
+	case policy == PublishImmediately:
+		return publishImmediatelyString
+	case policy < 0:
+		return publishOnCloseString
+	default:
+		return time.Duration(policy).String()
+	}
+}
+
+// Option alters the construction of the key file system.
+type Option func(*keyInterface)
+
+// WithPublishPolicy sets the policy used when publishing modifications to keys.
+// (`DefaultPublishPolicy` is used if not provided)
+func WithPublishPolicy(policy PublishPolicy) Option {
+	return func(ki *keyInterface) { ki.publisher.policy = policy }
+}
+
+// publisher coalesces modifications to keys,
+// publishing only their latest root, according to its policy.
+type publisher struct {
+	ctx    context.Context
+	core   coreiface.CoreAPI
+	policy PublishPolicy
+
+	sync.Mutex
+	keys map[string]*keyPublisher
+}
+
+type keyPublisher struct {
+	sync.Mutex               // guards the fields below
+	pending    corepath.Path // the latest root that has not been published yet (if any)
+	published  corepath.Path // the root that the key is known to have (if any)
+	timer      *time.Timer   // the quiet period timer (if any)
+	err        error         // from a delayed publish; returned by the next flush
+	publishing sync.Mutex    // only 1 publish may be in flight (per key)
+}
+
+func newPublisher(ctx context.Context, core coreiface.CoreAPI) *publisher {
+	return &publisher{
+		ctx:    ctx,
+		core:   core,
+		policy: DefaultPublishPolicy,
+		keys:   make(map[string]*keyPublisher),
+	}
+}
+
+func (pub *publisher) key(keyName string) *keyPublisher {
+	pub.Lock()
+	defer pub.Unlock()
+	kp, ok := pub.keys[keyName]
+	if !ok {
+		kp = new(keyPublisher)
+		pub.keys[keyName] = kp
+	}
+	return kp
+}
+
+// modified records the new root of the key,
+// publishing it when dictated by the policy.
+func (pub *publisher) modified(keyName string, root corepath.Path) error {
+	kp := pub.key(keyName)
+	kp.Lock()
+	if kp.isPublished(root) {
+		kp.Unlock()
+		return nil
+	}
+	kp.pending = root
+	if pub.policy == PublishImmediately {
+		kp.Unlock()
+		return pub.flush(keyName)
+	}
+
+	if delay := time.Duration(pub.policy); delay > 0 {
+		if kp.timer == nil {
+			kp.timer = time.AfterFunc(delay, func() {
+				if err := pub.flush(keyName); err != nil {
+					kp.Lock()
+					kp.err = err
+					kp.Unlock()
+				}
+			})
+		} else {
+			kp.timer.Reset(delay)
+		}
+	}
+	kp.Unlock()
+	return nil
+}
+
+// publish publishes the root to the key now, superseding any pending modifications.
+func (pub *publisher) publish(keyName string, root corepath.Path) error {
+	kp := pub.key(keyName)
+	kp.Lock()
+	if kp.isPublished(root) {
+		kp.pending = nil
+		kp.Unlock()
+		return nil
+	}
+	kp.pending = root
+	kp.Unlock()
+	return pub.flush(keyName)
+}
+
+// seen records the root that the key currently has,
+// so that it's not republished needlessly.
+func (pub *publisher) seen(keyName string, root corepath.Path) {
+	kp := pub.key(keyName)
+	kp.Lock()
+	defer kp.Unlock()
+	if kp.pending == nil {
+		kp.published = root
+	}
+}
+
+// isPublished returns true if the root is already the key's value,
+// and there are no other modifications pending
+// (kp must be locked)
+func (kp *keyPublisher) isPublished(root corepath.Path) bool {
+	return kp.pending == nil && kp.published != nil &&
+		kp.published.String() == root.String()
+}
+
+// flush publishes the pending root of the key (if any).
+func (pub *publisher) flush(keyName string) error {
+	kp := pub.key(keyName)
+	kp.publishing.Lock()
+	defer kp.publishing.Unlock()
+
+	kp.Lock()
+	root, err := kp.pending, kp.err
+	kp.pending, kp.err = nil, nil
+	if kp.timer != nil {
+		kp.timer.Stop()
+	}
+	kp.Unlock()
+
+	if root == nil {
+		return err
+	}
+
+	callCtx, cancel := interfaceutils.CallContext(pub.ctx)
+	defer cancel()
+	if err := localPublish(callCtx, pub.core, keyName, root); err != nil {
+		kp.Lock()
+		if kp.pending == nil { // retain the root so that it may be retried (unless it was superseded)
+			kp.pending = root
+		}
+		kp.Unlock()
+		return err
+	}
+
+	kp.Lock()
+	kp.published = root
+	kp.Unlock()
+	return nil
+}
+
+// forget discards the pending root of the key (if any).
+func (pub *publisher) forget(keyName string) {
+	pub.Lock()
+	kp, ok := pub.keys[keyName]
+	delete(pub.keys, keyName)
+	pub.Unlock()
+	if !ok {
+		return
+	}
+
+	kp.Lock()
+	defer kp.Unlock()
+	kp.pending, kp.err = nil, nil
+	if kp.timer != nil {
+		kp.timer.Stop()
+	}
+}
+
+// flushAll publishes the pending roots of all keys.
+func (pub *publisher) flushAll() (err error) {
+	pub.Lock()
+	keyNames := make([]string, 0, len(pub.keys))
+	for keyName := range pub.keys {
+		keyNames = append(keyNames, keyName)
+	}
+	pub.Unlock()
+
+	for _, keyName := range keyNames {
+		if fErr := pub.flush(keyName); fErr != nil && err == nil {
+			err = fErr
+		}
+	}
+	return
+}
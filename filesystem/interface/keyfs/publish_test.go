@@ -0,0 +1,143 @@
+package keyfs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+)
+
+func TestPublishPolicy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	writeAll := func(t *testing.T, file filesystem.File, chunks ...string) {
+		t.Helper()
+		for _, chunk := range chunks {
+			if _, err := file.Write([]byte(chunk)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	expectPublishes := func(t *testing.T, core *testCore, keyName string, expected int) {
+		t.Helper()
+		if count := core.publishCount(keyName); count != expected {
+			t.Errorf("expected %d publishes of %q, got %d", expected, keyName, count)
+		}
+	}
+
+	t.Run("on close", func(t *testing.T) {
+		core := newTestCore()
+		fs := NewInterface(ctx, core, WithPublishPolicy(PublishOnClose))
+		defer fs.Close()
+		if err := fs.Make("/file"); err != nil {
+			t.Fatal(err)
+		}
+		initial := core.publishCount("file")
+
+		file, err := fs.Open("/file", filesystem.IOWriteOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeAll(t, file, "a", "b", "c")
+		expectPublishes(t, core, "file", initial)
+
+		// modifications are coalesced into a single publish
+		if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+		expectPublishes(t, core, "file", initial+1)
+		expectContent(t, fs, "/file", "abc")
+	})
+
+	t.Run("quiet period", func(t *testing.T) {
+		const quietPeriod = 50 * time.Millisecond
+		core := newTestCore()
+		fs := NewInterface(ctx, core, WithPublishPolicy(PublishPolicy(quietPeriod)))
+		defer fs.Close()
+		if err := fs.Make("/file"); err != nil {
+			t.Fatal(err)
+		}
+		initial := core.publishCount("file")
+
+		file, err := fs.Open("/file", filesystem.IOWriteOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		writeAll(t, file, "a", "b")
+		expectPublishes(t, core, "file", initial)
+
+		// published while still open, once the key is quiet
+		time.Sleep(quietPeriod * 4)
+		expectPublishes(t, core, "file", initial+1)
+
+		// and not again on close, if nothing changed since
+		if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+		expectPublishes(t, core, "file", initial+1)
+	})
+
+	t.Run("table is not held while publishing", func(t *testing.T) {
+		core := newTestCore()
+		fs := NewInterface(ctx, core, WithPublishPolicy(PublishOnClose))
+		defer fs.Close()
+		for _, name := range []string{"/slow", "/other"} {
+			if err := fs.Make(name); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var (
+			publishing = make(chan struct{})
+			release    = make(chan struct{})
+		)
+		core.Lock()
+		core.onPublish = func(keyName string) {
+			if keyName == "slow" {
+				close(publishing)
+				<-release
+			}
+		}
+		core.Unlock()
+
+		file, err := fs.Open("/slow", filesystem.IOWriteOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeAll(t, file, "data")
+		closed := make(chan error, 1)
+		go func() { closed <- file.Close() }()
+		<-publishing
+
+		opened := make(chan error, 1)
+		go func() {
+			other, err := fs.Open("/other", filesystem.IOReadOnly)
+			if err == nil {
+				err = other.Close()
+			}
+			opened <- err
+		}()
+		var blocked bool
+		select {
+		case err = <-opened:
+		case <-time.After(time.Second):
+			blocked = true
+		}
+
+		close(release)
+		if blocked {
+			t.Error("opening a key was blocked by the publish of another")
+			err = <-opened
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := <-closed; err != nil {
+			t.Fatal(err)
+		}
+	})
+}
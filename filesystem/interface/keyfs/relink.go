@@ -0,0 +1,136 @@
+package keyfs
+
+import (
+	"errors"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-unixfs"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+var (
+	_ filesystem.Relinker = (*keyInterface)(nil)
+
+	errNotOwned    = errors.New("key is not owned by this node")
+	errKeyIntoSelf = errors.New("a key can not be moved into itself")
+)
+
+// Node returns the root node of a key, or a node within the key's root.
+func (ki *keyInterface) Node(path string) (ipld.Node, error) {
+	if path == "/" {
+		return nil, iferrors.Permission(path, errNotOwned)
+	}
+
+	fs, key, fsPath, deferFunc, err := ki.selectFS(path)
+	if err != nil {
+		return nil, err
+	}
+	defer deferFunc()
+
+	switch fs {
+	case ki.ipns:
+		return nil, iferrors.Permission(path, errNotOwned)
+	case ki: // file and link keys
+		callCtx, cancel := interfaceutils.CallContext(ki.ctx)
+		defer cancel()
+		node, err := ki.core.ResolveNode(callCtx, key.Path())
+		if err != nil {
+			return nil, iferrors.IO(path, err)
+		}
+		return node, nil
+	default: // directory keys, and nodes within them
+		if fsPath == "" {
+			fsPath = "/"
+		}
+		return fs.(filesystem.Relinker).Node(fsPath)
+	}
+}
+
+// Link makes a new key for the node, or links the node into an existing key's root.
+// Modified keys are published before returning.
+func (ki *keyInterface) Link(path string, node ipld.Node) error {
+	keyName, remainder := splitPath(path)
+	key, err := ki.checkKey(keyName)
+	if err != nil {
+		return iferrors.IO(path, err)
+	}
+
+	if remainder == "" {
+		if key != nil {
+			return ki.existErr(path, key)
+		}
+		callCtx, cancel := interfaceutils.CallContext(ki.ctx)
+		defer cancel()
+		if err := makeKeyWithNode(callCtx, ki.core, keyName, node); err != nil {
+			return err
+		}
+		return localPublish(callCtx, ki.core, keyName, corepath.IpfsPath(node.Cid()))
+	}
+
+	if key == nil {
+		return iferrors.NotExist("/" + keyName)
+	}
+	root, err := ki.getRoot(key)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	if err := root.(filesystem.Relinker).Link(remainder, node); err != nil {
+		return err
+	}
+	return root.(filesystem.Syncer).Sync()
+}
+
+// Unlink removes the key, or removes the node from the key's root.
+// Modified keys are published before returning.
+func (ki *keyInterface) Unlink(path string) error {
+	keyName, remainder := splitPath(path)
+	key, err := ki.checkKey(keyName)
+	if err != nil {
+		return iferrors.IO(path, err)
+	}
+	if key == nil {
+		return iferrors.NotExist(path)
+	}
+
+	if remainder == "" {
+		return ki.removeKey(path, keyName)
+	}
+
+	root, err := ki.getRoot(key)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	if err := root.(filesystem.Relinker).Unlink(remainder); err != nil {
+		return err
+	}
+	return root.(filesystem.Syncer).Sync()
+}
+
+// relink moves the node by reference, from one path to another
+// (which may be in different keys, or be keys themselves)
+func (ki *keyInterface) relink(oldName, newName string) error {
+	return interfaceutils.Relink(ki, oldName, ki, newName)
+}
+
+// existErr returns the error for a key that is in the way;
+// directories which contain entries are reported as not being empty
+func (ki *keyInterface) existErr(path string, key coreiface.Key) error {
+	callCtx, cancel := interfaceutils.CallContext(ki.ctx)
+	defer cancel()
+	node, err := ki.core.ResolveNode(callCtx, key.Path())
+	if err != nil {
+		return iferrors.Exist(path)
+	}
+	if fsNode, err := unixfs.ExtractFSNode(node); err == nil && fsNode.IsDir() && len(node.Links()) != 0 {
+		return iferrors.NotEmpty(path)
+	}
+	return iferrors.Exist(path)
+}
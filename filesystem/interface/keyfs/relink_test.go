@@ -0,0 +1,116 @@
+package keyfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	fserrors "github.com/ipfs/go-ipfs/filesystem/errors"
+)
+
+func TestRename(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newKeys := func(t *testing.T) (filesystem.Interface, *testCore) {
+		core := newTestCore()
+		fs := NewInterface(ctx, core, WithPublishPolicy(PublishImmediately))
+		writeFile(t, fs, "/file", filesystem.IOWriteOnly|filesystem.IOCreate, "file")
+		if err := fs.MakeDirectory("/dir"); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, fs, "/dir/sub", filesystem.IOWriteOnly|filesystem.IOCreate, "sub")
+		return fs, core
+	}
+	expectKind := func(t *testing.T, err error, kind fserrors.Kind) {
+		t.Helper()
+		var fsErr fserrors.Error
+		if !errors.As(err, &fsErr) || fsErr.Kind() != kind {
+			t.Errorf("expected error of kind %v, got: %v", kind, err)
+		}
+	}
+	expectMissing := func(t *testing.T, fs filesystem.Interface, path string) {
+		t.Helper()
+		_, _, err := fs.Info(path, filesystem.StatRequest{Type: true})
+		expectKind(t, err, fserrors.NotExist)
+	}
+	expectKeys := func(t *testing.T, core *testCore, keys map[string]bool) {
+		t.Helper()
+		for keyName, expected := range keys {
+			if exists := core.hasKey(keyName); exists != expected {
+				t.Errorf("key %q: expected to exist: %t, got: %t", keyName, expected, exists)
+			}
+		}
+	}
+
+	t.Run("key", func(t *testing.T) {
+		fs, core := newKeys(t)
+		defer fs.Close()
+		if err := fs.Rename("/file", "/renamed"); err != nil {
+			t.Fatal(err)
+		}
+		expectKeys(t, core, map[string]bool{"file": false, "renamed": true})
+		expectContent(t, fs, "/renamed", "file")
+
+		if err := fs.Make("/other"); err != nil {
+			t.Fatal(err)
+		}
+		expectKind(t, fs.Rename("/renamed", "/other"), fserrors.Exist)
+		expectContent(t, fs, "/renamed", "file")
+	})
+
+	t.Run("within a key", func(t *testing.T) {
+		fs, _ := newKeys(t)
+		defer fs.Close()
+		if err := fs.Rename("/dir/sub", "/dir/renamed"); err != nil {
+			t.Fatal(err)
+		}
+		expectContent(t, fs, "/dir/renamed", "sub")
+		expectMissing(t, fs, "/dir/sub")
+	})
+
+	t.Run("key into a key", func(t *testing.T) {
+		fs, core := newKeys(t)
+		defer fs.Close()
+		if err := fs.Rename("/file", "/dir/file"); err != nil {
+			t.Fatal(err)
+		}
+		expectKeys(t, core, map[string]bool{"file": false})
+		expectContent(t, fs, "/dir/file", "file")
+	})
+
+	t.Run("out of a key", func(t *testing.T) {
+		fs, core := newKeys(t)
+		defer fs.Close()
+		if err := fs.Rename("/dir/sub", "/sub"); err != nil {
+			t.Fatal(err)
+		}
+		expectKeys(t, core, map[string]bool{"sub": true})
+		expectContent(t, fs, "/sub", "sub")
+		expectMissing(t, fs, "/dir/sub")
+	})
+
+	t.Run("key into itself", func(t *testing.T) {
+		fs, core := newKeys(t)
+		defer fs.Close()
+		expectKind(t, fs.Rename("/dir", "/dir/dir"), fserrors.InvalidItem)
+		expectKeys(t, core, map[string]bool{"dir": true})
+	})
+
+	t.Run("failed unlink", func(t *testing.T) {
+		fs, core := newKeys(t)
+		defer fs.Close()
+
+		// the new name is removed again, if the old one can't be
+		core.setRemoveErr(errors.New("key is in use"))
+		if err := fs.Rename("/file", "/dir/file"); err == nil {
+			t.Fatal("expected the move to fail")
+		}
+		core.setRemoveErr(nil)
+
+		expectKeys(t, core, map[string]bool{"file": true})
+		expectContent(t, fs, "/file", "file")
+		expectMissing(t, fs, "/dir/file")
+	})
+}
@@ -65,11 +65,16 @@ func (ki *keyInterface) remove(path string, requestType coreiface.FileType) erro
 		}
 	}
 
+	return ki.removeKey(path, path[1:])
+}
+
+func (ki *keyInterface) removeKey(path, keyName string) error {
 	callCtx, cancel := interfaceutils.CallContext(ki.ctx)
 	defer cancel()
-	keyName := path[1:]
-	if _, err = ki.core.Key().Remove(callCtx, keyName); err != nil {
+	if _, err := ki.core.Key().Remove(callCtx, keyName); err != nil {
 		return iferrors.IO(path, err)
 	}
-	return nil
+	err := ki.references.invalidate(keyName) // don't revive references to the old key
+	ki.publisher.forget(keyName)             // (any pending modifications can no longer be published)
+	return err
 }
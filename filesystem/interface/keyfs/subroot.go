@@ -3,21 +3,25 @@ package keyfs
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/ipfs/go-ipfs/filesystem"
 	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
 	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
 	"github.com/ipfs/go-ipfs/filesystem/interface/mfs"
+	ipld "github.com/ipfs/go-ipld-format"
 	"github.com/ipfs/go-merkledag"
 	gomfs "github.com/ipfs/go-mfs"
 	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
 )
 
 // rootRef wraps a foreign file system
 // with the means to manage sub-references of that system.
 type rootRef struct {
 	filesystem.Interface
-	counter refCounter
+	counter *refCount
+	sync    syncFunc
 }
 
 // root references must be closed when no longer used
@@ -30,6 +34,7 @@ type (
 	rootFileRef struct {
 		filesystem.File
 		io.Closer
+		syncRoot syncFunc
 	}
 	rootDirectoryRef struct {
 		filesystem.Directory
@@ -40,6 +45,19 @@ type (
 func (rf rootFileRef) Close() error      { return rf.Closer.Close() }
 func (rd rootDirectoryRef) Close() error { return rd.Closer.Close() }
 
+// `Sync` commits the file's modifications to its root, and the root to its key
+func (rf rootFileRef) Sync() error {
+	if syncer, ok := rf.File.(filesystem.Syncer); ok {
+		if err := syncer.Sync(); err != nil {
+			return err
+		}
+	}
+	return rf.syncRoot()
+}
+
+// `Sync` commits the root's pending modifications, and publishes it to its key
+func (rr rootRef) Sync() error { return rr.sync() }
+
 func rootCloserGen(rootRef *rootRef, subRef io.Closer) closer {
 	return func() error {
 		err := subRef.Close()               // `Close` the subreference itself
@@ -62,8 +80,9 @@ func (rr rootRef) Open(path string, flags filesystem.IOFlags) (filesystem.File,
 	}
 
 	return rootFileRef{
-		File:   file,
-		Closer: rootCloserGen(&rr, file),
+		File:     file,
+		Closer:   rootCloserGen(&rr, file),
+		syncRoot: rr.sync,
 	}, nil
 }
 
@@ -82,13 +101,31 @@ func (rr rootRef) OpenDirectory(path string) (filesystem.Directory, error) {
 }
 
 func (ki *keyInterface) getRoot(key coreiface.Key) (filesystem.Interface, error) {
-	return ki.references.getRootRef(key.Name(), func() (filesystem.Interface, error) {
+	keyName := key.Name()
+	return ki.references.getRootRef(keyName, func() (filesystem.Interface, syncFunc, error) {
 		mroot, err := ki.keyToMFSRoot(key)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		return mfs.NewInterface(ki.ctx, mroot)
+		root, err := mfs.NewInterface(ki.ctx, mroot)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// the MFS republisher is delayed,
+		// so we publish the flushed root ourselves
+		sync := func() error {
+			if err := root.(filesystem.Syncer).Sync(); err != nil {
+				return err
+			}
+			node, err := mroot.GetDirectory().GetNode()
+			if err != nil {
+				return iferrors.IO(keyName, err)
+			}
+			return ki.publisher.publish(keyName, corepath.IpfsPath(node.Cid()))
+		}
+		return root, sync, nil
 	})
 }
 
@@ -130,5 +167,60 @@ func (ki *keyInterface) keyToMFSRoot(key coreiface.Key) (*gomfs.Root, error) {
 	if err != nil {
 		return nil, iferrors.IO(key.Name(), err)
 	}
+	ki.publisher.seen(key.Name(), corepath.IpfsPath(pbNode.Cid()))
 	return mroot, nil
 }
+
+// `ExtendedAttributes` relays the request to the native system (if it supports it)
+func (rr rootRef) ExtendedAttributes(path string) (map[string]string, error) {
+	attributer, ok := rr.Interface.(filesystem.ExtendedAttributer)
+	if !ok {
+		return nil, iferrors.UnsupportedRequest()
+	}
+	return attributer.ExtendedAttributes(path)
+}
+
+// `Chmod` relays the request to the native system (if it supports it)
+func (rr rootRef) Chmod(path string, mode uint32) error {
+	modifier, ok := rr.Interface.(filesystem.MetadataModifier)
+	if !ok {
+		return iferrors.UnsupportedRequest()
+	}
+	return modifier.Chmod(path, mode)
+}
+
+// `Chtimes` relays the request to the native system (if it supports it)
+func (rr rootRef) Chtimes(path string, mtime time.Time) error {
+	modifier, ok := rr.Interface.(filesystem.MetadataModifier)
+	if !ok {
+		return iferrors.UnsupportedRequest()
+	}
+	return modifier.Chtimes(path, mtime)
+}
+
+// `Node` relays the request to the native system (if it supports it)
+func (rr rootRef) Node(path string) (ipld.Node, error) {
+	relinker, ok := rr.Interface.(filesystem.Relinker)
+	if !ok {
+		return nil, iferrors.UnsupportedRequest()
+	}
+	return relinker.Node(path)
+}
+
+// `Link` relays the request to the native system (if it supports it)
+func (rr rootRef) Link(path string, node ipld.Node) error {
+	relinker, ok := rr.Interface.(filesystem.Relinker)
+	if !ok {
+		return iferrors.UnsupportedRequest()
+	}
+	return relinker.Link(path, node)
+}
+
+// `Unlink` relays the request to the native system (if it supports it)
+func (rr rootRef) Unlink(path string) error {
+	relinker, ok := rr.Interface.(filesystem.Relinker)
+	if !ok {
+		return iferrors.UnsupportedRequest()
+	}
+	return relinker.Unlink(path)
+}
@@ -2,7 +2,6 @@ package keyfs
 
 import (
 	"sync"
-	"sync/atomic"
 
 	"github.com/ipfs/go-ipfs/filesystem"
 )
@@ -10,62 +9,41 @@ import (
 type closer func() error      // io.Closure closure wrapper
 func (f closer) Close() error { return f() }
 
-type refcount func(increment bool) error
-
-func (f refcount) increment()       { f(true) }
-func (f refcount) decrement() error { return f(false) }
-
-type refCounter interface {
-	increment()
-	decrement() error // error value must only be populated when reference count reaches 0
-}
-
-// XXX: trash garbage, don't imitate
-// we need to utilize a mutex here because the lock is shared across all references, not unique per instance
-// so we can't just use a merged uintptr{MSB:count;LSB:lock} value
-// i.e. the counter is atomic but the cleanup can't be
-func newRefCounter(locker sync.Locker, onZero func() error) refcount {
-	var count uintptr = 1
-
-	return func(increment bool) error {
-		if increment {
-			atomic.AddUintptr(&count, 1)
-			return nil
-		}
-
-		locker.Lock()
-		defer locker.Unlock()
-
-		if atomic.AddUintptr(&count, ^uintptr(0)) == 0 {
-			// TODO: references should be timebombed
-			// it's very typical for the APIs like FUSE to make a sequence of single requests
-			// which means a sequence of create;reap;create;reap...
-			// references should stay alive and in the table for a few ms at least after the final decrement
-			// and a ticker here should be canceled on increment if one is called before the ticker is done
-			return onZero()
-		}
-		return nil
-	}
-}
-
 type (
 	openFileFunc      func() (filesystem.File, error)
-	openInterfaceFunc func() (filesystem.Interface, error)
+	openInterfaceFunc func() (filesystem.Interface, syncFunc, error)
+
+	// syncFunc commits the pending modifications of a root, to its key
+	syncFunc func() error
 
 	referenceTable interface {
 		// retrieves and existing reference and returns it, or opens a new one using provided function
 		getFileRef(string, openFileFunc) (fileRef, error)
 		getRootRef(string, openInterfaceFunc) (rootRef, error)
+		// commits the pending modifications of all open references
+		syncAll() error
+		// removes the key's reference from the cache (if it's idle)
+		invalidate(keyName string) error
+		// removes all idle references from the cache
+		purge() error
 	}
 
+	// releaseFunc is called after the last reference to a key is closed
+	releaseFunc func(keyName string) error
+
+	// references remain in the table while they're in use, or idle within the table's cache
 	fileTable struct {
 		sync.Mutex
-		refs map[string]fileRef
+		refs     map[string]fileRef
+		cache    *idleCache
+		released releaseFunc
 	}
 
 	rootTable struct {
 		sync.Mutex
-		refs map[string]rootRef
+		refs     map[string]rootRef
+		cache    *idleCache
+		released releaseFunc
 	}
 
 	combinedTable struct {
@@ -74,10 +52,18 @@ type (
 	}
 )
 
-func newReferenceTable() referenceTable {
+func newReferenceTable(released releaseFunc, config cacheConfig, metrics *cacheMetrics) referenceTable {
 	return &combinedTable{
-		fileTable: fileTable{refs: make(map[string]fileRef)},
-		rootTable: rootTable{refs: make(map[string]rootRef)},
+		fileTable: fileTable{
+			refs:     make(map[string]fileRef),
+			cache:    newIdleCache(config, metrics),
+			released: released,
+		},
+		rootTable: rootTable{
+			refs:     make(map[string]rootRef),
+			cache:    newIdleCache(config, metrics),
+			released: released,
+		},
 	}
 }
 
@@ -85,30 +71,41 @@ func (ft *fileTable) getFileRef(keyName string, opener openFileFunc) (fileRef, e
 	ft.Lock()
 	defer ft.Unlock()
 
-	// if a `File` reference already exists, use it
+	// if a `File` reference already exists (or is idle), use it
 	ref, ok := ft.refs[keyName]
 	if ok {
-		ref.counter.increment()
+		ref.counter.reuse()
 		return ref, nil
 	}
 
 	// otherwise open a new one and set it up …
+	ft.cache.misses.Inc()
 	file, err := opener()
 	if err != nil {
 		return fileRef{}, err
 	}
 
-	// … so that it removes itself from the table when its counter reaches 0 …
-	whenZeroRefs := func() error { // ft will be locked during this
-		delete(ft.refs, keyName)
-		return file.Close()
+	fileMu := new(sync.Mutex)
+	// … so that it commits its modifications when its counter reaches 0 …
+	whenZeroRefs := func() error {
+		if syncer, ok := file.(filesystem.Syncer); ok {
+			fileMu.Lock()
+			err := syncer.Sync()
+			fileMu.Unlock()
+			if err != nil {
+				return err
+			}
+		}
+		return ft.released(keyName)
 	}
+	// … and removes itself from the table when it expires …
+	whenExpired := func() { delete(ft.refs, keyName) } // ft will be locked during this
 
 	// … and decrements its counter on `Close`
 	fileRef := fileRef{
 		File:    file,
-		Mutex:   new(sync.Mutex),
-		counter: newRefCounter(&ft.Mutex, whenZeroRefs),
+		Mutex:   fileMu,
+		counter: ft.cache.newRefCount(&ft.Mutex, whenZeroRefs, whenExpired, file.Close),
 	}
 	fileRef.Closer = (closer)(fileRef.counter.decrement) // self referential
 
@@ -120,31 +117,138 @@ func (rt *rootTable) getRootRef(keyName string, opener openInterfaceFunc) (rootR
 	rt.Lock()
 	defer rt.Unlock()
 
-	// if an `Interface` reference already exists, use it
+	// if an `Interface` reference already exists (or is idle), use it
 	ref, ok := rt.refs[keyName]
 	if ok {
-		ref.counter.increment()
+		ref.counter.reuse()
 		return ref, nil
 	}
 
 	// otherwise open a new one and set it up …
-	root, err := opener()
+	rt.cache.misses.Inc()
+	root, sync, err := opener()
 	if err != nil {
 		return rootRef{}, err
 	}
 
-	// … so that it removes itself from the table when its counter reaches 0
-	whenZeroRefs := func() error { // rt will be locked during this
-		delete(rt.refs, keyName)
-		return root.Close()
+	// … so that it publishes its modifications when its counter reaches 0 …
+	whenZeroRefs := func() error {
+		if err := sync(); err != nil {
+			return err
+		}
+		return rt.released(keyName)
 	}
+	// … and removes itself from the table when it expires
+	whenExpired := func() { delete(rt.refs, keyName) } // rt will be locked during this
 
 	// NOTE: the counter starts at 1 and is decremented on `rootRef.Close`
 	rootRef := rootRef{
 		Interface: root,
-		counter:   newRefCounter(&rt.Mutex, whenZeroRefs),
+		counter:   rt.cache.newRefCount(&rt.Mutex, whenZeroRefs, whenExpired, root.Close),
+		sync:      sync,
 	}
 
 	rt.refs[keyName] = rootRef
 	return rootRef, nil
 }
+
+func (ct *combinedTable) syncAll() error {
+	err := ct.fileTable.syncAll()
+	if rErr := ct.rootTable.syncAll(); err == nil {
+		err = rErr
+	}
+	return err
+}
+
+// syncAll syncs every open `File` that supports it.
+// References are held during the sync, so that they may not be reaped underneath us.
+func (ft *fileTable) syncAll() (err error) {
+	ft.Lock()
+	refs := make([]fileRef, 0, len(ft.refs))
+	for _, ref := range ft.refs {
+		ref.counter.reuse()
+		refs = append(refs, ref)
+	}
+	ft.Unlock()
+
+	for _, ref := range refs {
+		if syncer, ok := ref.File.(filesystem.Syncer); ok {
+			ref.Lock()
+			sErr := syncer.Sync()
+			ref.Unlock()
+			if sErr != nil && err == nil {
+				err = sErr
+			}
+		}
+		if cErr := ref.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+	return
+}
+
+// syncAll syncs every open root.
+// References are held during the sync, so that they may not be reaped underneath us.
+func (rt *rootTable) syncAll() (err error) {
+	rt.Lock()
+	refs := make([]rootRef, 0, len(rt.refs))
+	for _, ref := range rt.refs {
+		ref.counter.reuse()
+		refs = append(refs, ref)
+	}
+	rt.Unlock()
+
+	for _, ref := range refs {
+		if sErr := ref.Sync(); sErr != nil && err == nil {
+			err = sErr
+		}
+		if cErr := ref.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+	return
+}
+
+func (ct *combinedTable) invalidate(keyName string) error {
+	err := ct.fileTable.invalidate(keyName)
+	if rErr := ct.rootTable.invalidate(keyName); err == nil {
+		err = rErr
+	}
+	return err
+}
+
+func (ft *fileTable) invalidate(keyName string) error {
+	ft.Lock()
+	ref, ok := ft.refs[keyName]
+	if !ok || ref.counter.element == nil {
+		ft.Unlock()
+		return nil
+	}
+	ft.cache.expire(ref.counter)
+	ft.Unlock()
+	return closeExpired([]*refCount{ref.counter})
+}
+
+func (rt *rootTable) invalidate(keyName string) error {
+	rt.Lock()
+	ref, ok := rt.refs[keyName]
+	if !ok || ref.counter.element == nil {
+		rt.Unlock()
+		return nil
+	}
+	rt.cache.expire(ref.counter)
+	rt.Unlock()
+	return closeExpired([]*refCount{ref.counter})
+}
+
+func (ct *combinedTable) purge() error {
+	ct.fileTable.Lock()
+	expired := ct.fileTable.cache.purge()
+	ct.fileTable.Unlock()
+
+	ct.rootTable.Lock()
+	expired = append(expired, ct.rootTable.cache.purge()...)
+	ct.rootTable.Unlock()
+
+	return closeExpired(expired)
+}
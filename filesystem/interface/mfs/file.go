@@ -1,56 +1,127 @@
 package mfs
 
 import (
-	"errors"
 	"fmt"
-	"os"
+	"io"
 
 	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
 	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
 	gomfs "github.com/ipfs/go-mfs"
 )
 
 var _ filesystem.File = (*mfsIOWrapper)(nil)
 
-type mfsIOWrapper struct{ f gomfs.FileDescriptor }
+type mfsIOWrapper struct {
+	f     gomfs.FileDescriptor
+	path  string
+	flags filesystem.IOFlags
 
-func (mio *mfsIOWrapper) Size() (int64, error)           { return mio.f.Size() }
-func (mio *mfsIOWrapper) Read(buff []byte) (int, error)  { return mio.f.Read(buff) }
-func (mio *mfsIOWrapper) Write(buff []byte) (int, error) { return mio.f.Write(buff) }
-func (mio *mfsIOWrapper) Truncate(size uint64) error     { return mio.f.Truncate(int64(size)) }
-func (mio *mfsIOWrapper) Close() error                   { return mio.f.Close() }
+	// (for writable files)
+	mi   *mfsInterface
+	file *gomfs.File
+}
+
+func (mio *mfsIOWrapper) Size() (int64, error) { return mio.f.Size() }
+func (mio *mfsIOWrapper) Sync() error          { return mio.f.Flush() }
+func (mio *mfsIOWrapper) Close() error {
+	err := mio.f.Close()
+	if mio.file != nil {
+		// the descriptor has written its node back to the parent by now
+		// so any metadata changes that were held for it can be applied on top
+		if wErr := mio.mi.closeWriter(mio.path, mio.file); err == nil {
+			err = wErr
+		}
+	}
+	return err
+}
 func (mio *mfsIOWrapper) Seek(offset int64, whence int) (int64, error) {
 	return mio.f.Seek(offset, whence)
 }
 
+func (mio *mfsIOWrapper) Read(buff []byte) (int, error) {
+	if !mio.flags.Readable() {
+		return 0, iferrors.Permission(mio.path, interfaceutils.ErrNotReadable)
+	}
+	return mio.f.Read(buff)
+}
+
+func (mio *mfsIOWrapper) Write(buff []byte) (int, error) {
+	if !mio.flags.Writable() {
+		return 0, iferrors.Permission(mio.path, interfaceutils.ErrNotWritable)
+	}
+	if mio.flags&filesystem.IOAppend != 0 {
+		if _, err := mio.f.Seek(0, io.SeekEnd); err != nil {
+			return 0, err
+		}
+	}
+	return mio.f.Write(buff)
+}
+
+func (mio *mfsIOWrapper) Truncate(size uint64) error {
+	if !mio.flags.Writable() {
+		return iferrors.Permission(mio.path, interfaceutils.ErrNotWritable)
+	}
+	return mio.f.Truncate(int64(size))
+}
+
 func (mi *mfsInterface) Open(path string, flags filesystem.IOFlags) (filesystem.File, error) {
+	if err := interfaceutils.MakeForOpen(mi, path, flags); err != nil {
+		return nil, err
+	}
+
+	writable := flags.Writable()
+	if writable {
+		mi.writersLock.Lock()
+	}
 	mfsNode, err := gomfs.Lookup(mi.mroot, path)
 	if err != nil {
-		return nil, mfsLookupErr(path, err)
-		if errors.Is(err, os.ErrNotExist) {
-			return nil, iferrors.NotExist(path)
+		if writable {
+			mi.writersLock.Unlock()
 		}
-		return nil, iferrors.Permission(path, err)
+		return nil, mfsLookupErr(path, err)
 	}
 
 	mfsFileIf, ok := mfsNode.(*gomfs.File)
 	if !ok {
+		if writable {
+			mi.writersLock.Unlock()
+		}
 		return nil, fmt.Errorf("(Type: %v), %w",
 			mfsNode.Type(),
 			iferrors.IsDir(path),
 		)
 	}
+	if writable {
+		// (registered before the node can be replaced by a metadata change)
+		mi.openWriter(mfsFileIf)
+		mi.writersLock.Unlock()
+	}
 
 	mfsFile, err := mfsFileIf.Open(translateFlags(flags))
 	if err != nil {
+		if writable {
+			mi.closeWriter(path, mfsFileIf) // (error unchecked; the open error takes precedence)
+		}
 		return nil, iferrors.Permission(path, err)
 	}
 
-	return &mfsIOWrapper{f: mfsFile}, nil
+	file := &mfsIOWrapper{f: mfsFile, path: path, flags: flags}
+	if writable {
+		file.mi, file.file = mi, mfsFileIf
+	}
+	if flags&filesystem.IOTruncate != 0 {
+		if err := file.Truncate(0); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	return file, nil
 }
 
 func translateFlags(flags filesystem.IOFlags) gomfs.Flags {
-	switch flags {
+	switch flags.Access() {
 	case filesystem.IOReadOnly:
 		return gomfs.Flags{Read: true}
 	case filesystem.IOWriteOnly:
@@ -0,0 +1,119 @@
+package mfs
+
+import (
+	"context"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	ipld "github.com/ipfs/go-ipld-format"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	gomfs "github.com/ipfs/go-mfs"
+	"github.com/ipfs/go-unixfs"
+)
+
+func TestHardLink(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dagService := &countingDAG{DAGService: mdtest.Mock()}
+	mroot, err := gomfs.NewRoot(ctx, dagService, unixfs.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, err := NewInterface(ctx, mroot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile := func(t *testing.T, path string, flags filesystem.IOFlags, data string) {
+		file, err := fs.Open(path, flags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectContent := func(t *testing.T, path, expected string) {
+		file, err := fs.Open(path, filesystem.IOReadOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		data, err := ioutil.ReadAll(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Errorf("%s: content does not match\n\twanted: %q\n\tgot: %q", path, expected, data)
+		}
+	}
+	// stats must not modify the system
+	// (directories write their node when they're synced)
+	expectStat := func(t *testing.T, path string) {
+		adds := dagService.addCount()
+		if _, _, err := fs.Info(path, filesystem.StatRequestAll); err != nil {
+			t.Fatal(err)
+		}
+		if added := dagService.addCount() - adds; added != 0 {
+			t.Errorf("%s: stat added %d nodes", path, added)
+		}
+	}
+
+	writeFile(t, "/a", filesystem.IOWriteOnly|filesystem.IOCreate, "shared")
+	if err := fs.MakeDirectory("/dir"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := interfaceutils.HardLink(fs, "/a", "/b"); err != nil {
+		t.Fatal(err)
+	}
+	expectContent(t, "/b", "shared")
+	expectStat(t, "/a")
+	expectStat(t, "/b")
+
+	// writes through either name diverge from the other
+	writeFile(t, "/b", filesystem.IOWriteOnly, "SH")
+	expectContent(t, "/a", "shared")
+	expectContent(t, "/b", "SHared")
+
+	if err := interfaceutils.HardLink(fs, "/dir", "/dir2"); err == nil {
+		t.Error("directory was hard linked")
+	}
+	if err := interfaceutils.HardLink(fs, "/a", "/b"); err == nil {
+		t.Error("existing name was replaced by a hard link")
+	}
+}
+
+// countingDAG counts how many nodes are added to it
+type countingDAG struct {
+	ipld.DAGService
+	sync.Mutex
+	adds int
+}
+
+func (cd *countingDAG) Add(ctx context.Context, node ipld.Node) error {
+	cd.Lock()
+	cd.adds++
+	cd.Unlock()
+	return cd.DAGService.Add(ctx, node)
+}
+
+func (cd *countingDAG) AddMany(ctx context.Context, nodes []ipld.Node) error {
+	cd.Lock()
+	cd.adds += len(nodes)
+	cd.Unlock()
+	return cd.DAGService.AddMany(ctx, nodes)
+}
+
+func (cd *countingDAG) addCount() int {
+	cd.Lock()
+	defer cd.Unlock()
+	return cd.adds
+}
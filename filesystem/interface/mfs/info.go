@@ -6,10 +6,12 @@ import (
 	"os"
 
 	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
 	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
 	gomfs "github.com/ipfs/go-mfs"
 	"github.com/ipfs/go-unixfs"
 	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
 )
 
 func (mi *mfsInterface) Info(path string, req filesystem.StatRequest) (*filesystem.Stat, filesystem.StatRequest, error) {
@@ -70,6 +72,15 @@ func (mi *mfsInterface) Info(path string, req filesystem.StatRequest) (*filesyst
 		filled.Blocks = true
 	}
 
+	if req.Mode || req.MTime {
+		if err := interfaceutils.ApplyUFSMetadata(ufsNode, attr, &filled); err != nil {
+			return attr, filled, iferrors.Other(path, err)
+		}
+	}
+
+	// NOTE: link counts are not filled in;
+	// hard links share nodes by CID, which can't be distinguished from copies of the same content
+
 	return attr, filled, nil
 }
 
@@ -103,3 +114,18 @@ func (mi *mfsInterface) ExtractLink(path string) (string, error) {
 
 	return string(ufsNode.Data()), nil
 }
+
+func (mi *mfsInterface) ExtendedAttributes(path string) (map[string]string, error) {
+	mfsNode, err := gomfs.Lookup(mi.mroot, path)
+	if err != nil {
+		return nil, mfsLookupErr(path, err)
+	}
+
+	ipldNode, err := mfsNode.GetNode()
+	if err != nil {
+		return nil, iferrors.Other(path, err)
+	}
+
+	// MFS paths are not content paths, so we provide the node's immutable path
+	return interfaceutils.NodeAttributes(ipldNode, corepath.IpfsPath(ipldNode.Cid()).String()), nil
+}
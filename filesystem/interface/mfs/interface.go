@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 
 	"github.com/ipfs/go-ipfs/filesystem"
 	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
@@ -15,6 +16,9 @@ import (
 type mfsInterface struct {
 	ctx   context.Context
 	mroot *gomfs.Root
+
+	writersLock sync.Mutex
+	writers     map[*gomfs.File]*fileWriters // files that are open for writing
 }
 
 func NewInterface(ctx context.Context, mroot *gomfs.Root) (fs filesystem.Interface, err error) {
@@ -24,14 +28,28 @@ func NewInterface(ctx context.Context, mroot *gomfs.Root) (fs filesystem.Interfa
 	}
 
 	fs = &mfsInterface{
-		ctx:   ctx,
-		mroot: mroot,
+		ctx:     ctx,
+		mroot:   mroot,
+		writers: make(map[*gomfs.File]*fileWriters),
 	}
 	return
 }
 
 func (mi *mfsInterface) ID() filesystem.ID { return filesystem.Files } // TODO: distinct ID
 func (mi *mfsInterface) Close() error      { return mi.mroot.Close() }
+
+// Sync flushes the directory tree up to the root,
+// and signals the root's republisher (if any) with the new root node.
+func (mi *mfsInterface) Sync() error {
+	if err := mi.mroot.GetDirectory().Flush(); err != nil {
+		return iferrors.IO("/", err)
+	}
+	if err := mi.mroot.Flush(); err != nil {
+		return iferrors.IO("/", err)
+	}
+	return nil
+}
+
 func (mi *mfsInterface) Rename(oldName, newName string) error {
 	if err := gomfs.Mv(mi.mroot, oldName, newName); err != nil {
 		return iferrors.IO(newName, err)
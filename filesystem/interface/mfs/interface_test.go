@@ -0,0 +1,80 @@
+package mfs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipfs/filesystem"
+	dag "github.com/ipfs/go-merkledag"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	gomfs "github.com/ipfs/go-mfs"
+	"github.com/ipfs/go-unixfs"
+)
+
+func TestSync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		dagService = mdtest.Mock()
+		published  = make(chan cid.Cid, 1)
+		publish    = func(_ context.Context, c cid.Cid) error {
+			select {
+			case <-published: // (only the latest is of interest)
+			default:
+			}
+			published <- c
+			return nil
+		}
+	)
+	mroot, err := gomfs.NewRoot(ctx, dagService, unixfs.EmptyDirNode(), publish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, err := NewInterface(ctx, mroot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Close()
+
+	file, err := fs.Open("/file", filesystem.IOWriteOnly|filesystem.IOCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.Write([]byte("data")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file's modifications must reach the published root, while it's still open
+	if err := file.(filesystem.Syncer).Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.(filesystem.Syncer).Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	var rootCid cid.Cid
+	select {
+	case rootCid = <-published:
+	case <-time.After(5 * time.Second): // (the republisher is delayed)
+		t.Fatal("sync did not publish the root")
+	}
+	rootNode, err := dagService.Get(ctx, rootCid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileNode, err := rootNode.(*dag.ProtoNode).GetLinkedProtoNode(ctx, dagService, "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ufsNode, err := unixfs.ExtractFSNode(fileNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := ufsNode.FileSize(); size != uint64(len("data")) {
+		t.Errorf("published root has a stale file, expected size %d, got %d", len("data"), size)
+	}
+}
@@ -0,0 +1,163 @@
+package mfs
+
+import (
+	"errors"
+	"fmt"
+	gopath "path"
+	"time"
+
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	dag "github.com/ipfs/go-merkledag"
+	gomfs "github.com/ipfs/go-mfs"
+)
+
+func (mi *mfsInterface) Chmod(path string, mode uint32) error {
+	return mi.setMetadata(path, interfaceutils.UFSMetadata{Mode: mode & 07777, HasMode: true})
+}
+
+func (mi *mfsInterface) Chtimes(path string, mtime time.Time) error {
+	return mi.setMetadata(path, interfaceutils.UFSMetadata{MTime: mtime, HasMTime: true})
+}
+
+// fileWriters tracks the write descriptors of a file,
+// and the metadata that is waiting for them to close
+type fileWriters struct {
+	count    int
+	metadata *interfaceutils.UFSMetadata
+}
+
+// openWriter registers a write descriptor for the file
+// (the caller must hold the writers lock)
+func (mi *mfsInterface) openWriter(file *gomfs.File) {
+	writers, ok := mi.writers[file]
+	if !ok {
+		writers = new(fileWriters)
+		mi.writers[file] = writers
+	}
+	writers.count++
+}
+
+// closeWriter unregisters a write descriptor for the file,
+// applying any pending metadata after the last one closes
+func (mi *mfsInterface) closeWriter(path string, file *gomfs.File) error {
+	mi.writersLock.Lock()
+	defer mi.writersLock.Unlock()
+
+	writers := mi.writers[file]
+	if writers.count--; writers.count != 0 {
+		return nil
+	}
+	delete(mi.writers, file)
+
+	if writers.metadata == nil {
+		return nil
+	}
+	// if the file was moved or replaced while it was open, the change is dropped
+	if node, err := gomfs.Lookup(mi.mroot, path); err != nil || node != file {
+		return nil
+	}
+	return mi.replaceNode(path, *writers.metadata)
+}
+
+// setMetadata stores the metadata within the node at path.
+// If the node is a file that's open for writing, the change is held until it's closed.
+// (MFS descriptors write their node back to the parent when closed,
+// which would otherwise overwrite the node we store the metadata in)
+func (mi *mfsInterface) setMetadata(path string, metadata interfaceutils.UFSMetadata) error {
+	mi.writersLock.Lock()
+	defer mi.writersLock.Unlock()
+
+	if node, err := gomfs.Lookup(mi.mroot, path); err == nil {
+		if file, ok := node.(*gomfs.File); ok {
+			if writers, ok := mi.writers[file]; ok {
+				if writers.metadata == nil {
+					writers.metadata = new(interfaceutils.UFSMetadata)
+				}
+				pending := writers.metadata
+				if metadata.HasMode {
+					pending.Mode, pending.HasMode = metadata.Mode, true
+				}
+				if metadata.HasMTime {
+					pending.MTime, pending.HasMTime = metadata.MTime, true
+				}
+				return nil
+			}
+		}
+	}
+
+	return mi.replaceNode(path, metadata)
+}
+
+// replaceNode replaces the node at path, with a copy of itself that contains the metadata
+func (mi *mfsInterface) replaceNode(path string, metadata interfaceutils.UFSMetadata) error {
+	parentPath, childName := gopath.Split(path)
+	if childName == "" {
+		// TODO: the MFS root node can't be replaced via the MFS API
+		// we'd need to construct a new root from the modified node
+		return iferrors.Permission(path, errors.New("metadata of the root can not be modified"))
+	}
+
+	parentNode, err := gomfs.Lookup(mi.mroot, parentPath)
+	if err != nil {
+		return mfsLookupErr(parentPath, err)
+	}
+
+	parentDir, ok := parentNode.(*gomfs.Directory)
+	if !ok {
+		return iferrors.NotDir(parentPath)
+	}
+
+	childNode, err := parentDir.Child(childName)
+	if err != nil {
+		return mfsLookupErr(path, err)
+	}
+
+	ipldNode, err := childNode.GetNode()
+	if err != nil {
+		return iferrors.IO(path, err)
+	}
+
+	protoNode, ok := ipldNode.(*dag.ProtoNode)
+	if !ok {
+		// raw nodes have no UnixFS data to store metadata in
+		return iferrors.UnsupportedItem(path,
+			fmt.Errorf("node type %T can not hold metadata", ipldNode),
+		)
+	}
+
+	nodeData, err := interfaceutils.SetUFSMetadata(protoNode.Data(), metadata)
+	if err != nil {
+		return iferrors.Other(path, err)
+	}
+
+	modifiedNode := protoNode.Copy().(*dag.ProtoNode)
+	modifiedNode.SetData(nodeData)
+
+	if err := parentDir.Unlink(childName); err != nil {
+		return iferrors.IO(path, err)
+	}
+	if err := parentDir.AddChild(childName, modifiedNode); err != nil {
+		return restoreNode(parentDir, childName, protoNode, false, iferrors.IO(path, err))
+	}
+	if err := parentDir.Flush(); err != nil {
+		return restoreNode(parentDir, childName, protoNode, true, iferrors.IO(path, err))
+	}
+
+	return nil
+}
+
+// restoreNode links the original node back into the directory,
+// in place of its modified copy (if it was linked),
+// so that a failed modification doesn't remove the entry.
+func restoreNode(parentDir *gomfs.Directory, childName string, original *dag.ProtoNode, replaced bool, err error) error {
+	if replaced {
+		if unlinkErr := parentDir.Unlink(childName); unlinkErr != nil {
+			return fmt.Errorf("%w (the modified node could not be unlinked: %s)", err, unlinkErr)
+		}
+	}
+	if addErr := parentDir.AddChild(childName, original); addErr != nil {
+		return fmt.Errorf("%w (the original node could not be restored: %s)", err, addErr)
+	}
+	return err
+}
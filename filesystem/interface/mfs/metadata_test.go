@@ -0,0 +1,134 @@
+package mfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	ipld "github.com/ipfs/go-ipld-format"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	gomfs "github.com/ipfs/go-mfs"
+	"github.com/ipfs/go-unixfs"
+)
+
+// failingDAG fails to add nodes that it doesn't have, while fail is set
+type failingDAG struct {
+	ipld.DAGService
+	fail bool
+}
+
+func (fd *failingDAG) Add(ctx context.Context, node ipld.Node) error {
+	if fd.fail {
+		if _, err := fd.DAGService.Get(ctx, node.Cid()); err != nil {
+			return errors.New("storage is full")
+		}
+	}
+	return fd.DAGService.Add(ctx, node)
+}
+
+func TestMetadata(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mroot, err := gomfs.NewRoot(ctx, mdtest.Mock(), unixfs.EmptyDirNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, err := NewInterface(ctx, mroot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modifier := fs.(filesystem.MetadataModifier)
+
+	const mode = 0640
+	mtime := time.Unix(1600000000, 0)
+	expectMetadata := func(t *testing.T, path string) {
+		t.Helper()
+		stat, filled, err := fs.Info(path, filesystem.StatRequest{Mode: true, MTime: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !filled.Mode || stat.Mode != mode {
+			t.Errorf("%s: expected mode %o, got %o (filled: %t)", path, mode, stat.Mode, filled.Mode)
+		}
+		if !filled.MTime || !stat.MTime.Equal(mtime) {
+			t.Errorf("%s: expected mtime %v, got %v (filled: %t)", path, mtime, stat.MTime, filled.MTime)
+		}
+	}
+
+	t.Run("closed file", func(t *testing.T) {
+		if err := fs.Make("/closed"); err != nil {
+			t.Fatal(err)
+		}
+		if err := modifier.Chmod("/closed", mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := modifier.Chtimes("/closed", mtime); err != nil {
+			t.Fatal(err)
+		}
+		expectMetadata(t, "/closed")
+	})
+
+	t.Run("open file", func(t *testing.T) {
+		// e.g. `cp -p` sets the metadata of its target before closing it
+		file, err := fs.Open("/open", filesystem.IOWriteOnly|filesystem.IOCreate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte("data")); err != nil {
+			t.Fatal(err)
+		}
+		if err := modifier.Chmod("/open", mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := modifier.Chtimes("/open", mtime); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte("more")); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+		expectMetadata(t, "/open")
+
+		stat, _, err := fs.Info("/open", filesystem.StatRequest{Size: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stat.Size != uint64(len("datamore")) {
+			t.Errorf("file data was lost, expected size %d, got %d", len("datamore"), stat.Size)
+		}
+	})
+	t.Run("failed modification", func(t *testing.T) {
+		dagService := &failingDAG{DAGService: mdtest.Mock()}
+		mroot, err := gomfs.NewRoot(ctx, dagService, unixfs.EmptyDirNode(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fs, err := NewInterface(ctx, mroot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fs.Close()
+		if err := fs.Make("/file"); err != nil {
+			t.Fatal(err)
+		}
+
+		// the entry must remain, with its original metadata
+		dagService.fail = true
+		if err := fs.(filesystem.MetadataModifier).Chmod("/file", mode); err == nil {
+			t.Fatal("expected chmod to fail")
+		}
+		dagService.fail = false
+		stat, _, err := fs.Info("/file", filesystem.StatRequest{Mode: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stat.Mode == mode {
+			t.Errorf("the failed mode change was stored")
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package mfs
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	ipld "github.com/ipfs/go-ipld-format"
+	gomfs "github.com/ipfs/go-mfs"
+)
+
+func (mi *mfsInterface) Node(path string) (ipld.Node, error) {
+	mfsNode, err := gomfs.Lookup(mi.mroot, path)
+	if err != nil {
+		return nil, mfsLookupErr(path, err)
+	}
+	node, err := mfsNode.GetNode()
+	if err != nil {
+		return nil, iferrors.IO(path, err)
+	}
+	return node, nil
+}
+
+func (mi *mfsInterface) Link(path string, node ipld.Node) error {
+	parentDir, childName, err := splitParentChild(mi.mroot, path)
+	if err != nil {
+		return err
+	}
+
+	existing, err := parentDir.Child(childName)
+	switch {
+	case err == nil:
+		return existErr(path, existing)
+	case !errors.Is(err, os.ErrNotExist):
+		return iferrors.Other(path, err)
+	}
+
+	if err := parentDir.AddChild(childName, node); err != nil {
+		return iferrors.IO(path, err)
+	}
+	if err := parentDir.Flush(); err != nil {
+		return iferrors.IO(path, err)
+	}
+	return nil
+}
+
+func (mi *mfsInterface) Unlink(path string) error {
+	parentDir, childName, err := splitParentChild(mi.mroot, path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := parentDir.Child(childName); err != nil {
+		return mfsLookupErr(path, err)
+	}
+
+	if err := parentDir.Unlink(childName); err != nil {
+		return iferrors.IO(path, err)
+	}
+	if err := parentDir.Flush(); err != nil {
+		return iferrors.IO(path, err)
+	}
+	return nil
+}
+
+// existErr returns the error for a node that is in the way;
+// directories which contain entries are reported as not being empty
+func existErr(path string, existing gomfs.FSNode) error {
+	if dir, ok := existing.(*gomfs.Directory); ok {
+		if ents, err := dir.ListNames(context.TODO()); err == nil && len(ents) != 0 {
+			return iferrors.NotEmpty(path)
+		}
+	}
+	return iferrors.Exist(path)
+}
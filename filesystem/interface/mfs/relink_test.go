@@ -0,0 +1,66 @@
+package mfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	fserrors "github.com/ipfs/go-ipfs/filesystem/errors"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	gomfs "github.com/ipfs/go-mfs"
+	"github.com/ipfs/go-unixfs"
+)
+
+func TestRelink(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dagService := mdtest.Mock()
+	newRoot := func(t *testing.T) filesystem.Relinker {
+		mroot, err := gomfs.NewRoot(ctx, dagService, unixfs.EmptyDirNode(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fs, err := NewInterface(ctx, mroot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, dir := range []string{"/a", "/a/b"} {
+			if err := fs.MakeDirectory(dir); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return fs.(filesystem.Relinker)
+	}
+	expectKind := func(t *testing.T, err error, kind fserrors.Kind) {
+		var fsErr fserrors.Error
+		if !errors.As(err, &fsErr) || fsErr.Kind() != kind {
+			t.Errorf("expected error of kind %v, got: %v", kind, err)
+		}
+	}
+
+	source, target := newRoot(t), newRoot(t)
+	node, err := source.Node("/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectKind(t, target.Link("/a", node), fserrors.NotEmpty)
+	expectKind(t, target.Link("/a/b", node), fserrors.Exist)
+	expectKind(t, target.Link("/missing/a", node), fserrors.NotExist)
+
+	if err := target.Link("/c", node); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.Unlink("/a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := target.Node("/c/b"); err != nil {
+		t.Errorf("linked node's children are missing: %v", err)
+	}
+	_, err = source.Node("/a")
+	expectKind(t, err, fserrors.NotExist)
+	expectKind(t, source.Unlink("/a"), fserrors.NotExist)
+}
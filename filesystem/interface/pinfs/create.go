@@ -1,8 +1,65 @@
 package pinfs
 
-// TODO: generic "if root, return some const error" func
-func (pi *pinInterface) Make(path string) error          { return pi.ipfs.Make(path) }
-func (pi *pinInterface) MakeDirectory(path string) error { return pi.ipfs.MakeDirectory(path) }
+import (
+	"errors"
+	"strings"
+
+	tcom "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+var (
+	errNotLink = errors.New("not a link")
+	errNotFile = errors.New("pins are links to their content; not files")
+	errNotPin  = errors.New("only links to IPFS content may be created here (pins)")
+	errStatic  = errors.New("directory is provided by the system")
+)
+
+// pins may only be created via links; files and directories can't be made here
+func (pi *pinInterface) Make(path string) error {
+	if pp := splitPath(path); pp.proxied {
+		return pi.ipfs.Make(path)
+	}
+	return iferrors.Permission(path, errNotPin)
+}
+
+func (pi *pinInterface) MakeDirectory(path string) error {
+	if pp := splitPath(path); pp.proxied {
+		return pi.ipfs.MakeDirectory(path)
+	}
+	return iferrors.Permission(path, errNotPin)
+}
+
+// MakeLink pins the target of the link (`/ipfs/...`),
+// within the group the link is created in.
+// (e.g. `/local/recursive/Qm...` -> `/ipfs/Qm...`, `/remote/service/pin name` -> `/ipfs/Qm...`)
 func (pi *pinInterface) MakeLink(path, linkTarget string) error {
-	return pi.ipfs.MakeLink(path, linkTarget)
+	pp := splitPath(path)
+	if pp.proxied {
+		return pi.ipfs.MakeLink(path, linkTarget)
+	}
+	if !pp.isEntry() || pp.remainder != "" {
+		return iferrors.Permission(path, errNotPin)
+	}
+
+	callCtx, cancel := tcom.CallContext(pi.ctx)
+	defer cancel()
+	if err := pi.checkDirectory(callCtx, pinPath{namespace: pp.namespace, group: pp.group}, path); err != nil {
+		return err
+	}
+
+	// bare CIDs are accepted too
+	if !strings.HasPrefix(linkTarget, "/") {
+		linkTarget = "/ipfs/" + linkTarget
+	}
+	target, err := pi.core.ResolvePath(callCtx, corepath.New(linkTarget))
+	if err != nil {
+		return iferrors.UnsupportedItem(path, err)
+	}
+
+	if pp.namespace == remoteDirectory {
+		return pi.remoteAdd(callCtx, pp, path, target)
+	}
+	return pi.localAdd(callCtx, pp, path, target)
 }
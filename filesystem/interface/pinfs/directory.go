@@ -1,15 +1,48 @@
 package pinfs
 
 import (
+	"context"
+
 	"github.com/ipfs/go-ipfs/filesystem"
 	tcom "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
 )
 
 func (pi *pinInterface) OpenDirectory(path string) (filesystem.Directory, error) {
-	if path == "/" {
-		return tcom.UpgradePartialStream(
-			tcom.NewPartialStream(pi.ctx, &pinDirectoryStream{pinAPI: pi.core.Pin()}))
+	pp := splitPath(path)
+	if pp.proxied {
+		return pi.ipfs.OpenDirectory(path)
+	}
+	if pp.isEntry() {
+		return nil, iferrors.NotDir(path)
+	}
+
+	callCtx, cancel := tcom.CallContext(pi.ctx)
+	defer cancel()
+	if err := pi.checkDirectory(callCtx, pp, path); err != nil {
+		return nil, err
+	}
+
+	var stream tcom.PartialStreamGenerator
+	switch {
+	case pp.namespace == "":
+		stream = staticNames(rootNames)
+	case pp.namespace == localDirectory && pp.group == "":
+		stream = staticNames(localNames)
+	case pp.namespace == localDirectory:
+		stream = &pinDirectoryStream{pinAPI: pi.core.Pin(), pinType: pp.group}
+	case pp.group == "":
+		stream = nameDirectoryStream(pi.remoteServices)
+	default:
+		service := pp.group
+		stream = nameDirectoryStream(func(ctx context.Context) ([]string, error) {
+			listing, err := pi.remoteListing(ctx, service)
+			if err != nil {
+				return nil, err
+			}
+			return listing.names, nil
+		})
 	}
 
-	return pi.ipfs.OpenDirectory(path)
+	return tcom.UpgradePartialStream(tcom.NewPartialStream(pi.ctx, stream))
 }
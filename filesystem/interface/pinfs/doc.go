@@ -1,9 +1,20 @@
 // Package pinfs provides a constructor to a `filesystem.Interface`,
 // which itself provides access to a node's pins.
-// Presenting them in the root directory.
-// In addition, it acts as an IPFS proxy. Paths will be directed to an IPFS `filesystem.Interface`
-// transparently.
 //
+// Pins are grouped by where they're stored, and presented as links to their content:
+//
+//	/local/{recursive,direct,indirect}/{CID} -> /ipfs/{CID}
+//	/remote/{service}/{pin name} -> /ipfs/{CID}
+//
+// Local pins are named by their CID (so links that pin them must be named by their target's CID).
+// Remote pins are named by their pin name, or by CID if they don't have one (or if it's already taken).
+// Remote pins are managed through the node's `pin remote` commands, and require an HTTP API client.
+//
+// Removing an entry unpins it, and creating a link (to `/ipfs/...` or a CID) pins its target
+// within the group it was created in.
+//
+// In addition, it acts as an IPFS proxy. Paths outside of the groups will be directed to an IPFS `filesystem.Interface`
+// transparently.
 // System paths are expected to follow the same convention as the ipfscore package's `filesystem.Interface`.
 // e.g. `pinfs.Info("/Qm...")`
 package pinfs
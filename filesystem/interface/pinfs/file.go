@@ -1,8 +1,18 @@
 package pinfs
 
-import "github.com/ipfs/go-ipfs/filesystem"
+import (
+	"github.com/ipfs/go-ipfs/filesystem"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+)
 
-// TODO: error on root
 func (pi *pinInterface) Open(path string, flags filesystem.IOFlags) (filesystem.File, error) {
-	return pi.ipfs.Open(path, flags)
+	pp := splitPath(path)
+	switch {
+	case pp.proxied:
+		return pi.ipfs.Open(path, flags)
+	case pp.isEntry():
+		return nil, iferrors.UnsupportedItem(path, errNotFile)
+	default:
+		return nil, iferrors.IsDir(path)
+	}
 }
@@ -2,19 +2,79 @@ package pinfs
 
 import (
 	"github.com/ipfs/go-ipfs/filesystem"
+	tcom "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
 	coreiface "github.com/ipfs/interface-go-ipfs-core"
 )
 
 var (
 	rootStat   = &filesystem.Stat{Type: coreiface.TDirectory}
 	rootFilled = filesystem.StatRequest{Type: true}
+	linkFilled = filesystem.StatRequest{Type: true, Size: true}
 )
 
 func (pi *pinInterface) Info(path string, req filesystem.StatRequest) (*filesystem.Stat, filesystem.StatRequest, error) {
-	if path == "/" {
+	pp := splitPath(path)
+	if pp.proxied {
+		return pi.ipfs.Info(path, req)
+	}
+
+	callCtx, cancel := tcom.CallContext(pi.ctx)
+	defer cancel()
+
+	if !pp.isEntry() {
+		if err := pi.checkDirectory(callCtx, pp, path); err != nil {
+			return nil, filesystem.StatRequest{}, err
+		}
 		return rootStat, rootFilled, nil
 	}
-	return pi.ipfs.Info(path, req)
+
+	// pins are presented as links to their content
+	pinCid, err := pi.lookup(callCtx, pp, path)
+	if err != nil {
+		return nil, filesystem.StatRequest{}, err
+	}
+	return &filesystem.Stat{
+		Type: coreiface.TSymlink,
+		Size: uint64(len(ipfsTarget(pinCid))),
+	}, linkFilled, nil
+}
+
+func (pi *pinInterface) ExtractLink(path string) (string, error) {
+	pp := splitPath(path)
+	if pp.proxied {
+		return pi.ipfs.ExtractLink(path)
+	}
+	if !pp.isEntry() {
+		return "", iferrors.UnsupportedItem(path, errNotLink)
+	}
+
+	callCtx, cancel := tcom.CallContext(pi.ctx)
+	defer cancel()
+	pinCid, err := pi.lookup(callCtx, pp, path)
+	if err != nil {
+		return "", err
+	}
+	return ipfsTarget(pinCid), nil
 }
 
-func (pi *pinInterface) ExtractLink(path string) (string, error) { return pi.ipfs.ExtractLink(path) }
+func (pi *pinInterface) ExtendedAttributes(path string) (map[string]string, error) {
+	pp := splitPath(path)
+	if pp.proxied {
+		return pi.ipfs.(filesystem.ExtendedAttributer).ExtendedAttributes(path)
+	}
+	if !pp.isEntry() {
+		return nil, nil
+	}
+
+	callCtx, cancel := tcom.CallContext(pi.ctx)
+	defer cancel()
+	pinCid, err := pi.lookup(callCtx, pp, path)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		filesystem.XattrCID:  pinCid.String(),
+		filesystem.XattrPath: ipfsTarget(pinCid),
+	}, nil
+}
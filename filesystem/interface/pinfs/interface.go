@@ -3,7 +3,10 @@ package pinfs
 import (
 	"context"
 
+	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
 	"github.com/ipfs/go-ipfs/filesystem/interface/ipfscore"
 	coreiface "github.com/ipfs/interface-go-ipfs-core"
 )
@@ -12,18 +15,67 @@ type pinInterface struct {
 	ctx  context.Context
 	core coreiface.CoreAPI
 	ipfs filesystem.Interface
+
+	api         interfaceutils.Requester // only present if the core supports it (required for remote pins)
+	remoteCache remoteCache
 }
 
 func NewInterface(ctx context.Context, core coreiface.CoreAPI) filesystem.Interface {
+	api, _ := core.(interfaceutils.Requester)
 	return &pinInterface{
-		ctx:  ctx,
-		core: core,
-		ipfs: ipfscore.NewInterface(ctx, core, filesystem.IPFS),
+		ctx:         ctx,
+		core:        core,
+		ipfs:        ipfscore.NewInterface(ctx, core, filesystem.IPFS),
+		api:         api,
+		remoteCache: remoteCache{listings: make(map[string]*remoteListing)},
 	}
 }
 
 func (pi *pinInterface) ID() filesystem.ID { return filesystem.PinFS }
 func (pi *pinInterface) Close() error      { return pi.ipfs.Close() }
 func (pi *pinInterface) Rename(oldName, newName string) error {
+	if pp := splitPath(oldName); !pp.proxied {
+		return iferrors.UnsupportedRequest()
+	}
 	return pi.ipfs.Rename(oldName, newName)
 }
+
+// pins come and go, but the content they point to (proxied paths) is content addressed
+func (pi *pinInterface) IsImmutable(path string) bool { return splitPath(path).proxied }
+
+// pins are stored within the same node as the IPFS namespace
+func (pi *pinInterface) StorageStat() (*filesystem.StorageStat, error) {
+	return pi.ipfs.(filesystem.StorageStater).StorageStat()
+}
+
+// lookup returns the CID of the pin, that the entry at `path` represents
+func (pi *pinInterface) lookup(ctx context.Context, pp pinPath, path string) (cid.Cid, error) {
+	if pp.remainder != "" { // entries are links, not directories
+		return cid.Cid{}, iferrors.NotDir(path)
+	}
+	if pp.namespace == remoteDirectory {
+		return pi.remoteLookup(ctx, pp, path)
+	}
+	return pi.localLookup(ctx, pp, path)
+}
+
+// checkDirectory returns an error if the path is not one of our directories
+func (pi *pinInterface) checkDirectory(ctx context.Context, pp pinPath, path string) error {
+	switch {
+	case pp.namespace == "", pp.group == "":
+		return nil
+	case pp.namespace == localDirectory:
+		return pp.validLocal(path)
+	default:
+		services, err := pi.remoteServices(ctx)
+		if err != nil {
+			return err
+		}
+		for _, service := range services {
+			if pp.group == service {
+				return nil
+			}
+		}
+		return iferrors.NotExist(path)
+	}
+}
@@ -0,0 +1,77 @@
+package pinfs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	coreoptions "github.com/ipfs/interface-go-ipfs-core/options"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// local pins are named by their CID
+func (pi *pinInterface) localLookup(ctx context.Context, pp pinPath, path string) (cid.Cid, error) {
+	if err := pp.validLocal(path); err != nil {
+		return cid.Cid{}, err
+	}
+
+	pinCid, err := cid.Decode(pp.name)
+	if err != nil {
+		return cid.Cid{}, iferrors.NotExist(path)
+	}
+
+	typeOpt, err := coreoptions.Pin.IsPinned.Type(pp.group)
+	if err != nil {
+		return cid.Cid{}, iferrors.NotExist(path)
+	}
+
+	_, pinned, err := pi.core.Pin().IsPinned(ctx, corepath.IpfsPath(pinCid), typeOpt)
+	if err != nil {
+		return cid.Cid{}, iferrors.IO(path, err)
+	}
+	if !pinned {
+		return cid.Cid{}, iferrors.NotExist(path)
+	}
+	return pinCid, nil
+}
+
+// local entries are named by their CID, so the name of the link must be the target's CID
+func (pi *pinInterface) localAdd(ctx context.Context, pp pinPath, path string, target corepath.Resolved) error {
+	if nameCid, err := cid.Decode(pp.name); err != nil || !nameCid.Equals(target.Cid()) {
+		return iferrors.UnsupportedItem(path,
+			fmt.Errorf("local pins must be named by their target's CID (%s)", target.Cid()),
+		)
+	}
+
+	var recursive bool
+	switch pp.group {
+	case recursivePins:
+		recursive = true
+	case directPins:
+	default: // indirect pins are a consequence of recursive pins; they can't be made directly
+		return iferrors.Permission(path, fmt.Errorf("%s pins may not be added", pp.group))
+	}
+
+	if err := pi.core.Pin().Add(ctx, target, coreoptions.Pin.Recursive(recursive)); err != nil {
+		return iferrors.IO(path, fmt.Errorf("failed to pin %s: %w", target, err))
+	}
+	return nil
+}
+
+func (pi *pinInterface) localRemove(ctx context.Context, pp pinPath, path string) error {
+	if pp.group == indirectPins {
+		return iferrors.Permission(path, fmt.Errorf("%s pins may not be removed", pp.group))
+	}
+
+	pinCid, err := pi.localLookup(ctx, pp, path)
+	if err != nil {
+		return err
+	}
+
+	recursive := coreoptions.Pin.RmRecursive(pp.group == recursivePins)
+	if err := pi.core.Pin().Rm(ctx, corepath.IpfsPath(pinCid), recursive); err != nil {
+		return iferrors.IO(path, fmt.Errorf("failed to unpin: %w", err))
+	}
+	return nil
+}
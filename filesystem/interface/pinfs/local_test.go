@@ -0,0 +1,68 @@
+package pinfs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	fserrors "github.com/ipfs/go-ipfs/filesystem/errors"
+	dag "github.com/ipfs/go-merkledag"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+	coreoptions "github.com/ipfs/interface-go-ipfs-core/options"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// pinCore is a `CoreAPI` that only records pins
+// (the methods it doesn't provide will panic)
+type pinCore struct {
+	coreiface.CoreAPI
+	pins map[string]bool // CID -> recursive
+}
+
+type pinAPI struct {
+	coreiface.PinAPI
+	*pinCore
+}
+
+func (pc *pinCore) Pin() coreiface.PinAPI { return pinAPI{pinCore: pc} }
+func (pa pinAPI) Add(_ context.Context, path corepath.Path, opts ...coreoptions.PinAddOption) error {
+	settings, err := coreoptions.PinAddOptions(opts...)
+	if err != nil {
+		return err
+	}
+	pa.pins[path.(corepath.Resolved).Cid().String()] = settings.Recursive
+	return nil
+}
+
+func TestLocalAdd(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		core   = &pinCore{pins: make(map[string]bool)}
+		pi     = &pinInterface{ctx: ctx, core: core}
+		target = corepath.IpfsPath(dag.NodeWithData([]byte("pin")).Cid())
+		other  = dag.NodeWithData([]byte("other")).Cid()
+	)
+	add := func(path string) error { return pi.localAdd(ctx, splitPath(path), path, target) }
+
+	for _, path := range []string{
+		"/local/recursive/anything",
+		"/local/recursive/" + other.String(),
+	} {
+		var fsErr fserrors.Error
+		if err := add(path); !errors.As(err, &fsErr) || fsErr.Kind() != fserrors.InvalidItem {
+			t.Errorf("%s: expected error of kind %v, got: %v", path, fserrors.InvalidItem, err)
+		}
+	}
+	if len(core.pins) != 0 {
+		t.Fatalf("misnamed links were pinned: %v", core.pins)
+	}
+
+	if err := add("/local/direct/" + target.Cid().String()); err != nil {
+		t.Fatal(err)
+	}
+	if recursive, pinned := core.pins[target.Cid().String()]; !pinned || recursive {
+		t.Errorf("expected a direct pin of %s, got: %v", target.Cid(), core.pins)
+	}
+}
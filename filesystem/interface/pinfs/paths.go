@@ -0,0 +1,72 @@
+package pinfs
+
+import (
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// the root contains these namespaces
+const (
+	localDirectory  = "local"  // `/local/{recursive,direct,indirect}/{CID}`
+	remoteDirectory = "remote" // `/remote/{service}/{pin name}`
+)
+
+// the local namespace contains these groups
+const (
+	recursivePins = "recursive"
+	directPins    = "direct"
+	indirectPins  = "indirect"
+)
+
+var (
+	rootNames  = []string{localDirectory, remoteDirectory}
+	localNames = []string{recursivePins, directPins, indirectPins}
+)
+
+// pinPath is a path within the system, split into its components.
+// Paths outside of the namespaces (e.g. `/Qm...`) are proxied to IPFS.
+type pinPath struct {
+	namespace string // `local` or `remote` ("" for the root)
+	group     string // the pin type (local), or service name (remote)
+	name      string // the name of the pin's entry
+	remainder string // anything beneath the entry (entries are links, so this is invalid)
+	proxied   bool
+}
+
+func splitPath(path string) (pp pinPath) {
+	components := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 4)
+	switch components[0] {
+	case "":
+		return
+	case localDirectory, remoteDirectory:
+		pp.namespace = components[0]
+	default:
+		pp.proxied = true
+		return
+	}
+
+	fields := []*string{&pp.group, &pp.name, &pp.remainder}
+	for i, component := range components[1:] {
+		*fields[i] = component
+	}
+	return
+}
+
+// isEntry returns true if the path refers to a pin, rather than one of our directories
+func (pp pinPath) isEntry() bool { return pp.name != "" }
+
+// validLocal returns an error if the path's group is not a local pin type
+func (pp pinPath) validLocal(path string) error {
+	for _, pinType := range localNames {
+		if pp.group == pinType {
+			return nil
+		}
+	}
+	return iferrors.NotExist(path)
+}
+
+// ipfsTarget is the link target of pin entries
+func ipfsTarget(c cid.Cid) string { return corepath.IpfsPath(c).String() }
@@ -0,0 +1,23 @@
+package pinfs
+
+import "testing"
+
+func TestSplitPath(t *testing.T) {
+	for _, test := range []struct {
+		path     string
+		expected pinPath
+	}{
+		{"/", pinPath{}},
+		{"/local", pinPath{namespace: localDirectory}},
+		{"/local/recursive", pinPath{namespace: localDirectory, group: recursivePins}},
+		{"/local/direct/Qm", pinPath{namespace: localDirectory, group: directPins, name: "Qm"}},
+		{"/remote/svc/pin name/sub/path", pinPath{
+			namespace: remoteDirectory, group: "svc", name: "pin name", remainder: "sub/path",
+		}},
+		{"/Qm/sub", pinPath{proxied: true}},
+	} {
+		if pp := splitPath(test.path); pp != test.expected {
+			t.Errorf("%q: expected %#v, got %#v", test.path, test.expected, pp)
+		}
+	}
+}
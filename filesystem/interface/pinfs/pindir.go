@@ -2,23 +2,27 @@ package pinfs
 
 import (
 	"context"
-	gopath "path"
 
 	tcom "github.com/ipfs/go-ipfs/filesystem/interface"
 	coreiface "github.com/ipfs/interface-go-ipfs-core"
 	coreoptions "github.com/ipfs/interface-go-ipfs-core/options"
 )
 
-// TODO: make a pass on everything [AM] [hasty port]
-
-// a `Directory` containing the node's pins (as a stream of entries).
+// a `Directory` containing the node's pins of a particular type (as a stream of entries).
 type pinDirectoryStream struct {
-	pinAPI coreiface.PinAPI
+	pinAPI  coreiface.PinAPI
+	pinType string
 }
 
 func (ps *pinDirectoryStream) SendTo(ctx context.Context, receiver chan<- tcom.PartialEntry) error {
+	typeOpt, err := coreoptions.Pin.Ls.Type(ps.pinType)
+	if err != nil {
+		close(receiver)
+		return err
+	}
+
 	// get the pin stream
-	pinChan, err := ps.pinAPI.Ls(ctx, coreoptions.Pin.Ls.Recursive())
+	pinChan, err := ps.pinAPI.Ls(ctx, typeOpt)
 	if err != nil {
 		close(receiver)
 		return err
@@ -31,7 +35,7 @@ func (ps *pinDirectoryStream) SendTo(ctx context.Context, receiver chan<- tcom.P
 
 type pinEntryTranslator struct{ coreiface.Pin }
 
-func (pe *pinEntryTranslator) Name() string { return gopath.Base(pe.Path().String()) }
+func (pe *pinEntryTranslator) Name() string { return pe.Path().Cid().String() }
 func (pe *pinEntryTranslator) Error() error { return pe.Err() }
 
 // TODO: review cancel semantics;
@@ -54,3 +58,36 @@ out:
 	}
 	close(out)
 }
+
+// a `Directory` containing a list of names that is generated when the stream is opened.
+// (our namespaces, remote services, and remote pins)
+type nameDirectoryStream func(context.Context) ([]string, error)
+
+func (ns nameDirectoryStream) SendTo(ctx context.Context, receiver chan<- tcom.PartialEntry) error {
+	names, err := ns(ctx)
+	if err != nil {
+		close(receiver)
+		return err
+	}
+
+	go func() {
+		defer close(receiver)
+		for _, name := range names {
+			select {
+			case receiver <- nameEntry(name):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+type nameEntry string
+
+func (ne nameEntry) Name() string { return string(ne) }
+func (nameEntry) Error() error    { return nil }
+
+func staticNames(names []string) nameDirectoryStream {
+	return func(context.Context) ([]string, error) { return names, nil }
+}
@@ -0,0 +1,218 @@
+package pinfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	corepath "github.com/ipfs/interface-go-ipfs-core/path"
+)
+
+// remote pins are managed through the node's `pin remote` commands
+// (so that we use the services, and credentials, from the node's config)
+const (
+	remoteServicesCommand = "pin/remote/service/ls"
+	remoteLsCommand       = "pin/remote/ls"
+	remoteAddCommand      = "pin/remote/add"
+	remoteRmCommand       = "pin/remote/rm"
+
+	remoteServiceOption    = "service"
+	remoteNameOption       = "name"
+	remoteCIDOption        = "cid"
+	remoteStatusOption     = "status"
+	remoteBackgroundOption = "background"
+	remoteForceOption      = "force"
+
+	// we present pins regardless of their status; so that failed pins may be removed too
+	remoteStatuses = "queued,pinning,pinned,failed"
+
+	// how long a listing of a service's pins is considered current
+	remoteListingTimeout = 10 * time.Second
+)
+
+var errNoRemote = errors.New("core API does not support remote pinning requests")
+
+// remotePin is the subset of `pin remote ls`'s output that we use
+type remotePin struct {
+	Status string
+	Cid    string
+	Name   string
+}
+
+// remoteServicesOutput is the subset of `pin remote service ls`'s output that we use
+type remoteServicesOutput struct {
+	RemoteServices []struct{ Service string }
+}
+
+// remoteListing maps entry names to pins, for a single service
+type remoteListing struct {
+	names   []string // in the order the service provided them
+	pins    map[string]cid.Cid
+	expires time.Time
+}
+
+// remoteCache stores the last listing of each service, until it expires
+type remoteCache struct {
+	sync.Mutex
+	listings map[string]*remoteListing
+}
+
+func (pi *pinInterface) remoteServices(ctx context.Context) ([]string, error) {
+	if pi.api == nil {
+		return nil, iferrors.Other("/"+remoteDirectory, errNoRemote)
+	}
+
+	services := new(remoteServicesOutput)
+	if err := pi.api.Request(remoteServicesCommand).Exec(ctx, services); err != nil {
+		return nil, iferrors.IO("/"+remoteDirectory, err)
+	}
+
+	names := make([]string, len(services.RemoteServices))
+	for i, service := range services.RemoteServices {
+		names[i] = service.Service
+	}
+	return names, nil
+}
+
+// remoteListing returns the entries of the service's directory.
+// Entries are named after their pin;
+// pins without a name (or with the same name as a previous entry) are named by their CID.
+func (pi *pinInterface) remoteListing(ctx context.Context, service string) (*remoteListing, error) {
+	cache := &pi.remoteCache
+	cache.Lock()
+	defer cache.Unlock()
+
+	if listing, ok := cache.listings[service]; ok && time.Now().Before(listing.expires) {
+		return listing, nil
+	}
+
+	pins, err := pi.remotePins(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	listing := &remoteListing{
+		names:   make([]string, 0, len(pins)),
+		pins:    make(map[string]cid.Cid, len(pins)),
+		expires: time.Now().Add(remoteListingTimeout),
+	}
+	for _, pin := range pins {
+		pinCid, err := cid.Decode(pin.Cid)
+		if err != nil {
+			return nil, iferrors.Other(pin.Cid, err)
+		}
+
+		name := pin.Name
+		if _, taken := listing.pins[name]; name == "" || taken {
+			name = pinCid.String()
+			if _, taken := listing.pins[name]; taken {
+				continue // same CID pinned more than once; 1 entry is enough
+			}
+		}
+		listing.names = append(listing.names, name)
+		listing.pins[name] = pinCid
+	}
+
+	cache.listings[service] = listing
+	return listing, nil
+}
+
+// invalidate drops the service's listing, so that the next request fetches a new one
+func (cache *remoteCache) invalidate(service string) {
+	cache.Lock()
+	defer cache.Unlock()
+	delete(cache.listings, service)
+}
+
+func (pi *pinInterface) remotePins(ctx context.Context, service string) ([]remotePin, error) {
+	path := "/" + remoteDirectory + "/" + service
+	if pi.api == nil {
+		return nil, iferrors.Other(path, errNoRemote)
+	}
+
+	response, err := pi.api.Request(remoteLsCommand).
+		Option(remoteServiceOption, service).
+		Option(remoteStatusOption, remoteStatuses).
+		Send(ctx)
+	if err != nil {
+		return nil, iferrors.IO(path, err)
+	}
+	defer response.Close()
+	if response.Error != nil {
+		return nil, iferrors.NotExist(path) // most likely an unknown service
+	}
+
+	var (
+		pins    []remotePin
+		decoder = json.NewDecoder(response.Output)
+	)
+	for {
+		var pin remotePin
+		if err := decoder.Decode(&pin); err != nil {
+			if err == io.EOF {
+				return pins, nil
+			}
+			return nil, iferrors.IO(path, err)
+		}
+		pins = append(pins, pin)
+	}
+}
+
+// remoteLookup returns the CID of the entry within the service's directory
+func (pi *pinInterface) remoteLookup(ctx context.Context, pp pinPath, path string) (cid.Cid, error) {
+	listing, err := pi.remoteListing(ctx, pp.group)
+	if err != nil {
+		return cid.Cid{}, err
+	}
+	pinCid, ok := listing.pins[pp.name]
+	if !ok {
+		return cid.Cid{}, iferrors.NotExist(path)
+	}
+	return pinCid, nil
+}
+
+func (pi *pinInterface) remoteAdd(ctx context.Context, pp pinPath, path string, target corepath.Resolved) error {
+	if pi.api == nil {
+		return iferrors.Other(path, errNoRemote)
+	}
+	defer pi.remoteCache.invalidate(pp.group)
+
+	err := pi.api.Request(remoteAddCommand, target.String()).
+		Option(remoteServiceOption, pp.group).
+		Option(remoteNameOption, pp.name).
+		Option(remoteBackgroundOption, true).
+		Exec(ctx, nil)
+	if err != nil {
+		return iferrors.IO(path, fmt.Errorf("failed to pin %s: %w", target, err))
+	}
+	return nil
+}
+
+// remoteRemove removes the pins that the entry represents
+func (pi *pinInterface) remoteRemove(ctx context.Context, pp pinPath, path string) error {
+	pinCid, err := pi.remoteLookup(ctx, pp, path)
+	if err != nil {
+		return err
+	}
+	defer pi.remoteCache.invalidate(pp.group)
+
+	request := pi.api.Request(remoteRmCommand).
+		Option(remoteServiceOption, pp.group).
+		Option(remoteCIDOption, pinCid.String()).
+		Option(remoteStatusOption, remoteStatuses).
+		Option(remoteForceOption, true)
+	if pp.name != pinCid.String() { // entries named by CID may represent pins with any name
+		request = request.Option(remoteNameOption, pp.name)
+	}
+
+	if err := request.Exec(ctx, nil); err != nil {
+		return iferrors.IO(path, fmt.Errorf("failed to unpin: %w", err))
+	}
+	return nil
+}
@@ -1,6 +1,45 @@
 package pinfs
 
-// TODO: root behaviours; should unpin on call
-func (pi *pinInterface) Remove(path string) error          { return pi.ipfs.Remove(path) }
-func (pi *pinInterface) RemoveLink(path string) error      { return pi.ipfs.RemoveLink(path) }
-func (pi *pinInterface) RemoveDirectory(path string) error { return pi.ipfs.RemoveDirectory(path) }
+import (
+	tcom "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+)
+
+// removing an entry unpins it; our directories can't be removed
+func (pi *pinInterface) Remove(path string) error {
+	pp := splitPath(path)
+	switch {
+	case pp.proxied:
+		return pi.ipfs.Remove(path)
+	case !pp.isEntry():
+		return iferrors.IsDir(path)
+	case pp.remainder != "":
+		return iferrors.NotDir(path)
+	}
+
+	callCtx, cancel := tcom.CallContext(pi.ctx)
+	defer cancel()
+	if pp.namespace == remoteDirectory {
+		return pi.remoteRemove(callCtx, pp, path)
+	}
+	return pi.localRemove(callCtx, pp, path)
+}
+
+func (pi *pinInterface) RemoveLink(path string) error {
+	if pp := splitPath(path); pp.proxied {
+		return pi.ipfs.RemoveLink(path)
+	}
+	return pi.Remove(path)
+}
+
+func (pi *pinInterface) RemoveDirectory(path string) error {
+	pp := splitPath(path)
+	switch {
+	case pp.proxied:
+		return pi.ipfs.RemoveDirectory(path)
+	case pp.isEntry():
+		return iferrors.NotDir(path)
+	default:
+		return iferrors.Permission(path, errStatic)
+	}
+}
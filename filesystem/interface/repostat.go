@@ -0,0 +1,69 @@
+package interfaceutils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	httpapi "github.com/ipfs/go-ipfs-http-client"
+	"github.com/ipfs/go-ipfs/filesystem"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+)
+
+// repo statistics are expensive to compute on the node
+// and are requested often by file managers, so we cache them for some time
+const repoStatLifetime = time.Minute
+
+// Requester is the subset of the HTTP API client we use to issue commands
+// that are not part of the CoreAPI
+type Requester interface {
+	Request(command string, args ...string) httpapi.RequestBuilder
+}
+
+// repoStatCache stores the result of the last `repo stat` request, until it expires
+type repoStatCache struct {
+	sync.Mutex
+	stat    filesystem.StorageStat
+	expires time.Time
+}
+
+// StorageStat returns the node's repo statistics (see `filesystem.StorageStater`).
+// This requires the CoreAPI to also implement `Requester` (as the HTTP API client does).
+func (core *CoreExtended) StorageStat(ctx context.Context) (*filesystem.StorageStat, error) {
+	api, ok := core.CoreAPI.(Requester)
+	if !ok {
+		return nil, fmt.Errorf("core API %T does not support command requests: %w",
+			core.CoreAPI, iferrors.UnsupportedRequest(),
+		)
+	}
+
+	cache := &core.repoStat
+	cache.Lock()
+	defer cache.Unlock()
+
+	if time.Now().Before(cache.expires) {
+		stat := cache.stat
+		return &stat, nil
+	}
+
+	// NOTE: fields from `corerepo.Stat`
+	var repoStat struct {
+		RepoSize   uint64
+		StorageMax uint64
+		NumObjects uint64
+	}
+	if err := api.Request("repo/stat").Exec(ctx, &repoStat); err != nil {
+		return nil, iferrors.IO("repo/stat", err)
+	}
+
+	cache.stat = filesystem.StorageStat{
+		Capacity: repoStat.StorageMax,
+		Used:     repoStat.RepoSize,
+		Objects:  repoStat.NumObjects,
+	}
+	cache.expires = time.Now().Add(repoStatLifetime)
+
+	stat := cache.stat
+	return &stat, nil
+}
@@ -0,0 +1,84 @@
+package interfaceutils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	httpapi "github.com/ipfs/go-ipfs-http-client"
+	"github.com/ipfs/go-ipfs/filesystem"
+	fserrors "github.com/ipfs/go-ipfs/filesystem/errors"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+)
+
+// statCore is a `CoreAPI` that only answers `repo/stat` requests
+// (the methods it doesn't provide will panic)
+type statCore struct {
+	coreiface.CoreAPI
+	requests int
+	response string
+}
+
+type statRequest struct {
+	httpapi.RequestBuilder
+	core *statCore
+}
+
+func (sc *statCore) Request(command string, _ ...string) httpapi.RequestBuilder {
+	if command != "repo/stat" {
+		panic("unexpected command: " + command)
+	}
+	return statRequest{core: sc}
+}
+
+func (sr statRequest) Exec(_ context.Context, res interface{}) error {
+	sr.core.requests++
+	return json.Unmarshal([]byte(sr.core.response), res)
+}
+
+func TestStorageStat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("unsupported", func(t *testing.T) {
+		core := &CoreExtended{CoreAPI: struct{ coreiface.CoreAPI }{}}
+		_, err := core.StorageStat(ctx)
+		var fsErr fserrors.Error
+		if !errors.As(err, &fsErr) || fsErr.Kind() != fserrors.InvalidOperation {
+			t.Errorf("expected unsupported request error, got: %v", err)
+		}
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		var (
+			api  = &statCore{response: `{"RepoSize":100,"StorageMax":1000,"NumObjects":3}`}
+			core = &CoreExtended{CoreAPI: api}
+		)
+		expectStat := func(t *testing.T, expected filesystem.StorageStat, requests int) {
+			t.Helper()
+			stat, err := core.StorageStat(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *stat != expected {
+				t.Errorf("expected %#v, got %#v", expected, *stat)
+			}
+			if api.requests != requests {
+				t.Errorf("expected %d requests to the node, got %d", requests, api.requests)
+			}
+		}
+
+		initial := filesystem.StorageStat{Capacity: 1000, Used: 100, Objects: 3}
+		expectStat(t, initial, 1)
+
+		// repeated calls are served from the cache, even if the node's values change
+		api.response = `{"RepoSize":200,"StorageMax":1000,"NumObjects":4}`
+		expectStat(t, initial, 1)
+
+		// until it expires
+		core.repoStat.expires = time.Now().Add(-time.Second)
+		expectStat(t, filesystem.StorageStat{Capacity: 1000, Used: 200, Objects: 4}, 2)
+	})
+}
@@ -0,0 +1,29 @@
+package rootfs
+
+import (
+	"context"
+
+	tcom "github.com/ipfs/go-ipfs/filesystem/interface"
+)
+
+// a `Directory` containing the names of our mounts
+type staticNames []string
+
+func (sn staticNames) SendTo(ctx context.Context, receiver chan<- tcom.PartialEntry) error {
+	go func() {
+		defer close(receiver)
+		for _, name := range sn {
+			select {
+			case receiver <- nameEntry(name):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+type nameEntry string
+
+func (ne nameEntry) Name() string { return string(ne) }
+func (nameEntry) Error() error    { return nil }
@@ -0,0 +1,24 @@
+// Package rootfs provides a constructor to a `filesystem.Interface`,
+// which presents several other `filesystem.Interface`s within a single tree.
+//
+// Each system is mounted as a directory within the root, named after its ID:
+//
+//	/ipfs  -> IPFS
+//	/ipns  -> IPNS
+//	/keys  -> KeyFS
+//	/pins  -> PinFS
+//	/files -> Files (MFS)
+//
+// Requests are routed to the system that contains the path,
+// with the path made relative to that system (e.g. `/ipfs/Qm...` -> IPFS `/Qm...`).
+// Renames between systems are supported when both systems implement `filesystem.Relinker`
+// (e.g. from `/keys/...` to `/files/...`); nodes are moved by reference, rather than copied.
+// Such moves are not atomic; if the source can't be unlinked, the target is unlinked again.
+// The root implements `filesystem.Relinker` itself, so nodes may also be hard linked between those systems.
+//
+// Links that target `/ipfs/...` or `/ipns/...` are presented relative to the link's location,
+// so that hosts resolve them within the root (wherever it happens to be mounted),
+// rather than within the host's own root.
+// Links made with relative targets that resolve into those directories are stored as absolute targets;
+// so that the link remains valid outside of the root too.
+package rootfs
@@ -0,0 +1,347 @@
+package rootfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	tcom "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
+	ipld "github.com/ipfs/go-ipld-format"
+	coreiface "github.com/ipfs/interface-go-ipfs-core"
+)
+
+var (
+	errNotLink        = errors.New("not a link")
+	errMountPoint     = errors.New("systems may not be modified from the root")
+	errCrossingSystem = errors.New("nodes can not be moved to or from this system")
+
+	rootStat   = &filesystem.Stat{Type: coreiface.TDirectory}
+	rootFilled = filesystem.StatRequest{Type: true}
+)
+
+type rootInterface struct {
+	ctx        context.Context
+	names      []string // in listing order
+	systems    map[string]filesystem.Interface
+	namespaces []string // the mounted `linkNamespaces`
+}
+
+// NewInterface mounts the systems within a single root.
+// Each system must have a unique ID, that is one of those listed in the package documentation.
+func NewInterface(ctx context.Context, systems ...filesystem.Interface) (filesystem.Interface, error) {
+	ri := &rootInterface{
+		ctx:     ctx,
+		systems: make(map[string]filesystem.Interface, len(systems)),
+	}
+	for _, fs := range systems {
+		name, ok := mountNames[fs.ID()]
+		if !ok {
+			return nil, fmt.Errorf("%v can not be mounted within the root", fs.ID())
+		}
+		if _, ok := ri.systems[name]; ok {
+			return nil, fmt.Errorf("%v was provided more than once", fs.ID())
+		}
+		ri.systems[name] = fs
+	}
+	for _, id := range mountOrder {
+		if _, ok := ri.systems[mountNames[id]]; ok {
+			ri.names = append(ri.names, mountNames[id])
+		}
+	}
+	for _, id := range linkNamespaces {
+		if _, ok := ri.systems[mountNames[id]]; ok {
+			ri.namespaces = append(ri.namespaces, mountNames[id])
+		}
+	}
+	return ri, nil
+}
+
+// route returns the system that contains the path, and the path relative to that system.
+// The root itself is not contained by any system (nil is returned).
+func (ri *rootInterface) route(path string) (filesystem.Interface, string, error) {
+	name, remainder := splitRoot(path)
+	if name == "" {
+		return nil, remainder, nil
+	}
+	fs, ok := ri.systems[name]
+	if !ok {
+		return nil, "", iferrors.NotExist(path)
+	}
+	return fs, remainder, nil
+}
+
+// routeModification is route, for requests that modify the path.
+// The root, and the directories systems are mounted on, can not be modified.
+func (ri *rootInterface) routeModification(path string) (filesystem.Interface, string, error) {
+	fs, subPath, err := ri.route(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if subPath == "/" {
+		return nil, "", iferrors.Permission(path, errMountPoint)
+	}
+	return fs, subPath, nil
+}
+
+func (ri *rootInterface) ID() filesystem.ID { return filesystem.RootFS }
+
+func (ri *rootInterface) Close() (err error) {
+	for _, name := range ri.names {
+		if cErr := ri.systems[name].Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+	return
+}
+
+func (ri *rootInterface) Open(path string, flags filesystem.IOFlags) (filesystem.File, error) {
+	fs, subPath, err := ri.route(path)
+	if err != nil {
+		return nil, err
+	}
+	if fs == nil {
+		return nil, iferrors.IsDir(path)
+	}
+	return fs.Open(subPath, flags)
+}
+
+func (ri *rootInterface) OpenDirectory(path string) (filesystem.Directory, error) {
+	fs, subPath, err := ri.route(path)
+	if err != nil {
+		return nil, err
+	}
+	if fs == nil {
+		return tcom.UpgradePartialStream(tcom.NewPartialStream(ri.ctx, staticNames(ri.names)))
+	}
+	return fs.OpenDirectory(subPath)
+}
+
+func (ri *rootInterface) Info(path string, req filesystem.StatRequest) (*filesystem.Stat, filesystem.StatRequest, error) {
+	fs, subPath, err := ri.route(path)
+	if err != nil {
+		return nil, filesystem.StatRequest{}, err
+	}
+	if fs == nil {
+		return rootStat, rootFilled, nil
+	}
+	return fs.Info(subPath, req)
+}
+
+func (ri *rootInterface) ExtractLink(path string) (string, error) {
+	fs, subPath, err := ri.route(path)
+	if err != nil {
+		return "", err
+	}
+	if fs == nil {
+		return "", iferrors.UnsupportedItem(path, errNotLink)
+	}
+	target, err := fs.ExtractLink(subPath)
+	if err != nil {
+		return "", err
+	}
+	return hostTarget(path, target, ri.namespaces), nil
+}
+
+func (ri *rootInterface) Make(path string) error {
+	fs, subPath, err := ri.route(path)
+	if err != nil {
+		return err
+	}
+	if subPath == "/" {
+		return iferrors.Exist(path)
+	}
+	return fs.Make(subPath)
+}
+
+func (ri *rootInterface) MakeDirectory(path string) error {
+	fs, subPath, err := ri.route(path)
+	if err != nil {
+		return err
+	}
+	if subPath == "/" {
+		return iferrors.Exist(path)
+	}
+	return fs.MakeDirectory(subPath)
+}
+
+func (ri *rootInterface) MakeLink(path, target string) error {
+	fs, subPath, err := ri.route(path)
+	if err != nil {
+		return err
+	}
+	if subPath == "/" {
+		return iferrors.Exist(path)
+	}
+	return fs.MakeLink(subPath, nodeTarget(path, target, ri.namespaces))
+}
+
+func (ri *rootInterface) Remove(path string) error {
+	fs, subPath, err := ri.routeModification(path)
+	if err != nil {
+		return err
+	}
+	return fs.Remove(subPath)
+}
+
+func (ri *rootInterface) RemoveDirectory(path string) error {
+	fs, subPath, err := ri.routeModification(path)
+	if err != nil {
+		return err
+	}
+	return fs.RemoveDirectory(subPath)
+}
+
+func (ri *rootInterface) RemoveLink(path string) error {
+	fs, subPath, err := ri.routeModification(path)
+	if err != nil {
+		return err
+	}
+	return fs.RemoveLink(subPath)
+}
+
+func (ri *rootInterface) Rename(oldName, newName string) error {
+	oldFs, oldPath, err := ri.routeModification(oldName)
+	if err != nil {
+		return err
+	}
+	newFs, newPath, err := ri.routeModification(newName)
+	if err != nil {
+		return err
+	}
+	if oldFs == newFs {
+		return oldFs.Rename(oldPath, newPath)
+	}
+
+	// nodes may be moved between systems by reference, if both systems support it
+	source, ok := oldFs.(filesystem.Relinker)
+	if !ok {
+		return iferrors.Permission(oldName, errCrossingSystem)
+	}
+	target, ok := newFs.(filesystem.Relinker)
+	if !ok {
+		return iferrors.Permission(newName, errCrossingSystem)
+	}
+	return tcom.Relink(source, oldPath, target, newPath)
+}
+
+// the methods below relay the optional extensions of the systems, for paths within them
+
+// Node, Link, and Unlink route the request to the system that contains the path;
+// nodes from one system may be linked into another (e.g. when hard linking across systems).
+
+func (ri *rootInterface) Node(path string) (ipld.Node, error) {
+	fs, subPath, err := ri.route(path)
+	if err != nil {
+		return nil, err
+	}
+	if fs == nil {
+		return nil, iferrors.Permission(path, errMountPoint)
+	}
+	relinker, ok := fs.(filesystem.Relinker)
+	if !ok {
+		return nil, iferrors.UnsupportedRequest()
+	}
+	return relinker.Node(subPath)
+}
+
+func (ri *rootInterface) Link(path string, node ipld.Node) error {
+	fs, subPath, err := ri.route(path)
+	if err != nil {
+		return err
+	}
+	if subPath == "/" {
+		return iferrors.Exist(path)
+	}
+	relinker, ok := fs.(filesystem.Relinker)
+	if !ok {
+		return iferrors.UnsupportedRequest()
+	}
+	return relinker.Link(subPath, node)
+}
+
+func (ri *rootInterface) Unlink(path string) error {
+	fs, subPath, err := ri.routeModification(path)
+	if err != nil {
+		return err
+	}
+	relinker, ok := fs.(filesystem.Relinker)
+	if !ok {
+		return iferrors.UnsupportedRequest()
+	}
+	return relinker.Unlink(subPath)
+}
+
+func (ri *rootInterface) IsImmutable(path string) bool {
+	fs, subPath, err := ri.route(path)
+	if err != nil || fs == nil {
+		return false
+	}
+	immutable, ok := fs.(filesystem.Immutable)
+	return ok && immutable.IsImmutable(subPath)
+}
+
+func (ri *rootInterface) ExtendedAttributes(path string) (map[string]string, error) {
+	fs, subPath, err := ri.route(path)
+	if err != nil {
+		return nil, err
+	}
+	if fs == nil {
+		return nil, nil // the root is not a node
+	}
+	xattrs, ok := fs.(filesystem.ExtendedAttributer)
+	if !ok {
+		return nil, iferrors.UnsupportedRequest()
+	}
+	return xattrs.ExtendedAttributes(subPath)
+}
+
+func (ri *rootInterface) Chmod(path string, mode uint32) error {
+	fs, subPath, err := ri.routeModification(path)
+	if err != nil {
+		return err
+	}
+	modifier, ok := fs.(filesystem.MetadataModifier)
+	if !ok {
+		return iferrors.UnsupportedRequest()
+	}
+	return modifier.Chmod(subPath, mode)
+}
+
+func (ri *rootInterface) Chtimes(path string, mtime time.Time) error {
+	fs, subPath, err := ri.routeModification(path)
+	if err != nil {
+		return err
+	}
+	modifier, ok := fs.(filesystem.MetadataModifier)
+	if !ok {
+		return iferrors.UnsupportedRequest()
+	}
+	return modifier.Chtimes(subPath, mtime)
+}
+
+// all of our systems are backed by the same node; any of them may report its storage
+func (ri *rootInterface) StorageStat() (*filesystem.StorageStat, error) {
+	for _, name := range ri.names {
+		if stater, ok := ri.systems[name].(filesystem.StorageStater); ok {
+			return stater.StorageStat()
+		}
+	}
+	return nil, iferrors.UnsupportedRequest()
+}
+
+// Sync syncs every system that supports it.
+func (ri *rootInterface) Sync() (err error) {
+	for _, name := range ri.names {
+		syncer, ok := ri.systems[name].(filesystem.Syncer)
+		if !ok {
+			continue
+		}
+		if sErr := syncer.Sync(); sErr != nil && err == nil {
+			err = sErr
+		}
+	}
+	return
+}
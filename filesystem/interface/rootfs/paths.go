@@ -0,0 +1,85 @@
+package rootfs
+
+import (
+	gopath "path"
+	"strings"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+)
+
+var (
+	// the directory each system is mounted on
+	mountNames = map[filesystem.ID]string{
+		filesystem.IPFS:  "ipfs",
+		filesystem.IPNS:  "ipns",
+		filesystem.KeyFS: "keys",
+		filesystem.PinFS: "pins",
+		filesystem.Files: "files",
+	}
+	// the order in which the root is listed
+	mountOrder = []filesystem.ID{
+		filesystem.IPFS,
+		filesystem.IPNS,
+		filesystem.KeyFS,
+		filesystem.PinFS,
+		filesystem.Files,
+	}
+	// the mounts whose (absolute) link targets are resolved within the root
+	linkNamespaces = []filesystem.ID{
+		filesystem.IPFS,
+		filesystem.IPNS,
+	}
+)
+
+// splitRoot returns the name of the mount that contains the path,
+// and the path relative to that mount ("" and "/" for the root itself).
+func splitRoot(path string) (name, remainder string) {
+	path = strings.TrimPrefix(path, "/")
+	if path == "" {
+		return "", "/"
+	}
+	if i := strings.IndexByte(path, '/'); i != -1 {
+		return path[:i], path[i:]
+	}
+	return path, "/"
+}
+
+// hostTarget rewrites link targets within the namespaces, to be relative to the link.
+// (e.g. `/pins/local/recursive/Qm...` -> `/ipfs/Qm...` becomes `../../../ipfs/Qm...`)
+func hostTarget(linkPath, target string, namespaces []string) string {
+	if !gopath.IsAbs(target) {
+		return target
+	}
+	if name, _ := splitRoot(target); !contains(namespaces, name) {
+		return target
+	}
+
+	parent := strings.Trim(gopath.Dir(linkPath), "/")
+	var depth int
+	if parent != "" {
+		depth = strings.Count(parent, "/") + 1
+	}
+	return strings.Repeat("../", depth) + strings.TrimPrefix(gopath.Clean(target), "/")
+}
+
+// nodeTarget is the inverse of hostTarget;
+// relative link targets that resolve into the namespaces, are made absolute.
+func nodeTarget(linkPath, target string, namespaces []string) string {
+	if target == "" || gopath.IsAbs(target) {
+		return target
+	}
+	resolved := gopath.Join(gopath.Dir(linkPath), target)
+	if name, _ := splitRoot(resolved); !contains(namespaces, name) {
+		return target
+	}
+	return resolved
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package rootfs
+
+import "testing"
+
+func TestSplitRoot(t *testing.T) {
+	for _, test := range []struct {
+		path, name, remainder string
+	}{
+		{"/", "", "/"},
+		{"/ipfs", "ipfs", "/"},
+		{"/ipfs/Qm", "ipfs", "/Qm"},
+		{"/keys/self/sub/path", "keys", "/self/sub/path"},
+	} {
+		if name, remainder := splitRoot(test.path); name != test.name || remainder != test.remainder {
+			t.Errorf("%q: expected (%q, %q), got (%q, %q)",
+				test.path, test.name, test.remainder, name, remainder)
+		}
+	}
+}
+
+func TestLinkTargets(t *testing.T) {
+	namespaces := []string{"ipfs", "ipns"}
+	for _, test := range []struct {
+		link, node, host string
+	}{
+		{"/pins/local/recursive/Qm", "/ipfs/Qm", "../../../ipfs/Qm"},
+		{"/files/link", "/ipns/key/file", "../ipns/key/file"},
+		{"/link", "/ipfs/Qm", "ipfs/Qm"},
+		{"/files/link", "/etc/passwd", "/etc/passwd"}, // outside of the namespaces
+		{"/files/link", "sibling", "sibling"},
+	} {
+		if host := hostTarget(test.link, test.node, namespaces); host != test.host {
+			t.Errorf("%q -> %q: expected host target %q, got %q", test.link, test.node, test.host, host)
+		}
+		if node := nodeTarget(test.link, test.host, namespaces); node != test.node {
+			t.Errorf("%q -> %q: expected node target %q, got %q", test.link, test.host, test.node, node)
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package ufs
 
 import (
+	"io"
+
+	"github.com/ipfs/go-ipfs/filesystem"
+	interfaceutils "github.com/ipfs/go-ipfs/filesystem/interface"
+	iferrors "github.com/ipfs/go-ipfs/filesystem/interface/errors"
 	"github.com/ipfs/go-unixfs/mod"
 )
 
@@ -8,12 +13,26 @@ import (
 // that is intended to be shared by multiple callers
 // who utilize the lock and adjust the reference count accordingly
 // dagRefs are intended to be managed internally by keyfs.
+// Operations are limited by the flags the reference was opened with.
 type dagRef struct {
 	*mod.DagModifier
 	modifiedCallback ModifiedFunc
+	path             string
+	flags            filesystem.IOFlags
+}
+
+func (dr *dagRef) Read(buff []byte) (int, error) {
+	if !dr.flags.Readable() {
+		return 0, iferrors.Permission(dr.path, interfaceutils.ErrNotReadable)
+	}
+	return dr.DagModifier.Read(buff)
 }
 
 func (dr *dagRef) Truncate(size uint64) error {
+	if !dr.flags.Writable() {
+		return iferrors.Permission(dr.path, interfaceutils.ErrNotWritable)
+	}
+
 	err := dr.DagModifier.Truncate(int64(size))
 	if err != nil {
 		return err
@@ -31,6 +50,16 @@ func (dr *dagRef) Truncate(size uint64) error {
 }
 
 func (dr *dagRef) Write(buff []byte) (int, error) {
+	if !dr.flags.Writable() {
+		return 0, iferrors.Permission(dr.path, interfaceutils.ErrNotWritable)
+	}
+
+	if dr.flags&filesystem.IOAppend != 0 {
+		if _, err := dr.DagModifier.Seek(0, io.SeekEnd); err != nil {
+			return 0, err
+		}
+	}
+
 	wroteBytes, err := dr.DagModifier.Write(buff)
 	if err != nil {
 		return wroteBytes, err
@@ -47,7 +76,11 @@ func (dr *dagRef) Write(buff []byte) (int, error) {
 	return wroteBytes, err
 }
 
-func (dr *dagRef) Close() error {
+func (dr *dagRef) Close() error { return dr.Sync() }
+
+// Sync flushes buffered writes to the DAG,
+// and provides the new node to the modified callback (if any).
+func (dr *dagRef) Sync() error {
 	if err := dr.DagModifier.Sync(); err != nil {
 		return err
 	}
@@ -45,13 +45,10 @@ func (ui *ufsInterface) ID() filesystem.ID { return filesystem.IPFS } // TODO: d
 
 func (ui *ufsInterface) SetModifier(callback ModifiedFunc) { ui.modifiedCallback = callback }
 
-// TODO: stale docs
-// Open will either grab an existing dag modifier and wrap it as a keyFile
-// or construct a dag modifier and do the same
-// handling reference count internally/automatically via keyFile's `Close` method
-// TODO: parse flags and limit functionality contextually (RO, WO, etc.)
-// for now we always give full access
-func (ui *ufsInterface) Open(path string, _ filesystem.IOFlags) (filesystem.File, error) {
+// Open constructs a dag modifier for the file at path
+// whose operations are limited by the flags provided.
+// Paths are content paths, so files can not be created by this system.
+func (ui *ufsInterface) Open(path string, flags filesystem.IOFlags) (filesystem.File, error) {
 	callCtx, cancel := interfaceutils.CallContext(ui.ctx)
 	defer cancel()
 	ipldNode, err := ui.core.ResolveNode(callCtx, corepath.New(path))
@@ -59,6 +56,10 @@ func (ui *ufsInterface) Open(path string, _ filesystem.IOFlags) (filesystem.File
 		return nil, err
 	}
 
+	if flags&(filesystem.IOCreate|filesystem.IOExclusive) == filesystem.IOCreate|filesystem.IOExclusive {
+		return nil, iferrors.Exist(path)
+	}
+
 	dmod, err := mod.NewDagModifier(ui.ctx, ipldNode, ui.core.Dag(), func(r io.Reader) chunk.Splitter {
 		return chunk.NewBuzhash(r) // TODO: maybe switch this back to the default later; buzhash should be faster so we're keeping it temporarily while testing
 	})
@@ -66,7 +67,20 @@ func (ui *ufsInterface) Open(path string, _ filesystem.IOFlags) (filesystem.File
 		return nil, iferrors.Other(path, err)
 	}
 
-	return &dagRef{DagModifier: dmod, modifiedCallback: ui.modifiedCallback}, nil
+	file := &dagRef{
+		DagModifier:      dmod,
+		modifiedCallback: ui.modifiedCallback,
+		path:             path,
+		flags:            flags,
+	}
+
+	if flags&filesystem.IOTruncate != 0 {
+		if err := file.Truncate(0); err != nil {
+			return nil, err
+		}
+	}
+
+	return file, nil
 }
 
 func (*ufsInterface) Close() error { return nil }
@@ -0,0 +1,190 @@
+package interfaceutils
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/ipfs/go-unixfs"
+	unixpb "github.com/ipfs/go-unixfs/pb"
+)
+
+// UnixFS 1.5 metadata field numbers
+// our version of go-unixfs predates these fields,
+// but its decoder retains them (as unrecognized fields) which we handle here
+const (
+	ufsModeField  = 7 // Data.mode (uint32)
+	ufsMTimeField = 8 // Data.mtime (UnixTime)
+
+	unixTimeSecondsField = 1 // UnixTime.Seconds (int64)
+	unixTimeNanosField   = 2 // UnixTime.FractionalNanoseconds (fixed32)
+)
+
+// protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errMalformedField = errors.New("malformed protobuf field")
+
+// UFSMetadata holds the (optional) UnixFS 1.5 metadata of a node.
+type UFSMetadata struct {
+	Mode     uint32 // permission bits
+	HasMode  bool
+	MTime    time.Time
+	HasMTime bool
+}
+
+// protoField is a single encoded protobuf field
+type protoField struct {
+	number, wireType uint64
+	value            uint64 // for varint and fixed types
+	bytes            []byte // for length delimited types
+	encoded          []byte // the entire field, including its key
+}
+
+func parseFields(buffer []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(buffer) != 0 {
+		start := buffer
+		key, n := binary.Uvarint(buffer)
+		if n <= 0 {
+			return nil, errMalformedField
+		}
+		buffer = buffer[n:]
+
+		field := protoField{number: key >> 3, wireType: key & 7}
+		switch field.wireType {
+		case wireVarint:
+			if field.value, n = binary.Uvarint(buffer); n <= 0 {
+				return nil, errMalformedField
+			}
+			buffer = buffer[n:]
+		case wireFixed64:
+			if len(buffer) < 8 {
+				return nil, errMalformedField
+			}
+			field.value, buffer = binary.LittleEndian.Uint64(buffer), buffer[8:]
+		case wireFixed32:
+			if len(buffer) < 4 {
+				return nil, errMalformedField
+			}
+			field.value, buffer = uint64(binary.LittleEndian.Uint32(buffer)), buffer[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(buffer)
+			if n <= 0 || uint64(len(buffer)-n) < length {
+				return nil, errMalformedField
+			}
+			buffer = buffer[n:]
+			field.bytes, buffer = buffer[:length], buffer[length:]
+		default:
+			return nil, errMalformedField
+		}
+		field.encoded = start[:len(start)-len(buffer)]
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// ExtractUFSMetadata returns the metadata stored in the UnixFS data (if any).
+func ExtractUFSMetadata(pbData *unixpb.Data) (UFSMetadata, error) {
+	var metadata UFSMetadata
+	fields, err := parseFields(pbData.XXX_unrecognized)
+	if err != nil {
+		return metadata, err
+	}
+
+	for _, field := range fields {
+		switch {
+		case field.number == ufsModeField && field.wireType == wireVarint:
+			metadata.Mode, metadata.HasMode = uint32(field.value), true
+
+		case field.number == ufsMTimeField && field.wireType == wireBytes:
+			timeFields, err := parseFields(field.bytes)
+			if err != nil {
+				return metadata, err
+			}
+			var seconds, nanos int64
+			for _, timeField := range timeFields {
+				switch timeField.number {
+				case unixTimeSecondsField:
+					seconds = int64(timeField.value)
+				case unixTimeNanosField:
+					nanos = int64(timeField.value)
+				}
+			}
+			metadata.MTime, metadata.HasMTime = time.Unix(seconds, nanos), true
+		}
+	}
+	return metadata, nil
+}
+
+// UFSMetadataFromBytes returns the metadata stored in the UnixFS data of a node (if any).
+func UFSMetadataFromBytes(nodeData []byte) (UFSMetadata, error) {
+	pbData, err := unixfs.FromBytes(nodeData)
+	if err != nil {
+		return UFSMetadata{}, err
+	}
+	return ExtractUFSMetadata(pbData)
+}
+
+// SetUFSMetadata returns a copy of the UnixFS node data,
+// with the metadata fields that are set in `metadata` replaced.
+// (fields that are not set retain their existing value, if any)
+func SetUFSMetadata(nodeData []byte, metadata UFSMetadata) ([]byte, error) {
+	pbData, err := unixfs.FromBytes(nodeData)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := parseFields(pbData.XXX_unrecognized)
+	if err != nil {
+		return nil, err
+	}
+
+	var unrecognized []byte
+	for _, field := range fields {
+		if (field.number == ufsModeField && metadata.HasMode) ||
+			(field.number == ufsMTimeField && metadata.HasMTime) {
+			continue // replaced below
+		}
+		unrecognized = append(unrecognized, field.encoded...)
+	}
+
+	if metadata.HasMode {
+		unrecognized = appendVarintField(unrecognized, ufsModeField, uint64(metadata.Mode))
+	}
+	if metadata.HasMTime {
+		var unixTime []byte
+		unixTime = appendVarintField(unixTime, unixTimeSecondsField, uint64(metadata.MTime.Unix()))
+		if nanos := metadata.MTime.Nanosecond(); nanos != 0 {
+			unixTime = appendKey(unixTime, unixTimeNanosField, wireFixed32)
+			var fixed [4]byte
+			binary.LittleEndian.PutUint32(fixed[:], uint32(nanos))
+			unixTime = append(unixTime, fixed[:]...)
+		}
+		unrecognized = appendKey(unrecognized, ufsMTimeField, wireBytes)
+		unrecognized = appendUvarint(unrecognized, uint64(len(unixTime)))
+		unrecognized = append(unrecognized, unixTime...)
+	}
+
+	pbData.XXX_unrecognized = unrecognized
+	return proto.Marshal(pbData)
+}
+
+func appendUvarint(buffer []byte, value uint64) []byte {
+	var encoded [binary.MaxVarintLen64]byte
+	return append(buffer, encoded[:binary.PutUvarint(encoded[:], value)]...)
+}
+
+func appendKey(buffer []byte, number, wireType uint64) []byte {
+	return appendUvarint(buffer, number<<3|wireType)
+}
+
+func appendVarintField(buffer []byte, number, value uint64) []byte {
+	return appendUvarint(appendKey(buffer, number, wireVarint), value)
+}
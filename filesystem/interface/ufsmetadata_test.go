@@ -0,0 +1,58 @@
+package interfaceutils
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-unixfs"
+)
+
+func TestUFSMetadata(t *testing.T) {
+	var (
+		content  = []byte("metadata")
+		nodeData = unixfs.FilePBData(content, uint64(len(content)))
+		mtime    = time.Unix(1600000000, 123456789)
+	)
+
+	metadata, err := UFSMetadataFromBytes(nodeData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.HasMode || metadata.HasMTime {
+		t.Fatalf("unexpected metadata in plain node: %#v", metadata)
+	}
+
+	withMode, err := SetUFSMetadata(nodeData, UFSMetadata{Mode: 0640, HasMode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withBoth, err := SetUFSMetadata(withMode, UFSMetadata{MTime: mtime, HasMTime: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// replace the existing mode, rather than appending another
+	withBoth, err = SetUFSMetadata(withBoth, UFSMetadata{Mode: 0755, HasMode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if metadata, err = UFSMetadataFromBytes(withBoth); err != nil {
+		t.Fatal(err)
+	}
+	if !metadata.HasMode || metadata.Mode != 0755 {
+		t.Errorf("mode mismatch, expected %o got %o (set: %t)", 0755, metadata.Mode, metadata.HasMode)
+	}
+	if !metadata.HasMTime || !metadata.MTime.Equal(mtime) {
+		t.Errorf("mtime mismatch, expected %v got %v (set: %t)", mtime, metadata.MTime, metadata.HasMTime)
+	}
+
+	// the original fields must survive the round trip
+	pbData, err := unixfs.FromBytes(withBoth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pbData.GetData(), content) || pbData.GetFilesize() != uint64(len(content)) {
+		t.Errorf("node data was modified: %q (%d)", pbData.GetData(), pbData.GetFilesize())
+	}
+}
@@ -5,13 +5,33 @@ type IOFlags uint
 const (
 	// TODO: (re)consider how these should be defined and what we want/need
 	// for now we mimick SUSv7's <fcntl.h>
+
+	// access modes; exactly one of these is expected to be set
 	IOReadOnly IOFlags = 1 << iota
 	IOReadWrite
 	IOWriteOnly
-	/* consider if we want to support
-	   append (writes)
-	   create (conditional)
-	   excl(usive create)
-	   trunc(ate file)
-	*/
+
+	// modifiers; these may be combined with an access mode
+	IOAppend    // writes always occur at the end of the file
+	IOCreate    // create the file if it does not exist
+	IOExclusive // used with `IOCreate`; fail if the file already exists
+	IOTruncate  // truncate the file to 0 when opened (requires write access)
 )
+
+// IOAccessModes is the mask of the access mode flags
+const IOAccessModes = IOReadOnly | IOReadWrite | IOWriteOnly
+
+// Access returns the access mode of the flags (without modifiers)
+func (flags IOFlags) Access() IOFlags { return flags & IOAccessModes }
+
+// Readable returns true if the access mode permits reading
+func (flags IOFlags) Readable() bool {
+	access := flags.Access()
+	return access == IOReadOnly || access == IOReadWrite
+}
+
+// Writable returns true if the access mode permits writing
+func (flags IOFlags) Writable() bool {
+	access := flags.Access()
+	return access == IOWriteOnly || access == IOReadWrite
+}
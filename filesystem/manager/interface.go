@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/ipfs/go-ipfs/filesystem/manager/errors"
 	"github.com/multiformats/go-multiaddr"
@@ -20,15 +21,41 @@ type (
 
 	// Response contains the request that initiated it,
 	// along with an error (if encountered).
+	// Statistics are only populated on request (e.g. by a long listing).
 	Response struct {
 		Request
 		Error error
 		io.Closer
+		Statistics *Statistics
 	}
 	// Responses is simply a series of responses.
 	Responses = <-chan Response
 )
 
+type (
+	// Statistics describe an active instance.
+	Statistics struct {
+		Mounted  time.Time `json:"mounted"`
+		IPFSAPI  string    `json:"ipfsAPI,omitempty"`  // the IPFS API the instance's node was reached through
+		Activity *Activity `json:"activity,omitempty"` // (if the instance reports it)
+	}
+
+	// Activity counts the operations of an instance, since it was mounted.
+	Activity struct {
+		OpenFiles       int    `json:"openFiles"`
+		OpenDirectories int    `json:"openDirectories"`
+		BytesRead       uint64 `json:"bytesRead"`
+		BytesWritten    uint64 `json:"bytesWritten"`
+		Errors          uint64 `json:"errors"`
+	}
+
+	// Monitor may be implemented by a Response's Closer,
+	// to report the current activity of its instance.
+	Monitor interface {
+		Activity() Activity
+	}
+)
+
 type (
 	// Interface accepts bind `Request`s,
 	// and typically stores relevant `Response`s within its `Index`.
@@ -81,8 +108,9 @@ func ParseRequests(ctx context.Context, arguments ...string) (Requests, errors.S
 }
 
 type encodableResponse struct {
-	Request []byte `json:"request"`
-	Error   string `json:"error,omitempty" xml:",omitempty"`
+	Request    []byte      `json:"request"`
+	Error      string      `json:"error,omitempty" xml:",omitempty"`
+	Statistics *Statistics `json:"statistics,omitempty" xml:",omitempty"`
 }
 
 // TODO: needs text encoder for error values e.g. `--enc=textnl` shows request only
@@ -92,7 +120,10 @@ func (response Response) MarshalJSON() ([]byte, error) {
 		return nil, fmt.Errorf("response's Request field must be populated")
 	}
 
-	encoded := encodableResponse{Request: response.Bytes()}
+	encoded := encodableResponse{
+		Request:    response.Bytes(),
+		Statistics: response.Statistics,
+	}
 	if response.Error != nil {
 		encoded.Error = response.Error.Error()
 	}
@@ -117,6 +148,7 @@ func (resp *Response) UnmarshalJSON(b []byte) (err error) {
 			resp.Error = fmt.Errorf(decoded.Error)
 		}
 	}
+	resp.Statistics = decoded.Statistics
 	resp.Request, err = multiaddr.NewMultiaddrBytes(decoded.Request)
 	return
 }
@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/ipfs/go-ipfs/filesystem" // registers the request protocols
+	"github.com/multiformats/go-multiaddr"
+)
+
+func TestResponseStatistics(t *testing.T) {
+	request, err := multiaddr.NewMultiaddr("/fuse/ipfs/path/ipfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name       string
+		statistics *Statistics
+	}{
+		{name: "none"},
+		{name: "inactive", statistics: &Statistics{
+			Mounted: time.Unix(1600000000, 0).UTC(),
+			IPFSAPI: "/ip4/127.0.0.1/tcp/5001",
+		}},
+		{name: "active", statistics: &Statistics{
+			Mounted: time.Unix(1600000000, 0).UTC(),
+			Activity: &Activity{
+				OpenFiles:    2,
+				BytesRead:    1 << 20,
+				BytesWritten: 512,
+				Errors:       1,
+			},
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			encoded, err := json.Marshal(Response{Request: request, Statistics: test.statistics})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded Response
+			if err := json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !decoded.Request.Equal(request) {
+				t.Errorf("requests do not match\n\twanted: %v\n\tgot: %v", request, decoded.Request)
+			}
+			if !reflect.DeepEqual(decoded.Statistics, test.statistics) {
+				t.Errorf("statistics do not match\n\twanted: %#v\n\tgot: %#v", test.statistics, decoded.Statistics)
+			}
+		})
+	}
+}
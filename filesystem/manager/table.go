@@ -0,0 +1,73 @@
+package manager
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Table is a list of requests, along with options that apply to all of them.
+//
+// Tables may be encoded as JSON, either as a list of request strings:
+//
+//	["/fuse/ipfs/path/ipfs", "/fuse/ipns/path/ipns"]
+//
+// or as an object containing that list, and options:
+//
+//	{"requests": ["/fuse/ipfs/path/ipfs"], "options": {"persist": true}}
+//
+// Or as plain text, with one request per line.
+// Blank lines, and lines starting with `#` are ignored.
+type Table struct {
+	Requests []string               `json:"requests"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+const tableCommentPrefix = "#"
+
+// DecodeTable reads a `Table` from the input.
+// The encoding is determined by the first non-space character of the input.
+func DecodeTable(input io.Reader) (*Table, error) {
+	tableData, err := ioutil.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	table := new(Table)
+	trimmed := bytes.TrimSpace(tableData)
+	if len(trimmed) == 0 {
+		return table, nil
+	}
+
+	switch trimmed[0] {
+	case '[':
+		err = json.Unmarshal(trimmed, &table.Requests)
+	case '{':
+		err = json.Unmarshal(trimmed, table)
+	default:
+		table.Requests, err = decodeTableLines(trimmed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode mount table: %w", err)
+	}
+	return table, nil
+}
+
+func decodeTableLines(tableData []byte) ([]string, error) {
+	var (
+		requests []string
+		scanner  = bufio.NewScanner(bytes.NewReader(tableData))
+	)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, tableCommentPrefix) {
+			continue
+		}
+		requests = append(requests, line)
+	}
+	return requests, scanner.Err()
+}
@@ -0,0 +1,41 @@
+package manager
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeTable(t *testing.T) {
+	expected := []string{"/fuse/ipfs/path/ipfs", "/fuse/ipns/path/ipns"}
+
+	for _, test := range []struct {
+		name, input string
+		options     map[string]interface{}
+	}{
+		{name: "list", input: `["/fuse/ipfs/path/ipfs", "/fuse/ipns/path/ipns"]`},
+		{
+			name:    "object",
+			input:   `{"requests": ["/fuse/ipfs/path/ipfs", "/fuse/ipns/path/ipns"], "options": {"persist": true}}`,
+			options: map[string]interface{}{"persist": true},
+		},
+		{name: "lines", input: "# comment\n/fuse/ipfs/path/ipfs\n\n  /fuse/ipns/path/ipns  \n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			table, err := DecodeTable(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(table.Requests, expected) {
+				t.Errorf("requests do not match\n\twanted: %v\n\tgot: %v", expected, table.Requests)
+			}
+			if !reflect.DeepEqual(table.Options, test.options) {
+				t.Errorf("options do not match\n\twanted: %v\n\tgot: %v", test.options, table.Options)
+			}
+		})
+	}
+
+	if _, err := DecodeTable(strings.NewReader(`["/fuse/ipfs/path/ipfs"`)); err == nil {
+		t.Error("malformed table was decoded without error")
+	}
+}
@@ -0,0 +1,60 @@
+package filesystem
+
+import "github.com/multiformats/go-multiaddr"
+
+// Request options may follow the API pair of a request,
+// altering the instance that is bound for it.
+// (e.g. `/fuse/keyfs/publish/10s/path/mnt/keys`)
+const (
+	// PublishOption sets the IPNS publishing policy of a KeyFS instance.
+	// (see `keyfs.ParsePublishPolicy` for its values)
+	PublishOption     = int(Plan9Protocol) - 1
+	PublishOptionName = "publish"
+
+	// CacheOption enables caching of metadata, with the time-to-live for mutable paths.
+	// (see `cachefs.ParseTTL` for its values)
+	CacheOption     = PublishOption - 1
+	CacheOptionName = "cache"
+
+	// ReadAheadOption sets how many blocks are prefetched ahead of sequential reads, for IPFS and IPNS instances.
+	// (see `ipfscore.ParseReadAhead` for its values)
+	ReadAheadOption     = CacheOption - 1
+	ReadAheadOptionName = "readahead"
+
+	// ReferencesOption sets how long (and how many) unused key references are retained by a KeyFS instance.
+	// (see `keyfs.ParseReferenceCache` for its values)
+	ReferencesOption     = ReadAheadOption - 1
+	ReferencesOptionName = "references"
+)
+
+func init() {
+	if err := registerOptionProtocols(); err != nil {
+		panic(err)
+	}
+}
+
+func registerOptionProtocols() error {
+	for _, option := range []struct {
+		name string
+		code int
+	}{
+		{PublishOptionName, PublishOption},
+		{CacheOptionName, CacheOption},
+		{ReadAheadOptionName, ReadAheadOption},
+		{ReferencesOptionName, ReferencesOption},
+	} {
+		if err := multiaddr.AddProtocol(multiaddr.Protocol{
+			Name:  option.name,
+			Code:  option.code,
+			VCode: multiaddr.CodeToVarint(option.code),
+			Size:  multiaddr.LengthPrefixedVarSize,
+			Transcoder: multiaddr.NewTranscoderFromFunctions(
+				func(s string) ([]byte, error) { return []byte(s), nil },
+				func(b []byte) (string, error) { return string(b), nil },
+				nil),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
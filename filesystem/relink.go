@@ -0,0 +1,14 @@
+package filesystem
+
+import ipld "github.com/ipfs/go-ipld-format"
+
+// Relinker may optionally be implemented by `Interface`s whose nodes are IPLD nodes,
+// so that nodes may be moved between systems by reference, rather than by copying their data.
+type Relinker interface {
+	// Node returns the node at `path`
+	Node(path string) (ipld.Node, error)
+	// Link adds the node to the system at `path`, which must not already exist
+	Link(path string, node ipld.Node) error
+	// Unlink removes the node at `path` from the system, regardless of its type or contents
+	Unlink(path string) error
+}
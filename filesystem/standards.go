@@ -26,12 +26,13 @@ const (
 	Fuse          // fuse
 	Plan9Protocol // 9p
 
-	_     ID = iota
-	IPFS     // ipfs
-	IPNS     // ipns
-	Files    // file
-	PinFS    // pinfs
-	KeyFS    // keyfs
+	_      ID = iota
+	IPFS      // ipfs
+	IPNS      // ipns
+	Files     // file
+	PinFS     // pinfs
+	KeyFS     // keyfs
+	RootFS    // rootfs
 
 	// Existing Multicodec standards:
 	// TODO [review]: this protocol may be defined in another package
@@ -50,7 +51,7 @@ func init() {
 	if err = registerAPIProtocols(Fuse, Plan9Protocol); err != nil {
 		panic(err)
 	}
-	registerSystemIDs(IPFS, IPNS, Files, PinFS, KeyFS)
+	registerSystemIDs(IPFS, IPNS, Files, PinFS, KeyFS, RootFS)
 }
 
 var ErrUnexpectedID = errors.New("unexpected ID value")
@@ -42,11 +42,12 @@ func _() {
 	_ = x[Files-6]
 	_ = x[PinFS-7]
 	_ = x[KeyFS-8]
+	_ = x[RootFS-9]
 }
 
-const _ID_name = "ipfsipnsfilepinfskeyfs"
+const _ID_name = "ipfsipnsfilepinfskeyfsrootfs"
 
-var _ID_index = [...]uint8{0, 4, 8, 12, 17, 22}
+var _ID_index = [...]uint8{0, 4, 8, 12, 17, 22, 28}
 
 func (i ID) String() string {
 	i -= 4
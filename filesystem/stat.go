@@ -1,6 +1,8 @@
 package filesystem
 
 import (
+	"time"
+
 	coreiface "github.com/ipfs/interface-go-ipfs-core"
 )
 
@@ -9,23 +11,42 @@ type Stat struct {
 	Size      uint64
 	BlockSize uint64
 	Blocks    uint64
-	/* TODO: UFS 2 when it's done
-	ATimeNano int64
-	MTimeNano int64
-	CTimeNano int64 */
+	// UnixFS 1.5 metadata; these are optional within nodes
+	// so callers must check if they were filled
+	Mode  uint32 // permission bits (e.g. 0755)
+	MTime time.Time
+	// the number of names the node has within the system (i.e. hard links)
+	// systems only fill this in if they can determine it cheaply
+	// (it's not part of `StatRequestAll`, callers must request it explicitly)
+	Links uint64
+	/* TODO: if the standard ever defines them
+	ATime time.Time
+	CTime time.Time */
 }
 
 var StatRequestAll = StatRequest{
 	Type: true, Size: true, Blocks: true,
+	Mode: true, MTime: true,
 }
 
 type StatRequest struct {
 	Type   bool
 	Size   bool
 	Blocks bool
-	/* TODO: UFS 2 when it's done
+	Mode   bool
+	MTime  bool
+	Links  bool
+	/* TODO: if the standard ever defines them
 	ATime       bool
-	MTime       bool
 	CTime       bool
 	*/
 }
+
+// MetadataModifier may optionally be implemented by (writable) `Interface`s
+// to store UnixFS 1.5 metadata within their nodes.
+type MetadataModifier interface {
+	// Chmod sets the permission bits of the node at path
+	Chmod(path string, mode uint32) error
+	// Chtimes sets the modification time of the node at path
+	Chtimes(path string, mtime time.Time) error
+}
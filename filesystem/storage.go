@@ -0,0 +1,14 @@
+package filesystem
+
+// StorageStat holds statistics about the storage of the node which backs an `Interface`.
+type StorageStat struct {
+	Capacity uint64 // maximum size of the storage, in bytes
+	Used     uint64 // current size of the storage, in bytes
+	Objects  uint64 // number of objects within the storage
+}
+
+// StorageStater may optionally be implemented by an `Interface`,
+// to report statistics about its node's storage (e.g. for `statfs`).
+type StorageStater interface {
+	StorageStat() (*StorageStat, error)
+}
@@ -0,0 +1,9 @@
+package filesystem
+
+// Syncer may optionally be implemented by a `File`, or an `Interface`,
+// to commit pending modifications to durable storage (e.g. for `fsync`).
+// For a `File`, only its own modifications are committed.
+// For an `Interface`, all of its pending modifications are committed.
+type Syncer interface {
+	Sync() error
+}
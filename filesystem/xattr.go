@@ -0,0 +1,20 @@
+package filesystem
+
+// Extended attribute names provided by `ExtendedAttributer`s
+const (
+	XattrCID    = "user.ipfs.cid"    // CID of the node
+	XattrCodec  = "user.ipfs.codec"  // IPLD codec name of the node's CID
+	XattrBlocks = "user.ipfs.blocks" // number of child blocks linked to by the node
+	XattrPath   = "user.ipfs.path"   // content path used to reach the node (e.g. `/ipns/.../file`)
+
+	XattrIPNSName  = "user.ipns.name"  // IPNS name which contains the node
+	XattrIPNSValue = "user.ipns.value" // current value of the IPNS name
+)
+
+// ExtendedAttributer may optionally be implemented by an `Interface`,
+// to provide (read-only) extended attributes for its nodes.
+// Each implementation decides which attributes it provides.
+type ExtendedAttributer interface {
+	// ExtendedAttributes returns the attributes of the node at `path`, keyed by name
+	ExtendedAttributes(path string) (map[string]string, error)
+}
@@ -0,0 +1,168 @@
+package gc
+
+import (
+	"context"
+
+	cid "github.com/ipfs/go-cid"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	pin "github.com/ipfs/go-ipfs-pinner"
+	ipld "github.com/ipfs/go-ipld-format"
+)
+
+// DryRun reports each block that a collection would remove as KeyRemoved, without removing anything.
+// It runs as a collection does (and its progress is reported alike):
+// the changes to the roots are counted, and the blocks are checked against the index
+// while the GC lock is held, yielding it between batches and counting the pins that changed meanwhile;
+// so blocks that are added while it runs are reported as a collection would remove them.
+func (ix *Index) DryRun(ctx context.Context, bs bstore.GCBlockstore, pn pin.Pinner, bestEffortRoots []cid.Cid) <-chan Result {
+	ctx, cancel := context.WithCancel(ctx)
+
+	ix.lockCollecting()
+	c := ix.newCollection(ctx, bs, pn)
+	c.dryRun = true
+
+	output := make(chan Result, 128)
+	c.output = output
+
+	go func() {
+		defer cancel()
+		defer close(output)
+		defer ix.unlockCollecting()
+		defer func() { c.unlocker.Unlock() }()
+
+		if err := c.mark(bestEffortRoots); err != nil {
+			c.send(Result{Error: err})
+			return
+		}
+		if err := c.sweep(); err != nil {
+			c.send(Result{Error: err})
+			return
+		}
+		c.sendProgress()
+	}()
+
+	return output
+}
+
+// RootKind describes why a root is retained.
+type RootKind string
+
+const (
+	RecursivePin   RootKind = "recursive pin"
+	DirectPin      RootKind = "direct pin"
+	InternalPin    RootKind = "internal pin"
+	BestEffortRoot RootKind = "best-effort root"
+)
+
+// Retention is a reason for a block to be kept by garbage collection.
+type Retention struct {
+	Root cid.Cid
+	Kind RootKind
+	// Path is the names of the links from the root to the block
+	// (empty if the block is the root itself).
+	Path []string
+}
+
+// Explain reports every root that retains the block, along with the path from each root to it.
+// The changes to the roots are counted first, as they are by DryRun,
+// and the roots the index counted are searched (parts of DAGs that are not present are skipped).
+// Blocks that aren't retained aren't searched for, and each block is searched beneath once.
+func (ix *Index) Explain(ctx context.Context, bs bstore.GCBlockstore, pn pin.Pinner, bestEffortRoots []cid.Cid, target cid.Cid) ([]Retention, error) {
+	ix.lockCollecting()
+	defer ix.unlockCollecting()
+
+	c := ix.newCollection(ctx, bs, pn)
+	err := c.mark(bestEffortRoots)
+	c.unlocker.Unlock()
+	if err != nil && err != ErrCannotFetchAllLinks {
+		return nil, err
+	}
+
+	retained, err := ix.retained(target)
+	if err != nil || !retained {
+		return nil, err
+	}
+
+	var retentions []Retention
+	dkeys, err := ix.roots(directSet)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range dkeys {
+		if k.Equals(target) {
+			retentions = append(retentions, Retention{Root: k, Kind: DirectPin})
+		}
+	}
+
+	finder := &pathFinder{ng: ix.ng, target: target, paths: make(map[cid.Cid][]string)}
+	for _, roots := range []struct {
+		kind RootKind
+		set  rootSet
+	}{
+		{RecursivePin, recursiveSet},
+		{BestEffortRoot, bestEffortSet},
+		{InternalPin, internalSet},
+	} {
+		cids, err := ix.roots(roots.set)
+		if err != nil {
+			return nil, err
+		}
+		for _, root := range cids {
+			path, found, err := finder.find(ctx, root)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				retentions = append(retentions, Retention{Root: root, Kind: roots.kind, Path: path})
+			}
+		}
+	}
+
+	return retentions, nil
+}
+
+// pathFinder searches DAGs for the target, depth first,
+// remembering the path found beneath each block it searched,
+// so the parts of DAGs that roots share are only searched once.
+type pathFinder struct {
+	ng     ipld.NodeGetter
+	target cid.Cid
+	paths  map[cid.Cid][]string // to the target (nil if it's not beneath the block)
+}
+
+// find returns the names of the links that lead from c to the target.
+func (pf *pathFinder) find(ctx context.Context, c cid.Cid) ([]string, bool, error) {
+	if c.Equals(pf.target) {
+		return nil, true, nil
+	}
+	if path, searched := pf.paths[c]; searched {
+		return path, path != nil, nil
+	}
+	pf.paths[c] = nil
+
+	links, err := ipld.GetLinks(ctx, pf.ng, c)
+	switch err {
+	case nil:
+	case ipld.ErrNotFound:
+		return nil, false, nil
+	default:
+		return nil, false, &CannotFetchLinksError{c, err}
+	}
+
+	for _, link := range links {
+		path, found, err := pf.find(ctx, link.Cid)
+		if err != nil {
+			return nil, false, err
+		}
+		if found {
+			name := link.Name
+			if name == "" {
+				name = link.Cid.String()
+			}
+			path = append([]string{name}, path...)
+			pf.paths[c] = path
+			return path, true, nil
+		}
+	}
+	return nil, false, nil
+}
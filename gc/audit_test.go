@@ -0,0 +1,214 @@
+package gc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	bserv "github.com/ipfs/go-blockservice"
+	cid "github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	pin "github.com/ipfs/go-ipfs-pinner"
+	"github.com/ipfs/go-ipfs-pinner/dspinner"
+	ipld "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
+)
+
+func TestAudit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ds := dssync.MutexWrap(dstore.NewMapDatastore())
+	bs := bstore.NewGCBlockstore(bstore.NewBlockstore(ds), bstore.NewGCLocker())
+	dserv := dag.NewDAGService(bserv.New(bs, offline.Exchange(bs)))
+	pinner, err := dspinner.New(ctx, ds, dserv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := NewIndex(ds, bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pn := IndexPinner(pinner, index)
+
+	leaf := dag.NodeWithData([]byte("leaf"))
+	dir := dag.NodeWithData([]byte("dir"))
+	if err := dir.AddNodeLink("leaf", leaf); err != nil {
+		t.Fatal(err)
+	}
+	root := dag.NodeWithData([]byte("root"))
+	if err := root.AddNodeLink("dir", dir); err != nil {
+		t.Fatal(err)
+	}
+	mfsRoot := dag.NodeWithData([]byte("mfs"))
+	if err := mfsRoot.AddNodeLink("leaf", leaf); err != nil {
+		t.Fatal(err)
+	}
+	unpinned := dag.NodeWithData([]byte("unpinned"))
+	if err := dserv.AddMany(ctx, []ipld.Node{leaf, dir, root, mfsRoot, unpinned}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pn.Pin(ctx, root, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := pn.Pin(ctx, leaf, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// dryRun returns the blocks the dry run reports, and its errors
+	dryRun := func(t *testing.T, bs bstore.GCBlockstore, bestEffortRoots ...cid.Cid) ([]string, []error) {
+		var (
+			removed []string
+			errs    []error
+		)
+		for res := range index.DryRun(ctx, bs, pn, bestEffortRoots) {
+			switch {
+			case res.Error != nil:
+				errs = append(errs, res.Error)
+			case res.Progress != nil:
+			default:
+				removed = append(removed, res.KeyRemoved.String())
+			}
+		}
+		sort.Strings(removed)
+		return removed, errs
+	}
+
+	removed, errs := dryRun(t, bs, mfsRoot.Cid())
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if expected := []string{unpinned.Cid().String()}; !reflect.DeepEqual(removed, expected) {
+		t.Errorf("dry run results do not match\n\twanted: %v\n\tgot: %v", expected, removed)
+	}
+	if has, err := bs.Has(unpinned.Cid()); err != nil || !has {
+		t.Errorf("dry run removed a block (err: %v)", err)
+	}
+
+	// the dry run reports what a collection removes (such as a replaced best-effort root)
+	newMFSRoot := dag.NodeWithData([]byte("new mfs"))
+	if err := newMFSRoot.AddNodeLink("leaf", leaf); err != nil {
+		t.Fatal(err)
+	}
+	if err := dserv.Add(ctx, newMFSRoot); err != nil {
+		t.Fatal(err)
+	}
+	removed, errs = dryRun(t, bs, newMFSRoot.Cid())
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	var collected []string
+	for res := range index.Collect(ctx, bs, ds, pn, []cid.Cid{newMFSRoot.Cid()}) {
+		switch {
+		case res.Error != nil:
+			t.Fatal(res.Error)
+		case res.Progress == nil:
+			collected = append(collected, res.KeyRemoved.String())
+		}
+	}
+	sort.Strings(collected)
+	if len(collected) != 2 || !reflect.DeepEqual(removed, collected) {
+		t.Errorf("dry run results do not match the collection\n\twanted: %v\n\tgot: %v", collected, removed)
+	}
+	mfsRoot = newMFSRoot
+	if err := dserv.Add(ctx, unpinned); err != nil {
+		t.Fatal(err)
+	}
+
+	retentions, err := index.Explain(ctx, bs, pn, []cid.Cid{mfsRoot.Cid()}, leaf.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Retention{
+		{Root: leaf.Cid(), Kind: DirectPin},
+		{Root: root.Cid(), Kind: RecursivePin, Path: []string{"dir", "leaf"}},
+		{Root: mfsRoot.Cid(), Kind: BestEffortRoot, Path: []string{"leaf"}},
+	}
+	if !reflect.DeepEqual(retentions, expected) {
+		t.Errorf("retentions do not match\n\twanted: %v\n\tgot: %v", expected, retentions)
+	}
+
+	retentions, err = index.Explain(ctx, bs, pn, []cid.Cid{mfsRoot.Cid()}, unpinned.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(retentions) != 0 {
+		t.Errorf("unpinned block is retained by %v", retentions)
+	}
+
+	// roots that share a DAG are each reported, even though it's only searched once
+	other := dag.NodeWithData([]byte("other"))
+	if err := other.AddNodeLink("shared", dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := dserv.Add(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if err := pn.Pin(ctx, other, true); err != nil {
+		t.Fatal(err)
+	}
+	retentions, err = index.Explain(ctx, bs, pn, []cid.Cid{mfsRoot.Cid()}, leaf.Cid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := make(map[cid.Cid][]string, len(retentions))
+	for _, retention := range retentions {
+		paths[retention.Root] = retention.Path
+	}
+	for root, path := range map[cid.Cid][]string{
+		root.Cid():    {"dir", "leaf"},
+		other.Cid():   {"shared", "leaf"},
+		mfsRoot.Cid(): {"leaf"},
+	} {
+		if !reflect.DeepEqual(paths[root], path) {
+			t.Errorf("%s: expected path %v, got %v", root, path, paths[root])
+		}
+	}
+	if err := pn.Unpin(ctx, other.Cid(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	// blocks pinned while the dry run yields the GC lock are not reported, as a collection wouldn't remove them
+	pinnedDuring, unpinnedDuring := dag.NodeWithData([]byte("pinned during")), dag.NodeWithData([]byte("unpinned during"))
+	if err := dserv.AddMany(ctx, []ipld.Node{pinnedDuring, unpinnedDuring}); err != nil {
+		t.Fatal(err)
+	}
+	last := cid.NewSet()
+	last.Add(pinnedDuring.Cid())
+	yieldingBs := &yieldingBlockstore{GCBlockstore: bs, last: last, yielded: func() {
+		defer bs.PinLock().Unlock()
+		if err := pn.Pin(ctx, pinnedDuring, false); err != nil {
+			t.Error(err)
+		}
+	}}
+	index.BatchSize = 1
+	removed, errs = dryRun(t, yieldingBs, mfsRoot.Cid())
+	index.BatchSize = DefaultBatchSize
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	expectedRemoved := []string{unpinned.Cid().String(), unpinnedDuring.Cid().String(), other.Cid().String()}
+	sort.Strings(expectedRemoved)
+	if !reflect.DeepEqual(removed, expectedRemoved) {
+		t.Errorf("dry run results do not match\n\twanted: %v\n\tgot: %v", expectedRemoved, removed)
+	}
+
+	// errors are reported once, followed by their summary
+	incomplete := dag.NodeWithData([]byte("incomplete"))
+	if err := incomplete.AddNodeLink("missing", dag.NodeWithData([]byte("missing"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := dserv.Add(ctx, incomplete); err != nil {
+		t.Fatal(err)
+	}
+	pn.PinWithMode(incomplete.Cid(), pin.Recursive)
+	_, errs = dryRun(t, bs, mfsRoot.Cid())
+	if len(errs) != 2 || !errors.As(errs[0], new(*CannotFetchLinksError)) || errs[1] != ErrCannotFetchAllLinks {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
@@ -22,10 +22,12 @@ import (
 var log = logging.Logger("gc")
 
 // Result represents an incremental output from a garbage collection
-// run.  It contains either an error, or the cid of a removed object.
+// run.  It contains either an error, the cid of a removed object,
+// or the progress of an incremental collection.
 type Result struct {
 	KeyRemoved cid.Cid
 	Error      error
+	Progress   *Progress
 }
 
 // GC performs a mark and sweep garbage collection of the blocks in the blockstore
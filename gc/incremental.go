@@ -0,0 +1,305 @@
+package gc
+
+import (
+	"context"
+
+	cid "github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	pin "github.com/ipfs/go-ipfs-pinner"
+)
+
+// Phase identifies what an incremental collection is doing.
+type Phase string
+
+const (
+	PhaseMark  Phase = "mark"
+	PhaseSweep Phase = "sweep"
+)
+
+// Progress reports how far an incremental collection has come.
+// It's sent as a Result whenever the collection yields the GC lock.
+type Progress struct {
+	Phase   Phase
+	Marked  uint64 // reference counts changed by this collection
+	Scanned uint64 // blocks checked against the index
+	Removed uint64 // blocks removed from the blockstore
+}
+
+// collection is a single run of Index.Collect.
+type collection struct {
+	ctx      context.Context
+	index    *Index
+	bs       bstore.GCBlockstore
+	pn       pin.Pinner
+	output   chan<- Result
+	unlocker bstore.Unlocker
+
+	progress Progress
+	pending  int  // blocks counted or swept since the lock was last yielded
+	sweeping bool // changes to the roots are counted whenever the lock is regained
+	dryRun   bool // unreferenced blocks are reported, but not removed
+	updating bool // changes are being counted (and may yield the lock themselves)
+
+	counter     *counter
+	fetchErrors bool
+}
+
+// Collect performs a garbage collection using the index.
+// Like GC, it removes every block that isn't reachable from the pins (and best-effort roots),
+// but it only walks the parts of DAGs whose roots changed since the last collection,
+// and it yields the GC lock between batches of blocks, so adds and pins aren't blocked for the entire run.
+// Pins that change while the lock is yielded are counted before the sweep continues.
+// Its progress is reported in Results between batches.
+func (ix *Index) Collect(ctx context.Context, bs bstore.GCBlockstore, dstor dstore.Datastore, pn pin.Pinner, bestEffortRoots []cid.Cid) <-chan Result {
+	ctx, cancel := context.WithCancel(ctx)
+
+	ix.lockCollecting()
+	c := ix.newCollection(ctx, bs, pn)
+
+	output := make(chan Result, 128)
+	c.output = output
+
+	go func() {
+		defer cancel()
+		defer close(output)
+		defer ix.unlockCollecting()
+		defer func() { c.unlocker.Unlock() }()
+
+		if err := c.mark(bestEffortRoots); err != nil {
+			c.send(Result{Error: err})
+			return
+		}
+		if err := c.sweep(); err != nil {
+			c.send(Result{Error: err})
+			return
+		}
+		c.sendProgress()
+
+		gds, ok := dstor.(dstore.GCDatastore)
+		if !ok {
+			return
+		}
+		if err := gds.CollectGarbage(); err != nil {
+			c.send(Result{Error: err})
+		}
+	}()
+
+	return output
+}
+
+// newCollection takes the GC lock, and returns a collection
+// (which has no output unless it's set).
+func (ix *Index) newCollection(ctx context.Context, bs bstore.GCBlockstore, pn pin.Pinner) *collection {
+	c := &collection{
+		ctx:      ctx,
+		index:    ix,
+		bs:       bs,
+		pn:       pn,
+		unlocker: bs.GCLock(),
+	}
+	c.counter = ix.newCounter(
+		func() error {
+			c.progress.Marked++
+			return c.step()
+		},
+		func(err *CannotFetchLinksError) error {
+			c.fetchErrors = true
+			if !c.send(Result{Error: err}) {
+				return c.ctx.Err()
+			}
+			return nil
+		},
+	)
+	return c
+}
+
+// send sends the result to the output, if the collection has one.
+func (c *collection) send(res Result) bool {
+	if c.output == nil {
+		return c.ctx.Err() == nil
+	}
+	select {
+	case c.output <- res:
+		return true
+	case <-c.ctx.Done():
+		return false
+	}
+}
+
+func (c *collection) sendProgress() bool {
+	progress := c.progress
+	return c.send(Result{Progress: &progress})
+}
+
+// step counts a block as processed,
+// and yields the GC lock if the batch is complete.
+func (c *collection) step() error {
+	if c.pending++; c.pending < c.index.batchSize() {
+		return c.ctx.Err()
+	}
+	c.pending = 0
+
+	c.unlocker.Unlock()
+	c.sendProgress() // (adds and pins may proceed while this is received)
+	c.unlocker = c.bs.GCLock()
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+
+	if c.sweeping && !c.updating {
+		// blocks pinned while the lock was yielded must not be swept
+		return c.update(nil)
+	}
+	return nil
+}
+
+func (ix *Index) batchSize() int {
+	if ix.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return ix.BatchSize
+}
+
+// mark counts the changes to the roots since the last collection,
+// including the replacement of the best-effort roots.
+func (c *collection) mark(bestEffortRoots []cid.Cid) error {
+	c.progress.Phase = PhaseMark
+	return c.update(func() error {
+		// blocks that were missing before may be present now
+		// (this precedes the changes, so blocks they find missing aren't retried)
+		if err := c.counter.relink(c.ctx); err != nil {
+			return err
+		}
+		return c.counter.replaceRoots(c.ctx, bestEffortSet, bestEffortRoots)
+	})
+}
+
+// update counts the changes to the pins until none are pending
+// (count is called before the first changes are, if it's not nil).
+// If a root's DAG couldn't be walked entirely, ErrCannotFetchAllLinks is returned
+// once the changes are counted.
+// If the count is interrupted, the changes that weren't counted are left to the next one.
+func (c *collection) update(count func() error) error {
+	c.updating = true
+	defer func() { c.updating = false }()
+
+	for first := count != nil; first || c.index.hasChanges(); first = false {
+		changes := c.index.beginCounting()
+		if err := c.countPending(&changes, first, count); err != nil {
+			c.index.resumeCounting(changes)
+			return err
+		}
+		if err := c.index.finishCounting(); err != nil {
+			return err
+		}
+	}
+
+	if c.fetchErrors {
+		return ErrCannotFetchAllLinks
+	}
+	return nil
+}
+
+func (c *collection) countPending(changes *pendingChanges, first bool, count func() error) error {
+	if changes.rebuild {
+		log.Warn("GC index was not saved entirely, every root will be counted again")
+		if err := c.index.clear(); err != nil {
+			return err
+		}
+		changes.rebuild = false // (the roots are counted again by the resync)
+	}
+	if first {
+		if err := count(); err != nil {
+			return err
+		}
+	}
+	return c.countChanges(*changes)
+}
+
+func (c *collection) countChanges(changes pendingChanges) error {
+	ctx, cn := c.ctx, c.counter
+	if changes.resync {
+		if err := cn.resyncPins(ctx, c.pn); err != nil {
+			return err
+		}
+	} else if err := cn.updatePins(ctx, c.pn, changes.pins); err != nil {
+		return err
+	}
+	if changes.resync || changes.internal {
+		ikeys, err := c.pn.InternalPins(ctx)
+		if err != nil {
+			return err
+		}
+		if err := cn.replaceRoots(ctx, internalSet, ikeys); err != nil {
+			return err
+		}
+	}
+	for _, root := range changes.bestEffort {
+		if err := cn.addRoot(ctx, bestEffortSet, root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sweep removes the blocks that aren't referenced (or only reports them, in a dry run).
+// References are checked while the GC lock is held,
+// so blocks that are pinned while the lock is yielded survive.
+func (c *collection) sweep() error {
+	c.progress.Phase = PhaseSweep
+	c.sweeping = true
+
+	keys, err := c.bs.AllKeysChan(c.ctx)
+	if err != nil {
+		return err
+	}
+
+	var deleteErrors bool
+	for {
+		var (
+			k  cid.Cid
+			ok bool
+		)
+		select {
+		case k, ok = <-keys:
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		}
+		if !ok {
+			break
+		}
+		c.progress.Scanned++
+
+		retained, err := c.index.retained(k)
+		if err != nil {
+			return err
+		}
+		if !retained {
+			var err error
+			if !c.dryRun {
+				err = c.bs.DeleteBlock(k)
+			}
+			if err != nil {
+				deleteErrors = true
+				if !c.send(Result{Error: &CannotDeleteBlockError{k, err}}) {
+					return c.ctx.Err()
+				}
+			} else {
+				c.progress.Removed++
+				if !c.send(Result{KeyRemoved: k}) {
+					return c.ctx.Err()
+				}
+			}
+		}
+
+		if err := c.step(); err != nil {
+			return err
+		}
+	}
+
+	if deleteErrors {
+		return ErrCannotDeleteSomeBlocks
+	}
+	return nil
+}
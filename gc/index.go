@@ -0,0 +1,866 @@
+package gc
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	bserv "github.com/ipfs/go-blockservice"
+	cid "github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	dsq "github.com/ipfs/go-datastore/query"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	dshelp "github.com/ipfs/go-ipfs-ds-help"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	pin "github.com/ipfs/go-ipfs-pinner"
+	ipld "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/ipfs/go-verifcid"
+)
+
+// DefaultBatchSize is the number of blocks an incremental collection
+// counts or sweeps before yielding the GC lock.
+const DefaultBatchSize = 4096
+
+// defaultMaxRootWrites is the number of changes to the index
+// that counting a single root keeps in memory, before they're saved.
+const defaultMaxRootWrites = 1 << 18
+
+var (
+	indexPrefix    = dstore.NewKey("/local/gc")
+	stateKey       = dstore.NewKey("/state")
+	refsPrefix     = dstore.NewKey("/refs")
+	unlinkedPrefix = dstore.NewKey("/unlinked")
+	rootsPrefix    = dstore.NewKey("/roots")
+)
+
+// Index state flags.
+const (
+	stateIndexed  byte = 1 << iota // the roots have been counted
+	stateChanged                   // pins changed since they were last counted
+	stateCounting                  // a root's references were partially saved (and can't be trusted if this persists)
+)
+
+// rootSet is a kind of root, whose counted members are recorded in the index.
+type rootSet struct {
+	name   string
+	mode   pin.Mode   // of the pins within the set
+	tree   bool       // the root's DAG is retained, not just the block
+	policy linkPolicy // for the root's DAG
+}
+
+var (
+	recursiveSet  = rootSet{name: "recursive", mode: pin.Recursive, tree: true, policy: strictLinks}
+	directSet     = rootSet{name: "direct", mode: pin.Direct, policy: strictLinks}
+	internalSet   = rootSet{name: "internal", tree: true, policy: strictLinks}
+	bestEffortSet = rootSet{name: "best-effort", tree: true, policy: bestEffortLinks}
+)
+
+func (s rootSet) prefix() dstore.Key { return rootsPrefix.ChildString(s.name) }
+
+func (s rootSet) key(c cid.Cid) dstore.Key { return s.prefix().Child(dshelp.CidToDsKey(c)) }
+
+// Index is a persisted count of the references to the blocks reachable from the node's roots
+// (pins, the pinner's internal state, and best-effort roots such as the MFS root).
+//
+// A block is retained while anything references it:
+// a root, or a link from a block whose DAG is retained.
+// When a root is added, only the blocks that weren't referenced yet are walked,
+// and when one is removed, only the blocks that are no longer referenced are;
+// a collection counts the roots that changed since the last one,
+// and sweeps the blocks that aren't referenced.
+//
+// Pins that change are only noted as they change (see PinChanged),
+// as are new best-effort roots (see BestEffortRootAdded);
+// their DAGs are walked by the next collection.
+// The counts of each root are saved together,
+// so a collection that's interrupted leaves the roots it didn't finish to the next one.
+type Index struct {
+	// BatchSize is the number of blocks a collection counts or sweeps
+	// before yielding the GC lock (allowing adds and pins to proceed).
+	BatchSize int
+
+	ds            dstore.Datastore
+	ng            ipld.NodeGetter
+	maxRootWrites int // changes kept in memory while a root is counted
+
+	// collecting serializes collections (which yield the GC lock to each other otherwise).
+	// (see lockCollecting)
+	collecting sync.Mutex
+
+	mu              sync.Mutex // protects the fields below
+	state           byte       // persisted state flags
+	changed         *cid.Set   // pins that changed since they were last counted
+	bestEffortAdded *cid.Set   // best-effort roots added since they were last counted
+	collectingRoots bool       // best-effort roots are recorded (while a collection runs)
+	internalChanged bool       // the pinner's internal pins changed since they were last counted
+	resync          bool       // every pin must be compared with the counted roots
+	rebuild         bool       // the counts can't be trusted, and must be started over
+}
+
+// NewIndex returns the index stored in the datastore,
+// which walks the DAGs of the blocks within the blockstore.
+func NewIndex(dstor dstore.Datastore, bs bstore.Blockstore) (*Index, error) {
+	ix := &Index{
+		BatchSize:       DefaultBatchSize,
+		ds:              namespace.Wrap(dstor, indexPrefix),
+		ng:              dag.NewDAGService(bserv.New(bs, offline.Exchange(bs))),
+		maxRootWrites:   defaultMaxRootWrites,
+		changed:         cid.NewSet(),
+		bestEffortAdded: cid.NewSet(),
+	}
+
+	state, err := ix.ds.Get(stateKey)
+	switch err {
+	case dstore.ErrNotFound:
+		ix.resync = true
+		return ix, nil
+	case nil:
+	default:
+		return nil, err
+	}
+
+	if len(state) != 1 {
+		return nil, fmt.Errorf("GC index state is corrupt (%x)", state)
+	}
+	ix.state = state[0]
+	// changes that weren't counted before the node stopped are lost,
+	// so every pin is compared again
+	ix.resync = ix.state&(stateIndexed|stateChanged) != stateIndexed
+	ix.rebuild = ix.state&stateCounting != 0
+	return ix, nil
+}
+
+// saveState persists the state flags.
+// Caller must hold ix.mu.
+func (ix *Index) saveState() error {
+	return ix.ds.Put(stateKey, []byte{ix.state})
+}
+
+// setState sets the state flag, persisting it if it wasn't set already.
+// Caller must hold ix.mu.
+func (ix *Index) setState(flag byte) {
+	if ix.state&flag != 0 {
+		return
+	}
+	ix.state |= flag
+	if err := ix.saveState(); err != nil {
+		log.Errorf("failed to save GC index state: %s", err)
+	}
+}
+
+// PinChanged records that the block may have been pinned or unpinned.
+// The index isn't walked until the next collection, which compares the block's pins
+// with the roots it has counted.
+func (ix *Index) PinChanged(c cid.Cid) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.changed.Add(c)
+	ix.setState(stateChanged)
+}
+
+// InternalPinsChanged records that the pinner's internal state may have been replaced.
+func (ix *Index) InternalPinsChanged() {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.internalChanged = true
+	ix.setState(stateChanged)
+}
+
+// BestEffortRootAdded records a new best-effort root, such as a flushed MFS root,
+// while a collection is running; it's counted before the collection sweeps any further.
+// (collections are given every best-effort root when they start,
+// so roots added between them don't need to be recorded)
+func (ix *Index) BestEffortRootAdded(c cid.Cid) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.collectingRoots {
+		ix.bestEffortAdded.Add(c)
+	}
+}
+
+// lockCollecting waits for other collections to finish,
+// and records the best-effort roots that are added until unlockCollecting is called.
+func (ix *Index) lockCollecting() {
+	ix.collecting.Lock()
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.collectingRoots = true
+}
+
+func (ix *Index) unlockCollecting() {
+	ix.mu.Lock()
+	ix.collectingRoots = false
+	ix.bestEffortAdded = cid.NewSet()
+	ix.mu.Unlock()
+	ix.collecting.Unlock()
+}
+
+// pendingChanges are the changes to the roots that haven't been counted.
+type pendingChanges struct {
+	rebuild, resync, internal bool
+	pins, bestEffort          []cid.Cid
+}
+
+func (ix *Index) hasChanges() bool {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.rebuild || ix.resync || ix.internalChanged ||
+		ix.changed.Len() != 0 || ix.bestEffortAdded.Len() != 0
+}
+
+// beginCounting takes the pending changes.
+func (ix *Index) beginCounting() pendingChanges {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	changes := pendingChanges{
+		rebuild:    ix.rebuild,
+		resync:     ix.resync || ix.rebuild,
+		internal:   ix.internalChanged,
+		pins:       ix.changed.Keys(),
+		bestEffort: ix.bestEffortAdded.Keys(),
+	}
+	ix.rebuild, ix.resync, ix.internalChanged = false, false, false
+	ix.changed, ix.bestEffortAdded = cid.NewSet(), cid.NewSet()
+	return changes
+}
+
+// resumeCounting returns the changes that weren't counted entirely to the pending changes.
+// (the roots that were counted remain counted, so only the rest are walked by the next count)
+func (ix *Index) resumeCounting(changes pendingChanges) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.rebuild = ix.rebuild || changes.rebuild
+	ix.resync = ix.resync || changes.resync
+	ix.internalChanged = ix.internalChanged || changes.internal
+	for _, c := range changes.pins {
+		ix.changed.Add(c)
+	}
+	for _, c := range changes.bestEffort {
+		ix.bestEffortAdded.Add(c)
+	}
+}
+
+// finishCounting records that the references were counted.
+func (ix *Index) finishCounting() error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.state |= stateIndexed
+	if !ix.resync && !ix.internalChanged && ix.changed.Len() == 0 {
+		ix.state &^= stateChanged
+	}
+	return ix.saveState()
+}
+
+// setCounting records whether a root's references are partially saved.
+// If they are when a count is interrupted, the counts are started over by the next collection.
+func (ix *Index) setCounting(partial, interrupted bool) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if interrupted {
+		ix.rebuild = true
+		return nil // (the persisted state is left as is)
+	}
+	if partial {
+		ix.state |= stateCounting
+	} else {
+		ix.state &^= stateCounting
+	}
+	return ix.saveState()
+}
+
+// clear removes every count and counted root.
+func (ix *Index) clear() error {
+	for _, prefix := range []dstore.Key{refsPrefix, unlinkedPrefix, rootsPrefix} {
+		keys, err := ix.keys(prefix)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := ix.ds.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.state &^= stateIndexed | stateCounting // (the roots are counted again by a resync)
+	return ix.saveState()
+}
+
+func (ix *Index) keys(prefix dstore.Key) ([]dstore.Key, error) {
+	results, err := ix.ds.Query(dsq.Query{Prefix: prefix.String(), KeysOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := results.Rest()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]dstore.Key, len(entries))
+	for i, entry := range entries {
+		keys[i] = dstore.RawKey(entry.Key)
+	}
+	return keys, nil
+}
+
+// roots returns the counted roots of the set.
+func (ix *Index) roots(s rootSet) ([]cid.Cid, error) {
+	keys, err := ix.keys(s.prefix())
+	if err != nil {
+		return nil, err
+	}
+	roots := make([]cid.Cid, len(keys))
+	for i, key := range keys {
+		if roots[i], err = dshelp.DsKeyToCid(dstore.NewKey(key.BaseNamespace())); err != nil {
+			return nil, err
+		}
+	}
+	return roots, nil
+}
+
+// unlinked returns the blocks whose links couldn't be counted,
+// and the policy of the DAGs they're part of.
+func (ix *Index) unlinked() (map[cid.Cid]linkPolicy, error) {
+	results, err := ix.ds.Query(dsq.Query{Prefix: unlinkedPrefix.String()})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := results.Rest()
+	if err != nil {
+		return nil, err
+	}
+	blocks := make(map[cid.Cid]linkPolicy, len(entries))
+	for _, entry := range entries {
+		c, err := dshelp.DsKeyToCid(dstore.NewKey(dstore.RawKey(entry.Key).BaseNamespace()))
+		if err != nil {
+			return nil, err
+		}
+		blocks[c] = linkPolicy(len(entry.Value) == 1 && entry.Value[0] != 0)
+	}
+	return blocks, nil
+}
+
+func refKey(c cid.Cid) dstore.Key {
+	return refsPrefix.Child(dshelp.CidToDsKey(c))
+}
+
+func unlinkedKey(c cid.Cid) dstore.Key {
+	return unlinkedPrefix.Child(dshelp.CidToDsKey(c))
+}
+
+// ref is the number of references to a block.
+type ref struct {
+	trees  uint64 // references that retain the block's DAG (recursive roots, and links from retained DAGs)
+	blocks uint64 // references that only retain the block (direct pins)
+	linked bool   // the block's links are counted as references (while trees > 0)
+}
+
+// retained reports whether anything references the block.
+func (ix *Index) retained(c cid.Cid) (bool, error) {
+	return ix.ds.Has(refKey(c))
+}
+
+func (ix *Index) ref(c cid.Cid) (ref, error) {
+	return decodeRef(c, ix.ds.Get)
+}
+
+func decodeRef(c cid.Cid, get func(dstore.Key) ([]byte, error)) (ref, error) {
+	value, err := get(refKey(c))
+	switch err {
+	case nil:
+	case dstore.ErrNotFound:
+		return ref{}, nil
+	default:
+		return ref{}, err
+	}
+
+	trees, treesRead := binary.Uvarint(value)
+	if treesRead > 0 {
+		blocks, blocksRead := binary.Uvarint(value[treesRead:])
+		if blocksRead > 0 && len(value) == treesRead+blocksRead+1 {
+			return ref{trees: trees, blocks: blocks, linked: value[len(value)-1] != 0}, nil
+		}
+	}
+	return ref{}, fmt.Errorf("GC index reference count of %s is corrupt (%x)", c, value)
+}
+
+func (cn *counter) ref(c cid.Cid) (ref, error) {
+	return decodeRef(c, cn.get)
+}
+
+func (cn *counter) putRef(c cid.Cid, r ref) error {
+	if r.trees == 0 && r.blocks == 0 {
+		return cn.delete(refKey(c))
+	}
+	value := make([]byte, 2*binary.MaxVarintLen64+1)
+	n := binary.PutUvarint(value, r.trees)
+	n += binary.PutUvarint(value[n:], r.blocks)
+	if r.linked {
+		value[n] = 1
+	}
+	return cn.put(refKey(c), value[:n+1])
+}
+
+type linkPolicy bool
+
+const (
+	strictLinks     linkPolicy = true  // missing blocks are errors
+	bestEffortLinks linkPolicy = false // missing blocks are skipped
+)
+
+// counter changes the reference counts of blocks, as roots are added and removed.
+type counter struct {
+	ix *Index
+	// counted is called after each reference count changes (and may be nil)
+	counted func() error
+	// unlinked is called with the error of each block whose links couldn't be counted,
+	// within a strict DAG (and may be nil)
+	unlinked func(*CannotFetchLinksError) error
+
+	writes  map[dstore.Key]write // made by the root being counted (nil when none is)
+	partial bool                 // some of the root's writes were saved before it was counted
+}
+
+// write is a change to the index that hasn't been saved.
+type write struct {
+	value   []byte
+	deleted bool
+}
+
+func (ix *Index) newCounter(counted func() error, unlinked func(*CannotFetchLinksError) error) *counter {
+	return &counter{ix: ix, counted: counted, unlinked: unlinked}
+}
+
+// countRoot counts the changes made by fn as a single root:
+// its writes are kept in memory, and saved together once it returns,
+// so if it's interrupted, the index is left as it was before it.
+// (roots that make more changes than the index keeps in memory save them as they're counted,
+// and if they're interrupted, the counts are started over by the next collection)
+func (cn *counter) countRoot(fn func() error) error {
+	cn.writes, cn.partial = make(map[dstore.Key]write), false
+	err := fn()
+	if err == nil {
+		err = cn.save()
+	}
+	partial := cn.partial
+	cn.writes, cn.partial = nil, false
+
+	if !partial {
+		return err
+	}
+	if stateErr := cn.ix.setCounting(false, err != nil); err == nil {
+		return stateErr
+	}
+	return err
+}
+
+// save saves the writes of the root being counted.
+func (cn *counter) save() error {
+	if len(cn.writes) == 0 {
+		return nil
+	}
+	var (
+		batch dstore.Batch
+		err   = dstore.ErrBatchUnsupported
+	)
+	if batching, ok := cn.ix.ds.(dstore.Batching); ok {
+		batch, err = batching.Batch()
+	}
+	switch err {
+	case nil:
+	case dstore.ErrBatchUnsupported:
+		// the writes can't be saved together
+		if err := cn.setPartial(); err != nil {
+			return err
+		}
+		batch = dstore.NewBasicBatch(cn.ix.ds)
+	default:
+		return err
+	}
+
+	for key, w := range cn.writes {
+		if w.deleted {
+			err = batch.Delete(key)
+		} else {
+			err = batch.Put(key, w.value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		return err
+	}
+	cn.writes = make(map[dstore.Key]write)
+	return nil
+}
+
+func (cn *counter) setPartial() error {
+	if cn.partial {
+		return nil
+	}
+	cn.partial = true
+	return cn.ix.setCounting(true, false)
+}
+
+func (cn *counter) get(key dstore.Key) ([]byte, error) {
+	if w, ok := cn.writes[key]; ok {
+		if w.deleted {
+			return nil, dstore.ErrNotFound
+		}
+		return w.value, nil
+	}
+	return cn.ix.ds.Get(key)
+}
+
+func (cn *counter) has(key dstore.Key) (bool, error) {
+	if w, ok := cn.writes[key]; ok {
+		return !w.deleted, nil
+	}
+	return cn.ix.ds.Has(key)
+}
+
+func (cn *counter) put(key dstore.Key, value []byte) error {
+	if cn.writes == nil {
+		return cn.ix.ds.Put(key, value)
+	}
+	cn.writes[key] = write{value: value}
+	return cn.spill()
+}
+
+func (cn *counter) delete(key dstore.Key) error {
+	if cn.writes == nil {
+		return cn.ix.ds.Delete(key)
+	}
+	cn.writes[key] = write{deleted: true}
+	return cn.spill()
+}
+
+// spill saves the writes of the root being counted, if there are too many to keep in memory.
+func (cn *counter) spill() error {
+	if len(cn.writes) < cn.ix.maxRootWrites {
+		return nil
+	}
+	if err := cn.setPartial(); err != nil {
+		return err
+	}
+	return cn.save()
+}
+
+func (cn *counter) afterCount() error {
+	if cn.counted == nil {
+		return nil
+	}
+	return cn.counted()
+}
+
+// addRoot counts the root, if it wasn't already.
+func (cn *counter) addRoot(ctx context.Context, s rootSet, c cid.Cid) error {
+	return cn.countRoot(func() error {
+		counted, err := cn.has(s.key(c))
+		if err != nil || counted {
+			return err
+		}
+		if s.tree {
+			err = cn.addTree(ctx, c, s.policy)
+		} else {
+			err = cn.addBlock(c)
+		}
+		if err != nil {
+			return err
+		}
+		return cn.put(s.key(c), nil)
+	})
+}
+
+// removeRoot uncounts the root, if it was counted.
+func (cn *counter) removeRoot(ctx context.Context, s rootSet, c cid.Cid) error {
+	return cn.countRoot(func() error {
+		counted, err := cn.has(s.key(c))
+		if err != nil || !counted {
+			return err
+		}
+		if s.tree {
+			err = cn.removeTree(ctx, c)
+		} else {
+			err = cn.removeBlock(c)
+		}
+		if err != nil {
+			return err
+		}
+		return cn.delete(s.key(c))
+	})
+}
+
+// replaceRoots counts the roots of the set that weren't counted,
+// and uncounts the ones that aren't within it anymore.
+// (New roots are counted first, so DAGs they share with the old ones aren't walked.)
+func (cn *counter) replaceRoots(ctx context.Context, s rootSet, roots []cid.Cid) error {
+	counted, err := cn.ix.roots(s)
+	if err != nil {
+		return err
+	}
+	current := cid.NewSet()
+	for _, c := range roots {
+		current.Add(c)
+		if err := cn.addRoot(ctx, s, c); err != nil {
+			return err
+		}
+	}
+	for _, c := range counted {
+		if current.Has(c) {
+			continue
+		}
+		if err := cn.removeRoot(ctx, s, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updatePins compares the pins of the blocks with the counted roots.
+func (cn *counter) updatePins(ctx context.Context, pn pin.Pinner, changed []cid.Cid) error {
+	type root struct {
+		set rootSet
+		cid cid.Cid
+	}
+	var unpinned []root
+	for _, c := range changed {
+		for _, s := range []rootSet{recursiveSet, directSet} {
+			_, pinned, err := pn.IsPinnedWithType(ctx, c, s.mode)
+			if err != nil {
+				return err
+			}
+			if !pinned {
+				unpinned = append(unpinned, root{s, c})
+				continue
+			}
+			if err := cn.addRoot(ctx, s, c); err != nil {
+				return err
+			}
+		}
+	}
+	for _, r := range unpinned {
+		if err := cn.removeRoot(ctx, r.set, r.cid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resyncPins compares every pin with the counted roots.
+func (cn *counter) resyncPins(ctx context.Context, pn pin.Pinner) error {
+	rkeys, err := pn.RecursiveKeys(ctx)
+	if err != nil {
+		return err
+	}
+	if err := cn.replaceRoots(ctx, recursiveSet, rkeys); err != nil {
+		return err
+	}
+	dkeys, err := pn.DirectKeys(ctx)
+	if err != nil {
+		return err
+	}
+	return cn.replaceRoots(ctx, directSet, dkeys)
+}
+
+// relink counts the links of the blocks that couldn't be counted before
+// (such as blocks that were missing when they were first referenced).
+func (cn *counter) relink(ctx context.Context) error {
+	blocks, err := cn.ix.unlinked()
+	if err != nil {
+		return err
+	}
+	for c, policy := range blocks {
+		if err := cn.countRoot(func() error { return cn.relinkBlock(ctx, c, policy) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cn *counter) relinkBlock(ctx context.Context, c cid.Cid, policy linkPolicy) error {
+	r, err := cn.ref(c)
+	if err != nil {
+		return err
+	}
+	if r.linked || r.trees == 0 { // (shouldn't be recorded)
+		return cn.delete(unlinkedKey(c))
+	}
+	if r.linked, err = cn.link(ctx, c, policy, true); err != nil || !r.linked {
+		return err
+	}
+	if err := cn.putRef(c, r); err != nil {
+		return err
+	}
+	return cn.afterCount()
+}
+
+func (cn *counter) addBlock(c cid.Cid) error {
+	r, err := cn.ref(c)
+	if err != nil {
+		return err
+	}
+	r.blocks++
+	if err := cn.putRef(c, r); err != nil {
+		return err
+	}
+	return cn.afterCount()
+}
+
+func (cn *counter) removeBlock(c cid.Cid) error {
+	r, err := cn.ref(c)
+	if err != nil {
+		return err
+	}
+	if r.blocks == 0 {
+		log.Warnf("GC index: %s was not referenced as a block", c)
+		return nil
+	}
+	r.blocks--
+	if err := cn.putRef(c, r); err != nil {
+		return err
+	}
+	return cn.afterCount()
+}
+
+// addTree references the block's DAG.
+// The links of blocks that weren't referenced yet are counted too,
+// so only the parts of the DAG that weren't retained already are walked.
+func (cn *counter) addTree(ctx context.Context, c cid.Cid, policy linkPolicy) error {
+	r, err := cn.ref(c)
+	if err != nil {
+		return err
+	}
+	wasUnlinked := r.trees != 0 && !r.linked
+	r.trees++
+	if !r.linked {
+		if r.linked, err = cn.link(ctx, c, policy, wasUnlinked); err != nil {
+			return err
+		}
+	}
+	if err := cn.putRef(c, r); err != nil {
+		return err
+	}
+	return cn.afterCount()
+}
+
+// link counts the links of the block as references to their DAGs.
+// If they can't be fetched, the block is recorded as unlinked
+// (so that they're counted when it's present), and false is returned.
+func (cn *counter) link(ctx context.Context, c cid.Cid, policy linkPolicy, wasUnlinked bool) (bool, error) {
+	links, err := cn.getLinks(ctx, c)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return false, cn.recordUnlinked(c, policy, err)
+	}
+	for _, link := range links {
+		if err := cn.addTree(ctx, link.Cid, policy); err != nil {
+			return false, err
+		}
+	}
+	if wasUnlinked {
+		return true, cn.delete(unlinkedKey(c))
+	}
+	return true, nil
+}
+
+func (cn *counter) recordUnlinked(c cid.Cid, policy linkPolicy, err error) error {
+	if policy == bestEffortLinks {
+		// (a strict DAG may reference the block too)
+		previous, getErr := cn.get(unlinkedKey(c))
+		switch getErr {
+		case nil:
+			if len(previous) == 1 && previous[0] != 0 {
+				policy = strictLinks
+			}
+		case dstore.ErrNotFound:
+		default:
+			return getErr
+		}
+	}
+	var value byte
+	if policy == strictLinks {
+		value = 1
+	}
+	if putErr := cn.put(unlinkedKey(c), []byte{value}); putErr != nil {
+		return putErr
+	}
+
+	if cn.unlinked == nil || (policy == bestEffortLinks && errors.Is(err, ipld.ErrNotFound)) {
+		return nil
+	}
+	fetchErr, ok := err.(*CannotFetchLinksError)
+	if !ok {
+		fetchErr = &CannotFetchLinksError{c, err}
+	}
+	return cn.unlinked(fetchErr)
+}
+
+// removeTree removes a reference to the block's DAG.
+// The links of blocks that aren't referenced anymore are uncounted too.
+func (cn *counter) removeTree(ctx context.Context, c cid.Cid) error {
+	r, err := cn.ref(c)
+	if err != nil {
+		return err
+	}
+	if r.trees == 0 {
+		log.Warnf("GC index: %s was not referenced as a DAG", c)
+		return nil
+	}
+	r.trees--
+	if r.trees == 0 {
+		if r.linked {
+			r.linked = false
+			if err := cn.unlink(ctx, c); err != nil {
+				return err
+			}
+		} else if err := cn.delete(unlinkedKey(c)); err != nil {
+			return err
+		}
+	}
+	if err := cn.putRef(c, r); err != nil {
+		return err
+	}
+	return cn.afterCount()
+}
+
+func (cn *counter) unlink(ctx context.Context, c cid.Cid) error {
+	links, err := cn.getLinks(ctx, c)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// (linked blocks are retained, unless removed by something other than a collection)
+		log.Warnf("GC index: the links of %s can't be uncounted, its DAG will be retained: %s", c, err)
+		return nil
+	}
+	for _, link := range links {
+		if err := cn.removeTree(ctx, link.Cid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cn *counter) getLinks(ctx context.Context, c cid.Cid) ([]*ipld.Link, error) {
+	if err := verifcid.ValidateCid(c); err != nil {
+		return nil, &CannotFetchLinksError{c, err}
+	}
+	links, err := ipld.GetLinks(ctx, cn.ix.ng, c)
+	if err != nil && !errors.Is(err, ipld.ErrNotFound) && ctx.Err() == nil {
+		return nil, &CannotFetchLinksError{c, err}
+	}
+	return links, err
+}
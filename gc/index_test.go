@@ -0,0 +1,405 @@
+package gc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	bserv "github.com/ipfs/go-blockservice"
+	cid "github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	pin "github.com/ipfs/go-ipfs-pinner"
+	"github.com/ipfs/go-ipfs-pinner/dspinner"
+	ipld "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
+)
+
+// yieldingBlockstore lists the given keys of its blocks last,
+// and calls yielded (if it's not nil) the first time a sweep takes the GC lock again
+type yieldingBlockstore struct {
+	bstore.GCBlockstore
+	last     *cid.Set
+	yielded  func()
+	sweeping bool
+}
+
+func (bs *yieldingBlockstore) GCLock() bstore.Unlocker {
+	if bs.sweeping && bs.yielded != nil {
+		bs.yielded()
+		bs.yielded = nil
+	}
+	return bs.GCBlockstore.GCLock()
+}
+
+func (bs *yieldingBlockstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	bs.sweeping = true
+	keys, err := bs.GCBlockstore.AllKeysChan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ordered := make(chan cid.Cid)
+	go func() {
+		defer close(ordered)
+		var last []cid.Cid
+		for k := range keys {
+			if bs.last.Has(k) {
+				last = append(last, k)
+				continue
+			}
+			select {
+			case ordered <- k:
+			case <-ctx.Done():
+				return
+			}
+		}
+		for _, k := range last {
+			select {
+			case ordered <- k:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ordered, nil
+}
+
+// cancellingBlockstore calls cancel the given number of times it's locked
+type cancellingBlockstore struct {
+	bstore.GCBlockstore
+	locks  int
+	cancel func()
+}
+
+func (bs *cancellingBlockstore) GCLock() bstore.Unlocker {
+	if bs.locks--; bs.locks == 0 {
+		bs.cancel()
+	}
+	return bs.GCBlockstore.GCLock()
+}
+
+func TestIncrementalCollection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ds := dssync.MutexWrap(dstore.NewMapDatastore())
+	bs := bstore.NewGCBlockstore(bstore.NewBlockstore(ds), bstore.NewGCLocker())
+	dserv := dag.NewDAGService(bserv.New(bs, offline.Exchange(bs)))
+	pinner, err := dspinner.New(ctx, ds, dserv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := NewIndex(ds, bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index.BatchSize = 1 // yield after every block
+	pn := IndexPinner(pinner, index)
+
+	// newTree returns a node with a single child
+	newTree := func(t *testing.T, name string) (root, child *dag.ProtoNode) {
+		child = dag.NodeWithData([]byte(name + " child"))
+		root = dag.NodeWithData([]byte(name))
+		if err := root.AddNodeLink("child", child); err != nil {
+			t.Fatal(err)
+		}
+		return root, child
+	}
+	addTree := func(t *testing.T, name string) ipld.Node {
+		root, child := newTree(t, name)
+		if err := dserv.AddMany(ctx, []ipld.Node{child, root}); err != nil {
+			t.Fatal(err)
+		}
+		return root
+	}
+	// collect returns the blocks that were removed, and the final progress
+	collect := func(t *testing.T, index *Index, bs bstore.GCBlockstore, pn pin.Pinner, bestEffortRoots []cid.Cid) (*cid.Set, Progress, error) {
+		removed := cid.NewSet()
+		var (
+			progress Progress
+			err      error
+		)
+		for res := range index.Collect(ctx, bs, ds, pn, bestEffortRoots) {
+			switch {
+			case res.Error != nil:
+				err = res.Error
+			case res.Progress != nil:
+				progress = *res.Progress
+			default:
+				removed.Add(res.KeyRemoved)
+			}
+		}
+		return removed, progress, err
+	}
+	expectCollection := func(t *testing.T, marked, removed uint64, bestEffortRoots ...cid.Cid) {
+		t.Helper()
+		removedSet, progress, err := collect(t, index, bs, pn, bestEffortRoots)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if progress.Marked != marked || progress.Removed != removed || uint64(removedSet.Len()) != removed {
+			t.Errorf("expected %d marked and %d removed, got progress: %+v (%d results)",
+				marked, removed, progress, removedSet.Len())
+		}
+	}
+	expectPresent := func(t *testing.T, expected bool, cids ...cid.Cid) {
+		t.Helper()
+		for _, c := range cids {
+			has, err := bs.Has(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if has != expected {
+				t.Errorf("%s: expected present: %t, got: %t", c, expected, has)
+			}
+		}
+	}
+	treeCids := func(root ipld.Node) []cid.Cid { return []cid.Cid{root.Cid(), root.Links()[0].Cid} }
+
+	pinned, unpinned := addTree(t, "pinned"), addTree(t, "unpinned")
+	if err := pn.Pin(ctx, pinned, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first collection counts every root
+	expectCollection(t, 2, 2)
+	expectPresent(t, true, treeCids(pinned)...)
+	expectPresent(t, false, treeCids(unpinned)...)
+
+	// and later collections only count the roots that changed
+	added, unpinned := addTree(t, "added"), addTree(t, "unpinned again")
+	if err := pn.Pin(ctx, added, true); err != nil {
+		t.Fatal(err)
+	}
+	expectCollection(t, 2, 2)
+	expectPresent(t, true, append(treeCids(pinned), treeCids(added)...)...)
+	expectPresent(t, false, treeCids(unpinned)...)
+
+	if err := pn.Unpin(ctx, pinned.Cid(), true); err != nil {
+		t.Fatal(err)
+	}
+	expectCollection(t, 2, 2)
+	expectPresent(t, false, treeCids(pinned)...)
+	expectPresent(t, true, treeCids(added)...)
+
+	// best-effort roots are counted by every collection, even if the index wasn't told of them
+	mfsRoot := addTree(t, "mfs")
+	expectCollection(t, 2, 0, mfsRoot.Cid())
+	expectPresent(t, true, treeCids(mfsRoot)...)
+
+	// and replaced roots only have the blocks they don't share uncounted
+	mfsChild := mfsRoot.Links()[0].Cid
+	childNode, err := dserv.Get(ctx, mfsChild)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newMFSRoot := dag.NodeWithData([]byte("new mfs"))
+	if err := newMFSRoot.AddNodeLink("child", childNode); err != nil {
+		t.Fatal(err)
+	}
+	if err := dserv.Add(ctx, newMFSRoot); err != nil {
+		t.Fatal(err)
+	}
+	expectCollection(t, 4, 1, newMFSRoot.Cid())
+	expectPresent(t, false, mfsRoot.Cid())
+	expectPresent(t, true, newMFSRoot.Cid(), mfsChild)
+
+	t.Run("pinned during a collection", func(t *testing.T) {
+		root := addTree(t, "pinned during")
+		unpinned := addTree(t, "unpinned during")
+		last := cid.NewSet()
+		for _, c := range treeCids(root) {
+			last.Add(c)
+		}
+		yieldingBs := &yieldingBlockstore{GCBlockstore: bs, last: last, yielded: func() {
+			// (as adds do)
+			defer bs.PinLock().Unlock()
+			if err := pn.Pin(ctx, root, true); err != nil {
+				t.Error(err)
+			}
+		}}
+
+		_, _, err := collect(t, index, yieldingBs, pn, []cid.Cid{newMFSRoot.Cid()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectPresent(t, true, treeCids(root)...)
+		expectPresent(t, false, treeCids(unpinned)...)
+	})
+
+	t.Run("missing blocks", func(t *testing.T) {
+		root, child := newTree(t, "incomplete")
+		if err := dserv.Add(ctx, root); err != nil {
+			t.Fatal(err)
+		}
+		pn.PinWithMode(root.Cid(), pin.Recursive) // (without fetching the DAG)
+		if err := pn.Flush(ctx); err != nil {
+			t.Fatal(err)
+		}
+		unpinned := addTree(t, "unpinned while incomplete")
+
+		// nothing is swept while a pinned DAG is incomplete
+		for i := 0; i < 2; i++ {
+			if _, _, err := collect(t, index, bs, pn, []cid.Cid{newMFSRoot.Cid()}); !errors.Is(err, ErrCannotFetchAllLinks) {
+				t.Errorf("expected %v, got: %v", ErrCannotFetchAllLinks, err)
+			}
+			expectPresent(t, true, treeCids(unpinned)...)
+		}
+
+		// and the DAG is counted once it's complete
+		if err := dserv.Add(ctx, child); err != nil {
+			t.Fatal(err)
+		}
+		expectCollection(t, 1, 2, newMFSRoot.Cid())
+		expectPresent(t, true, root.Cid(), child.Cid())
+		expectPresent(t, false, treeCids(unpinned)...)
+	})
+
+	expectRefs := func(t *testing.T, index *Index, trees uint64, cids ...cid.Cid) {
+		t.Helper()
+		for _, c := range cids {
+			r, err := index.ref(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.trees != trees {
+				t.Errorf("expected %d references to %s, got %d", trees, c, r.trees)
+			}
+		}
+	}
+	// interrupt cancels a collection after it counts the first block
+	// (the cancellation isn't always reported, as the output is abandoned)
+	interrupt := func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		cancellingBs := &cancellingBlockstore{GCBlockstore: bs, locks: 2, cancel: cancel}
+		for range index.Collect(ctx, cancellingBs, ds, pn, []cid.Cid{newMFSRoot.Cid()}) {
+		}
+	}
+	rebuilding := func(index *Index) bool {
+		index.mu.Lock()
+		defer index.mu.Unlock()
+		return index.rebuild
+	}
+
+	t.Run("interrupted", func(t *testing.T) {
+		root := addTree(t, "pinned before interrupting")
+		if err := pn.Pin(ctx, root, true); err != nil {
+			t.Fatal(err)
+		}
+		interrupt(t)
+
+		// the root that was being counted is left as it was, and is counted by the next collection
+		if rebuilding(index) {
+			t.Error("an interrupted count is started over")
+		}
+		if !index.hasChanges() {
+			t.Error("changes that weren't counted were lost")
+		}
+		expectRefs(t, index, 0, treeCids(root)...)
+
+		expectCollection(t, 2, 0, newMFSRoot.Cid())
+		expectRefs(t, index, 1, treeCids(root)...)
+		expectPresent(t, true, treeCids(root)...)
+	})
+
+	t.Run("interrupted while saving", func(t *testing.T) {
+		root := addTree(t, "pinned before interrupting a partial save")
+		if err := pn.Pin(ctx, root, true); err != nil {
+			t.Fatal(err)
+		}
+		// roots that change more than the index keeps in memory can't be left as they were
+		index.maxRootWrites = 1
+		interrupt(t)
+		index.maxRootWrites = defaultMaxRootWrites
+		if !rebuilding(index) {
+			t.Error("a partially saved count isn't started over")
+		}
+		if state, err := index.ds.Get(stateKey); err != nil || state[0]&stateCounting == 0 {
+			t.Errorf("a partially saved count isn't recorded (%x): %v", state, err)
+		}
+
+		if _, _, err := collect(t, index, bs, pn, []cid.Cid{newMFSRoot.Cid()}); err != nil {
+			t.Fatal(err)
+		}
+		expectRefs(t, index, 1, treeCids(root)...)
+		expectRefs(t, index, 1, mfsChild)
+	})
+
+	t.Run("best-effort root added during a collection", func(t *testing.T) {
+		// roots added between collections are left to the next one
+		index.BestEffortRootAdded(mfsRoot.Cid())
+		if index.hasChanges() {
+			t.Error("a best-effort root was recorded outside of a collection")
+		}
+
+		flushed := addTree(t, "flushed during")
+		last := cid.NewSet()
+		for _, c := range treeCids(flushed) {
+			last.Add(c)
+		}
+		yieldingBs := &yieldingBlockstore{GCBlockstore: bs, last: last, yielded: func() {
+			index.BestEffortRootAdded(flushed.Cid())
+		}}
+		if _, _, err := collect(t, index, yieldingBs, pn, []cid.Cid{newMFSRoot.Cid()}); err != nil {
+			t.Fatal(err)
+		}
+		expectPresent(t, true, treeCids(flushed)...)
+
+		// and uncounted by the next collection, if they're replaced
+		expectCollection(t, 2, 2, newMFSRoot.Cid())
+		expectPresent(t, false, treeCids(flushed)...)
+	})
+
+	t.Run("reopened", func(t *testing.T) {
+		root := addTree(t, "pinned before reopening")
+		if err := pn.Pin(ctx, root, true); err != nil {
+			t.Fatal(err)
+		}
+
+		// changes that weren't counted are recovered from the pinner
+		reopened, err := NewIndex(ds, bs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reopened.hasChanges() {
+			t.Error("changes before reopening the index were lost")
+		}
+		reopenedPn := IndexPinner(pinner, reopened)
+		if _, _, err := collect(t, reopened, bs, reopenedPn, []cid.Cid{newMFSRoot.Cid()}); err != nil {
+			t.Fatal(err)
+		}
+		expectPresent(t, true, treeCids(root)...)
+
+		if reopened, err = NewIndex(ds, bs); err != nil {
+			t.Fatal(err)
+		}
+		if reopened.hasChanges() {
+			t.Error("the index has changes after reopening it, even though they were counted")
+		}
+
+		// and counts that weren't saved entirely are started over
+		if err := reopened.ds.Put(stateKey, []byte{stateIndexed | stateCounting}); err != nil {
+			t.Fatal(err)
+		}
+		if reopened, err = NewIndex(ds, bs); err != nil {
+			t.Fatal(err)
+		}
+		unpinned := addTree(t, "unpinned after reopening")
+		reopenedPn = IndexPinner(pinner, reopened)
+		if _, _, err := collect(t, reopened, bs, reopenedPn, []cid.Cid{newMFSRoot.Cid()}); err != nil {
+			t.Fatal(err)
+		}
+		expectPresent(t, true, append(treeCids(root), newMFSRoot.Cid(), mfsChild)...)
+		expectPresent(t, false, treeCids(unpinned)...)
+		r, err := reopened.ref(mfsChild)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.trees != 1 {
+			t.Errorf("expected 1 reference to %s after starting over, got %d", mfsChild, r.trees)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package gc
+
+import (
+	"context"
+
+	cid "github.com/ipfs/go-cid"
+	pin "github.com/ipfs/go-ipfs-pinner"
+	ipld "github.com/ipfs/go-ipld-format"
+)
+
+// indexedPinner notes the changes to pins in an Index.
+type indexedPinner struct {
+	pin.Pinner
+	index *Index
+}
+
+// IndexPinner wraps the pinner, so that the index is told when pins are added or removed.
+func IndexPinner(pn pin.Pinner, index *Index) pin.Pinner {
+	return &indexedPinner{Pinner: pn, index: index}
+}
+
+func (p *indexedPinner) Pin(ctx context.Context, node ipld.Node, recursive bool) error {
+	if err := p.Pinner.Pin(ctx, node, recursive); err != nil {
+		return err
+	}
+	p.index.PinChanged(node.Cid())
+	return nil
+}
+
+func (p *indexedPinner) Unpin(ctx context.Context, c cid.Cid, recursive bool) error {
+	if err := p.Pinner.Unpin(ctx, c, recursive); err != nil {
+		return err
+	}
+	p.index.PinChanged(c)
+	return nil
+}
+
+func (p *indexedPinner) Update(ctx context.Context, from, to cid.Cid, unpin bool) error {
+	if err := p.Pinner.Update(ctx, from, to, unpin); err != nil {
+		return err
+	}
+	p.index.PinChanged(to)
+	if unpin {
+		p.index.PinChanged(from)
+	}
+	return nil
+}
+
+func (p *indexedPinner) PinWithMode(c cid.Cid, mode pin.Mode) {
+	p.Pinner.PinWithMode(c, mode)
+	p.index.PinChanged(c)
+}
+
+func (p *indexedPinner) RemovePinWithMode(c cid.Cid, mode pin.Mode) {
+	p.Pinner.RemovePinWithMode(c, mode)
+	p.index.PinChanged(c)
+}
+
+func (p *indexedPinner) Flush(ctx context.Context) error {
+	if err := p.Pinner.Flush(ctx); err != nil {
+		return err
+	}
+	// the pinner's internal state may have been replaced
+	p.index.InternalPinsChanged()
+	return nil
+}
@@ -38,6 +38,12 @@ test_expect_success "'ipfs repo gc' doesn't remove file" '
   test_cmp out afile
 '
 
+test_expect_success "'ipfs repo gc --explain' reports the pin" '
+  ipfs repo gc --explain="$HASH" >explain_actual &&
+  echo "retained by recursive pin $HASH via /ipfs/$HASH" >explain_expected &&
+  test_cmp explain_expected explain_actual
+'
+
 test_expect_success "'ipfs pin rm' succeeds" '
   ipfs pin rm -r "$HASH" >actual1
 '
@@ -47,6 +53,21 @@ test_expect_success "'ipfs pin rm' output looks good" '
   test_cmp expected1 actual1
 '
 
+test_expect_success "'ipfs repo gc --explain' reports unpinned blocks" '
+  ipfs repo gc --explain="$HASH" >explain_actual &&
+  echo "$HASH is not retained by any pin or root" >explain_expected &&
+  test_cmp explain_expected explain_actual
+'
+
+test_expect_success "'ipfs repo gc --dry-run' reports the file without removing it" '
+  ipfs repo gc --dry-run --quiet >dry_run_actual &&
+  grep "$HASH" dry_run_actual &&
+  ipfs repo gc --dry-run >dry_run_summary &&
+  grep "^would remove [0-9]* blocks" dry_run_summary &&
+  ipfs cat "$HASH" >out &&
+  test_cmp out afile
+'
+
 test_expect_success "ipfs repo gc fully reverse ipfs add (part 1)" '
   ipfs repo gc &&
   random 100000 41 >gcfile &&